@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 interface{}            `json:"type,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Deprecated           bool                   `json:"deprecated,omitempty"`
+}
+
+type jsonSchemaRenderer struct{}
+
+func (jsonSchemaRenderer) render(docs []doc) {
+	b, err := renderJSONSchema(docs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error rendering JSON schema: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(string(b))
+}
+
+// jsonSchemaGoRenderer renders the JSON schema as a constant in a go source file,
+// so that it can be compiled into the kismatic binary.
+type jsonSchemaGoRenderer struct {
+	packageName string
+}
+
+func (r jsonSchemaGoRenderer) render(docs []doc) {
+	b, err := renderJSONSchema(docs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error rendering JSON schema: %v\n", err)
+		os.Exit(1)
+	}
+	lines := strings.Split(string(b), "\n")
+	quoted := make([]string, len(lines))
+	for i, l := range lines {
+		if i < len(lines)-1 {
+			l = l + "\n"
+		}
+		quoted[i] = strconv.Quote(l)
+	}
+	fmt.Println("// Code generated by gen-kismatic-ref-docs. DO NOT EDIT.")
+	fmt.Println()
+	fmt.Printf("package %s\n", r.packageName)
+	fmt.Println()
+	fmt.Println("// PlanJSONSchema is the JSON Schema document of the plan file")
+	fmt.Printf("const PlanJSONSchema = %s\n", strings.Join(quoted, " +\n\t"))
+}
+
+// builds the JSON schema document for the docs. The docs are expected to be in the
+// depth-first order returned by docForType, so that parents are seen before their children.
+func renderJSONSchema(docs []doc) ([]byte, error) {
+	root := &jsonSchema{
+		Schema:               jsonSchemaDraft,
+		Title:                "Plan",
+		Description:          "The installation plan of a Kubernetes cluster managed by KET",
+		Type:                 "object",
+		Properties:           map[string]*jsonSchema{},
+		AdditionalProperties: false,
+	}
+	// keep track of the object schema that holds the properties of each struct
+	objects := map[string]*jsonSchema{"": root}
+	for _, d := range docs {
+		parentPath, name := splitProperty(d.property)
+		parent, ok := objects[parentPath]
+		if !ok {
+			return nil, fmt.Errorf("parent of property %q was not found", d.property)
+		}
+		s, obj, err := schemaForDoc(d)
+		if err != nil {
+			return nil, err
+		}
+		parent.Properties[name] = s
+		if d.required {
+			parent.Required = append(parent.Required, name)
+		}
+		if obj != nil {
+			objects[d.property] = obj
+		}
+	}
+	return json.MarshalIndent(root, "", "  ")
+}
+
+func splitProperty(property string) (string, string) {
+	i := strings.LastIndex(property, ".")
+	if i == -1 {
+		return "", property
+	}
+	return property[:i], property[i+1:]
+}
+
+// returns the schema of the property, and if the property is a struct (or a list of structs),
+// the object schema that should hold the properties of the struct
+func schemaForDoc(d doc) (*jsonSchema, *jsonSchema, error) {
+	s := &jsonSchema{
+		Description: strings.TrimSpace(d.description),
+		Deprecated:  d.deprecated,
+	}
+	var obj *jsonSchema
+	typeName := d.propertyType
+	if strings.HasPrefix(typeName, "[]") {
+		typeName = strings.TrimPrefix(typeName, "[]")
+		item, err := schemaForType(typeName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("property %q: %v", d.property, err)
+		}
+		s.Type = "array"
+		s.Items = item
+		if isStruct(typeName) {
+			obj = item
+		}
+		return s, obj, nil
+	}
+	t, err := schemaForType(typeName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("property %q: %v", d.property, err)
+	}
+	s.Type = t.Type
+	s.Properties = t.Properties
+	s.AdditionalProperties = t.AdditionalProperties
+	if isStruct(typeName) {
+		obj = s
+	}
+	if d.nullable {
+		s.Type = []interface{}{s.Type, "null"}
+	}
+	if def, ok := defaultValue(d, typeName); ok {
+		s.Default = def
+	}
+	if len(d.options) > 0 {
+		// empty values are set to their defaults when the plan is read
+		s.Enum = []interface{}{""}
+		for _, o := range d.options {
+			s.Enum = append(s.Enum, o)
+		}
+	}
+	return s, obj, nil
+}
+
+func schemaForType(typeName string) (*jsonSchema, error) {
+	switch typeName {
+	case "string":
+		return &jsonSchema{Type: "string"}, nil
+	case "int":
+		return &jsonSchema{Type: "integer"}, nil
+	case "bool":
+		return &jsonSchema{Type: "boolean"}, nil
+	case "map[string]string":
+		return &jsonSchema{Type: "object", AdditionalProperties: &jsonSchema{Type: "string"}}, nil
+	}
+	if !isStruct(typeName) {
+		return nil, fmt.Errorf("unhandled type %q", typeName)
+	}
+	return &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}, AdditionalProperties: false}, nil
+}
+
+func defaultValue(d doc, typeName string) (interface{}, bool) {
+	def := strings.Trim(d.defaultValue, "'")
+	switch typeName {
+	case "bool":
+		if def == "" {
+			return false, true
+		}
+		b, err := strconv.ParseBool(def)
+		return b, err == nil
+	case "int":
+		i, err := strconv.Atoi(def)
+		return i, err == nil
+	case "string":
+		// 'empty' and '' are used to document an empty default
+		if def == "" || def == "empty" {
+			return nil, false
+		}
+		return def, true
+	}
+	return nil, false
+}
@@ -13,6 +13,7 @@ import (
 )
 
 var output = flag.String("o", "", "the output mode")
+var goPackage = flag.String("package", "install", "the package name of the generated go file, used by the jsonschema-go output mode")
 
 type doc struct {
 	property     string
@@ -22,6 +23,8 @@ type doc struct {
 	options      []string
 	required     bool
 	deprecated   bool
+	// nullable is true when the property is a pointer
+	nullable bool
 }
 
 func main() {
@@ -40,6 +43,10 @@ func main() {
 		r = markdown{}
 	case "markdown-table":
 		r = markdownTable{}
+	case "jsonschema":
+		r = jsonSchemaRenderer{}
+	case "jsonschema-go":
+		r = jsonSchemaGoRenderer{packageName: *goPackage}
 	default:
 		fmt.Fprintf(os.Stderr, "unknown output type: %s\n", *output)
 		os.Exit(1)
@@ -105,6 +112,7 @@ func docForType(typeName string, allTypes []*godoc.Type, parentFieldName string)
 						if err != nil {
 							panic(err)
 						}
+						d.nullable = true
 						docs = append(docs, d)
 						if isStruct(typeName) {
 							docs = append(docs, docForType(typeName, allTypes, fieldName)...)
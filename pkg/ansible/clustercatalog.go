@@ -78,6 +78,10 @@ type ClusterCatalog struct {
 	DiagnosticsDirectory string `yaml:"diagnostics_dir"`
 	DiagnosticsDateTime  string `yaml:"diagnostics_date_time"`
 
+	// etcd snapshot and restore vars
+	EtcdSnapshotName      string `yaml:"etcd_snapshot_name"`
+	EtcdSnapshotDirectory string `yaml:"etcd_snapshot_dir"`
+
 	Docker struct {
 		Enabled bool
 		Logs    struct {
@@ -202,6 +206,23 @@ func (c *ClusterCatalog) EnableRestart() {
 	c.ForceDockerRestart = true
 }
 
+// redactedSecretValue replaces the secrets of the cluster catalogs recorded in the run directory
+const redactedSecretValue = "<redacted>"
+
+// Redacted returns a copy of the cluster catalog without the secrets
+func (c ClusterCatalog) Redacted() ClusterCatalog {
+	redact := func(s *string) {
+		if *s != "" {
+			*s = redactedSecretValue
+		}
+	}
+	redact(&c.AdminPassword)
+	redact(&c.DockerRegistryPassword)
+	redact(&c.CloudConfig)
+	redact(&c.CNI.Options.Weave.Password)
+	return c
+}
+
 func (c *ClusterCatalog) ToYAML() ([]byte, error) {
 	bytez, marshalErr := yaml.Marshal(c)
 	if marshalErr != nil {
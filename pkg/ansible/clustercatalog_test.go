@@ -0,0 +1,28 @@
+package ansible
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterCatalogRedacted(t *testing.T) {
+	cc := ClusterCatalog{
+		AdminPassword:          "adminsecret",
+		DockerRegistryPassword: "registrysecret",
+		CloudConfig:            "/etc/cloud.conf",
+	}
+	cc.CNI.Options.Weave.Password = "weavesecret"
+	r := cc.Redacted()
+	b, err := r.ToYAML()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, secret := range []string{"adminsecret", "registrysecret", "/etc/cloud.conf", "weavesecret"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("expected redacted cluster catalog not to contain %q", secret)
+		}
+	}
+	if cc.AdminPassword != "adminsecret" || cc.CNI.Options.Weave.Password != "weavesecret" {
+		t.Errorf("expected the cluster catalog not to be modified")
+	}
+}
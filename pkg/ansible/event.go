@@ -1,12 +1,35 @@
 package ansible
 
+import "time"
+
 // Event produced by Ansible when running a playbook
 type Event interface {
 	// Type is the name of the event type
 	Type() string
 }
 
+// TimedEvent is an event that carries the time it was produced. The time is only known for
+// the events that are replayed from a recorded event stream, and is zero otherwise.
+type TimedEvent interface {
+	Event
+	Time() time.Time
+}
+
+type eventTime struct {
+	time time.Time
+}
+
+// Time returns the time the event was produced, if it is known
+func (e eventTime) Time() time.Time {
+	return e.time
+}
+
+func (e *eventTime) setTime(t time.Time) {
+	e.time = t
+}
+
 type namedEvent struct {
+	eventTime
 	Name string
 }
 
@@ -29,6 +52,7 @@ type runnerResult struct {
 }
 
 type runnerResultEvent struct {
+	eventTime
 	Host         string
 	Result       runnerResult
 	IgnoreErrors bool
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/util"
 )
@@ -41,6 +42,8 @@ func EventStream(in io.Reader) <-chan Event {
 type eventEnvelope struct {
 	Type string      `json:"eventType"`
 	Data interface{} `json:"eventData"`
+	// Time is added to the events that are recorded by the runner
+	Time time.Time `json:"time"`
 }
 
 func eventFromJSONLine(line []byte) (Event, error) {
@@ -53,8 +56,21 @@ func eventFromJSONLine(line []byte) (Event, error) {
 		return nil, fmt.Errorf("error parsing event: %v\nline was:\n%s\n", err, string(line))
 	}
 
-	// Unmarshal the data according to the event type
-	switch env.Type {
+	e, err := eventOfType(env.Type, data, line)
+	if err != nil {
+		return nil, err
+	}
+	if t, ok := e.(interface {
+		setTime(time.Time)
+	}); ok {
+		t.setTime(env.Time)
+	}
+	return e, nil
+}
+
+// eventOfType unmarshals the data of the event according to the event type
+func eventOfType(eventType string, data json.RawMessage, line []byte) (Event, error) {
+	switch eventType {
 	case "PLAYBOOK_START":
 		e := &PlaybookStartEvent{}
 		if err := json.Unmarshal(data, e); err != nil {
@@ -128,6 +144,6 @@ func eventFromJSONLine(line []byte) (Event, error) {
 		}
 		return e, nil
 	default:
-		return nil, fmt.Errorf("unhandled ansible event type %q", env.Type)
+		return nil, fmt.Errorf("unhandled ansible event type %q", eventType)
 	}
 }
@@ -8,6 +8,9 @@ import (
 // Inventory is a collection of Nodes, keyed by role.
 type Inventory struct {
 	Roles []Role
+	// KnownHostsFile is the known_hosts file used for verifying the host keys of
+	// the nodes. Host keys are not verified if it is not set.
+	KnownHostsFile string
 }
 
 // Role is an Ansible role, containing nodes that belong to the role.
@@ -26,12 +29,45 @@ type Node struct {
 	PublicIP string
 	// InternalIP is the internal IP, if different from PublicIP.
 	InternalIP string
-	// SSHPrivateKey is the private key to be used for SSH authentication
+	// SSHPrivateKey is the private key to be used for SSH authentication.
+	// The keys of the ssh-agent are used when it is empty.
 	SSHPrivateKey string
 	// SSHPort is the SSH port number for connecting to the node
 	SSHPort int
 	// SSHUser is the SSH user for logging into the node
 	SSHUser string
+	// SSHBastion is the bastion through which the SSH connection to the node is
+	// proxied, or nil if the node is accessed directly.
+	SSHBastion *Bastion
+}
+
+// Bastion is a jump host for accessing nodes via SSH
+type Bastion struct {
+	// Host is the hostname or IP of the bastion
+	Host string
+	// SSHPrivateKey is the private key to be used for SSH authentication
+	SSHPrivateKey string
+	// SSHPort is the SSH port number for connecting to the bastion
+	SSHPort int
+	// SSHUser is the SSH user for logging into the bastion
+	SSHUser string
+}
+
+// proxyArgs returns the SSH arguments that proxy the connection through the bastion
+func (b Bastion) proxyArgs(knownHostsFile string) string {
+	var keyArg string
+	if b.SSHPrivateKey != "" {
+		keyArg = fmt.Sprintf(" -i %s", b.SSHPrivateKey)
+	}
+	return fmt.Sprintf(`-o ProxyCommand="ssh %s%s -p %d -W %%h:%%p %s@%s"`, hostKeyArgs(knownHostsFile), keyArg, b.SSHPort, b.SSHUser, b.Host)
+}
+
+// hostKeyArgs returns the SSH arguments for verifying host keys against the known_hosts file
+func hostKeyArgs(knownHostsFile string) string {
+	if knownHostsFile == "" {
+		return "-o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+	}
+	return fmt.Sprintf("-o StrictHostKeyChecking=yes -o UserKnownHostsFile=%s", knownHostsFile)
 }
 
 // ToINI converts the inventory into INI format
@@ -44,9 +80,21 @@ func (i Inventory) ToINI() []byte {
 			if n.InternalIP != "" {
 				internalIP = n.InternalIP
 			}
-			fmt.Fprintf(w, "%q ansible_host=%q internal_ipv4=%q ansible_ssh_private_key_file=%q ansible_port=%d ansible_user=%q\n", n.Host, n.PublicIP, internalIP, n.SSHPrivateKey, n.SSHPort, n.SSHUser)
+			fmt.Fprintf(w, "%q ansible_host=%q internal_ipv4=%q", n.Host, n.PublicIP, internalIP)
+			if n.SSHPrivateKey != "" {
+				fmt.Fprintf(w, " ansible_ssh_private_key_file=%q", n.SSHPrivateKey)
+			}
+			fmt.Fprintf(w, " ansible_port=%d ansible_user=%q", n.SSHPort, n.SSHUser)
+			if n.SSHBastion != nil {
+				fmt.Fprintf(w, " ansible_ssh_common_args=%q", n.SSHBastion.proxyArgs(i.KnownHostsFile))
+			}
+			fmt.Fprintln(w)
 		}
 	}
+	if i.KnownHostsFile != "" {
+		fmt.Fprintln(w, "[all:vars]")
+		fmt.Fprintf(w, "ansible_ssh_extra_args=%q\n", hostKeyArgs(i.KnownHostsFile))
+	}
 
 	return w.Bytes()
 }
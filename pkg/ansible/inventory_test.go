@@ -70,3 +70,84 @@ func TestInventoryINIGeneration(t *testing.T) {
 	}
 
 }
+
+func TestInventoryINIGenerationWithBastion(t *testing.T) {
+	inv := Inventory{
+		Roles: []Role{
+			{
+				Name: "worker",
+				Nodes: []Node{
+					{
+						Host:          "worker01",
+						PublicIP:      "10.0.0.3",
+						SSHPrivateKey: "/keys/id_rsa",
+						SSHPort:       22,
+						SSHUser:       "alice",
+						SSHBastion: &Bastion{
+							Host:          "bastion.example.com",
+							SSHPrivateKey: "/keys/bastion",
+							SSHPort:       2222,
+							SSHUser:       "jump",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ini := string(inv.ToINI())
+
+	expected := `[worker]
+"worker01" ansible_host="10.0.0.3" internal_ipv4="10.0.0.3" ansible_ssh_private_key_file="/keys/id_rsa" ansible_port=22 ansible_user="alice" ansible_ssh_common_args="-o ProxyCommand=\"ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -i /keys/bastion -p 2222 -W %h:%p jump@bastion.example.com\""
+`
+
+	if ini != expected {
+		t.Errorf("expected format differs from obtained format. Expected: \n%s\nGot: \n%s\n", expected, ini)
+	}
+
+	inv.KnownHostsFile = "/generated/known_hosts"
+	ini = string(inv.ToINI())
+
+	expected = `[worker]
+"worker01" ansible_host="10.0.0.3" internal_ipv4="10.0.0.3" ansible_ssh_private_key_file="/keys/id_rsa" ansible_port=22 ansible_user="alice" ansible_ssh_common_args="-o ProxyCommand=\"ssh -o StrictHostKeyChecking=yes -o UserKnownHostsFile=/generated/known_hosts -i /keys/bastion -p 2222 -W %h:%p jump@bastion.example.com\""
+[all:vars]
+ansible_ssh_extra_args="-o StrictHostKeyChecking=yes -o UserKnownHostsFile=/generated/known_hosts"
+`
+
+	if ini != expected {
+		t.Errorf("expected format differs from obtained format. Expected: \n%s\nGot: \n%s\n", expected, ini)
+	}
+}
+
+func TestInventoryINIGenerationWithAgent(t *testing.T) {
+	inv := Inventory{
+		Roles: []Role{
+			{
+				Name: "worker",
+				Nodes: []Node{
+					{
+						Host:     "worker01",
+						PublicIP: "10.0.0.3",
+						SSHPort:  22,
+						SSHUser:  "alice",
+						SSHBastion: &Bastion{
+							Host:    "bastion.example.com",
+							SSHPort: 22,
+							SSHUser: "jump",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ini := string(inv.ToINI())
+
+	expected := `[worker]
+"worker01" ansible_host="10.0.0.3" internal_ipv4="10.0.0.3" ansible_port=22 ansible_user="alice" ansible_ssh_common_args="-o ProxyCommand=\"ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -p 22 -W %h:%p jump@bastion.example.com\""
+`
+
+	if ini != expected {
+		t.Errorf("expected format differs from obtained format. Expected: \n%s\nGot: \n%s\n", expected, ini)
+	}
+}
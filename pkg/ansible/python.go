@@ -0,0 +1,216 @@
+package ansible
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PythonEnvVar is the environment variable that sets the python interpreter that runs ansible
+const PythonEnvVar = "KISMATIC_PYTHON"
+
+// the python interpreter that was set explicitly, which takes precedence over the environment
+var (
+	pythonMu          sync.Mutex
+	pythonInterpreter string
+)
+
+// SetPythonInterpreter sets the python interpreter that runs ansible, which is the name or
+// the path of an interpreter, or the directory of a virtualenv. It takes precedence over the
+// KISMATIC_PYTHON and VIRTUAL_ENV environment variables.
+func SetPythonInterpreter(interpreter string) {
+	pythonMu.Lock()
+	defer pythonMu.Unlock()
+	pythonInterpreter = interpreter
+}
+
+// Python is the python interpreter that runs ansible
+type Python struct {
+	// Path of the interpreter
+	Path string
+	// Version of the interpreter, such as "2.7.5"
+	Version string
+	// AnsibleVersion is the version of the ansible that is loaded by the interpreter
+	AnsibleVersion string
+	// path is the PYTHONPATH that loads the ansible that ships with kismatic. It is empty
+	// when ansible is loaded from the site-packages of the interpreter, such as a virtualenv.
+	path string
+}
+
+func (p Python) String() string {
+	return fmt.Sprintf("Ansible %s with %s (Python %s)", p.AnsibleVersion, p.Path, p.Version)
+}
+
+// FindPython returns the python interpreter that runs the ansible of the ansible directory.
+// The interpreter is the one that was set with SetPythonInterpreter, or with the KISMATIC_PYTHON
+// environment variable, the interpreter of the active virtualenv, or the first of the "python"
+// and "python3" interpreters on the PATH that can load ansible, in that order. It fails when
+// the interpreter cannot load ansible.
+func FindPython(ansibleDir string) (*Python, error) {
+	interpreters, err := pythonInterpreterPaths()
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	for _, interpreter := range interpreters {
+		p, err := loadPython(interpreter, ansibleDir)
+		if err == nil {
+			return p, nil
+		}
+		if len(interpreters) == 1 {
+			return nil, err
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, fmt.Errorf("None of the python interpreters in the PATH can run Ansible:\n%s", strings.Join(errs, "\n"))
+}
+
+// loadPython returns the python interpreter if it runs the ansible of the ansible directory
+func loadPython(interpreter string, ansibleDir string) (*Python, error) {
+	version, err := pythonOutput(interpreter, "", "import sys; print('%d.%d.%d' % sys.version_info[:3])")
+	if err != nil {
+		return nil, fmt.Errorf("error getting the version of the python interpreter %q: %v", interpreter, err)
+	}
+	p := &Python{Path: interpreter, Version: version}
+	if !supportedPython(version) {
+		return nil, fmt.Errorf("%s is Python %s, but Ansible requires Python 2.7, or Python 3.5 or later. Set the python interpreter with the --python flag or the %s environment variable", interpreter, version, PythonEnvVar)
+	}
+
+	vendored, err := vendoredPythonPaths(ansibleDir)
+	if err != nil {
+		return nil, err
+	}
+	p.path = strings.Join(vendored[majorMinor(version)], string(os.PathListSeparator))
+	ansibleVersion, err := pythonOutput(interpreter, p.path, "import ansible; print(ansible.__version__)")
+	if err != nil {
+		if p.path == "" && len(vendored) > 0 {
+			var versions []string
+			for v := range vendored {
+				versions = append(versions, v)
+			}
+			sort.Strings(versions)
+			return nil, fmt.Errorf("the Ansible that ships with kismatic was built for Python %s, and cannot be used with %s (Python %s). Use a Python %s interpreter, or a virtualenv where Ansible is installed, with the --python flag or the %s environment variable",
+				strings.Join(versions, ", "), interpreter, version, strings.Join(versions, " or "), PythonEnvVar)
+		}
+		return nil, fmt.Errorf("Ansible cannot be loaded by %s (Python %s): %v", interpreter, version, err)
+	}
+	p.AnsibleVersion = ansibleVersion
+	return p, nil
+}
+
+// pythonInterpreterPaths returns the paths of the python interpreters that can run ansible,
+// in order of preference. The interpreter that was set explicitly is the only one returned.
+func pythonInterpreterPaths() ([]string, error) {
+	pythonMu.Lock()
+	interpreter := pythonInterpreter
+	pythonMu.Unlock()
+	if interpreter == "" {
+		interpreter = os.Getenv(PythonEnvVar)
+	}
+	if interpreter == "" {
+		if venv := os.Getenv("VIRTUAL_ENV"); venv != "" {
+			interpreter = venv
+		}
+	}
+	if interpreter != "" {
+		// the interpreter of a virtualenv directory
+		if info, err := os.Stat(interpreter); err == nil && info.IsDir() {
+			interpreter = filepath.Join(interpreter, "bin", "python")
+		}
+		path, err := exec.LookPath(interpreter)
+		if err != nil {
+			return nil, fmt.Errorf("the python interpreter %q was not found: %v", interpreter, err)
+		}
+		return []string{path}, nil
+	}
+	var paths []string
+	for _, name := range []string{"python", "python3"} {
+		if path, err := exec.LookPath(name); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("Could not find 'python' or 'python3' in the PATH. Ensure that Python 2.7, or Python 3.5 or later, is installed, or set the python interpreter with the --python flag or the %s environment variable", PythonEnvVar)
+	}
+	return paths, nil
+}
+
+// pythonOutput runs the python code with the interpreter, and returns its output
+func pythonOutput(interpreter string, pythonPath string, code string) (string, error) {
+	cmd := exec.Command(interpreter, "-c", code)
+	cmd.Env = os.Environ()
+	if pythonPath != "" {
+		cmd.Env = append(cmd.Env, "PYTHONPATH="+pythonPath)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			// the last line of a python error is the exception
+			lines := strings.Split(strings.TrimSpace(string(exitErr.Stderr)), "\n")
+			return "", fmt.Errorf("%v: %s", err, lines[len(lines)-1])
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// vendoredPythonPaths returns the site-packages directories of the ansible directory, by the
+// python version they were built for, such as "2.7"
+func vendoredPythonPaths(ansibleDir string) (map[string][]string, error) {
+	dir, err := filepath.Abs(ansibleDir)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path of %q: %v", ansibleDir, err)
+	}
+	var paths []string
+	for _, lib := range []string{"lib", "lib64"} {
+		matches, err := filepath.Glob(filepath.Join(dir, lib, "python*", "site-packages"))
+		if err != nil {
+			return nil, fmt.Errorf("error finding the python libraries of %q: %v", ansibleDir, err)
+		}
+		paths = append(paths, matches...)
+	}
+	vendored := map[string][]string{}
+	for _, p := range paths {
+		version := strings.TrimPrefix(filepath.Base(filepath.Dir(p)), "python")
+		vendored[version] = append(vendored[version], p)
+	}
+	return vendored, nil
+}
+
+// majorMinor returns the major and minor version of a python version, such as "2.7" for "2.7.5"
+func majorMinor(version string) string {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return version
+	}
+	return parts[0] + "." + parts[1]
+}
+
+// supportedPython returns whether ansible runs on the python version
+func supportedPython(version string) bool {
+	parts := strings.SplitN(version, ".", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+	minor, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return false
+	}
+	switch major {
+	case 2:
+		return minor >= 7
+	case 3:
+		return minor >= 5
+	default:
+		return major > 3
+	}
+}
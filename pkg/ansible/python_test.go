@@ -0,0 +1,202 @@
+package ansible
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakePython writes a python interpreter that reports the version, and loads ansible when
+// the PYTHONPATH matches, or when loadsAnsible is set
+func fakePython(t *testing.T, dir string, version string, pythonPath string, loadsAnsible bool) string {
+	script := `#!/bin/sh
+case "$2" in
+*sys.version_info*) echo ` + version + ` ;;
+*ansible*)
+	if [ "` + pythonPath + `" != "" ] && [ "$PYTHONPATH" = "` + pythonPath + `" ]; then echo 2.3.0.0; exit 0; fi
+	if [ "` + boolString(loadsAnsible) + `" = "true" ]; then echo 2.4.1.0; exit 0; fi
+	echo "Traceback (most recent call last):" >&2
+	echo "ImportError: No module named ansible" >&2
+	exit 1 ;;
+esac
+`
+	path := filepath.Join(dir, "python")
+	if err := ioutil.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("error writing fake python: %v", err)
+	}
+	return path
+}
+
+func boolString(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+
+func TestFindPython(t *testing.T) {
+	dir, err := ioutil.TempDir("", "find-python")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	ansibleDir := filepath.Join(dir, "ansible")
+	vendored := filepath.Join(ansibleDir, "lib", "python2.7", "site-packages")
+	if err = os.MkdirAll(vendored, 0755); err != nil {
+		t.Fatalf("error creating dir: %v", err)
+	}
+	defer SetPythonInterpreter("")
+
+	tests := []struct {
+		name           string
+		version        string
+		loadsAnsible   bool
+		pythonPath     string
+		ansibleVersion string
+		err            string
+	}{
+		{
+			name:           "the vendored ansible is loaded by a python of the same version",
+			version:        "2.7.5",
+			pythonPath:     vendored,
+			ansibleVersion: "2.3.0.0",
+		},
+		{
+			name:           "the ansible of a virtualenv is loaded by its python",
+			version:        "3.6.5",
+			loadsAnsible:   true,
+			ansibleVersion: "2.4.1.0",
+		},
+		{
+			name:    "the vendored ansible cannot be loaded by a python of another version",
+			version: "3.6.5",
+			err:     "the Ansible that ships with kismatic was built for Python 2.7, and cannot be used with",
+		},
+		{
+			name:       "the vendored ansible fails to load",
+			version:    "2.7.5",
+			pythonPath: "/somewhere/else",
+			err:        "ImportError: No module named ansible",
+		},
+		{
+			name:    "python versions that ansible does not run on",
+			version: "3.4.1",
+			err:     "Ansible requires Python 2.7, or Python 3.5 or later",
+		},
+	}
+	for _, test := range tests {
+		SetPythonInterpreter(fakePython(t, dir, test.version, test.pythonPath, test.loadsAnsible))
+		p, err := FindPython(ansibleDir)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("%s: expected an error containing %q, got: %v", test.name, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if p.Version != test.version || p.AnsibleVersion != test.ansibleVersion {
+			t.Errorf("%s: expected Python %s and Ansible %s, got: %s", test.name, test.version, test.ansibleVersion, p)
+		}
+		if p.path != test.pythonPath {
+			t.Errorf("%s: expected PYTHONPATH %q, got %q", test.name, test.pythonPath, p.path)
+		}
+	}
+}
+
+func TestPythonInterpreterPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "python-interpreter")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	venv := filepath.Join(dir, "venv")
+	if err = os.MkdirAll(filepath.Join(venv, "bin"), 0755); err != nil {
+		t.Fatalf("error creating dir: %v", err)
+	}
+	venvPython := fakePython(t, filepath.Join(venv, "bin"), "3.6.5", "", true)
+	explicit := fakePython(t, dir, "2.7.5", "", true)
+	defer SetPythonInterpreter("")
+	defer os.Setenv(PythonEnvVar, os.Getenv(PythonEnvVar))
+	defer os.Setenv("VIRTUAL_ENV", os.Getenv("VIRTUAL_ENV"))
+
+	tests := []struct {
+		set      string
+		env      string
+		venv     string
+		expected string
+	}{
+		{set: explicit, env: venvPython, venv: venv, expected: explicit},
+		{env: explicit, venv: venv, expected: explicit},
+		{venv: venv, expected: venvPython},
+		// a virtualenv directory can be set explicitly
+		{set: venv, env: explicit, expected: venvPython},
+	}
+	for i, test := range tests {
+		SetPythonInterpreter(test.set)
+		os.Setenv(PythonEnvVar, test.env)
+		os.Setenv("VIRTUAL_ENV", test.venv)
+		paths, err := pythonInterpreterPaths()
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+			continue
+		}
+		if len(paths) != 1 || paths[0] != test.expected {
+			t.Errorf("test %d: expected interpreter %q, got %q", i, test.expected, paths)
+		}
+	}
+
+	SetPythonInterpreter(filepath.Join(dir, "missing"))
+	if _, err = pythonInterpreterPaths(); err == nil {
+		t.Errorf("expected an error when the interpreter does not exist")
+	}
+}
+
+func TestFindPythonSkipsIncompatibleInterpretersInPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "python-path")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	bin := filepath.Join(dir, "bin")
+	python3 := filepath.Join(dir, "python3")
+	for _, d := range []string{bin, python3} {
+		if err = os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("error creating dir: %v", err)
+		}
+	}
+	fakePython(t, bin, "3.4.1", "", true)
+	if err = os.Rename(fakePython(t, python3, "3.6.5", "", true), filepath.Join(bin, "python3")); err != nil {
+		t.Fatalf("error writing fake python3: %v", err)
+	}
+	SetPythonInterpreter("")
+	defer os.Setenv(PythonEnvVar, os.Getenv(PythonEnvVar))
+	defer os.Setenv("VIRTUAL_ENV", os.Getenv("VIRTUAL_ENV"))
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv(PythonEnvVar, "")
+	os.Setenv("VIRTUAL_ENV", "")
+	os.Setenv("PATH", bin)
+
+	p, err := FindPython(filepath.Join(dir, "ansible"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Path != filepath.Join(bin, "python3") || p.Version != "3.6.5" {
+		t.Errorf("expected python3 to be used, as python is not supported, got: %s", p)
+	}
+
+	// the errors of all the interpreters are returned
+	os.Remove(filepath.Join(bin, "python3"))
+	fakePython(t, python3, "3.6.5", "", false)
+	if err = os.Rename(filepath.Join(python3, "python"), filepath.Join(bin, "python3")); err != nil {
+		t.Fatalf("error writing fake python3: %v", err)
+	}
+	_, err = FindPython(filepath.Join(dir, "ansible"))
+	if err == nil || !strings.Contains(err.Error(), "Python 3.4.1") || !strings.Contains(err.Error(), "No module named ansible") {
+		t.Errorf("expected the errors of python and python3, got: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package ansible
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// EventsFilename is the file of the run directory where the runner records the event stream of ansible
+const EventsFilename = "events.jsonl"
+
+// ReplayRunner is a Runner that replays a recorded event stream, in the JSON lines format of
+// the callback plugin, instead of running ansible. It is used for testing the consumers of the
+// event stream, and for rendering the output of past runs.
+type ReplayRunner struct {
+	// Events is the recorded event stream. It is closed by WaitPlaybook if it is a closer.
+	Events io.Reader
+	// Err is returned by WaitPlaybook, such as for replaying a failed run
+	Err error
+	// Playbooks that were started, in order
+	Playbooks []string
+	// Nodes that the last playbook was limited to
+	Nodes []string
+
+	done chan struct{}
+}
+
+// StartPlaybook replays the recorded event stream
+func (r *ReplayRunner) StartPlaybook(ctx context.Context, playbookFile string, inventory Inventory, cc ClusterCatalog) (<-chan Event, error) {
+	return r.StartPlaybookOnNode(ctx, playbookFile, inventory, cc)
+}
+
+// StartPlaybookOnNode replays the recorded event stream, and records the nodes
+func (r *ReplayRunner) StartPlaybookOnNode(ctx context.Context, playbookFile string, inventory Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("playbook %q was not started: %v", playbookFile, ctx.Err())
+	}
+	if r.done != nil {
+		return nil, fmt.Errorf("the recorded event stream was already replayed")
+	}
+	r.Playbooks = append(r.Playbooks, playbookFile)
+	r.Nodes = nodes
+	r.done = make(chan struct{})
+	out := make(chan Event)
+	go func() {
+		defer close(r.done)
+		defer close(out)
+		for e := range EventStream(r.Events) {
+			out <- e
+		}
+	}()
+	return out, nil
+}
+
+// WaitPlaybook blocks until all the recorded events were read from the event stream, and
+// returns the error of the runner
+func (r *ReplayRunner) WaitPlaybook(ctx context.Context) error {
+	if r.done == nil {
+		return fmt.Errorf("wait called, but playbook not started")
+	}
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		return fmt.Errorf("ansible was stopped: %v", ctx.Err())
+	}
+	if c, ok := r.Events.(io.Closer); ok {
+		c.Close()
+	}
+	return r.Err
+}
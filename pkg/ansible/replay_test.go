@@ -0,0 +1,46 @@
+package ansible
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestReplayRunner(t *testing.T) {
+	r := &ReplayRunner{
+		Events: strings.NewReader(`{"eventType":"PLAY_START", "eventData": {"name":"Install etcd"}}
+{"eventType":"RUNNER_FAILED", "eventData": {"host":"etcd01", "result": {"msg":"etcd did not start"}}}
+`),
+		Err: errors.New("exit status 2"),
+	}
+	if err := r.WaitPlaybook(context.Background()); err == nil {
+		t.Errorf("expected an error when waiting for a playbook that was not started")
+	}
+	events, err := r.StartPlaybookOnNode(context.Background(), "kubernetes.yaml", Inventory{}, ClusterCatalog{}, "etcd01")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var replayed []Event
+	for e := range events {
+		replayed = append(replayed, e)
+	}
+	if err = r.WaitPlaybook(context.Background()); err == nil || err.Error() != "exit status 2" {
+		t.Errorf("expected the error of the runner, got: %v", err)
+	}
+	if len(replayed) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(replayed))
+	}
+	failed, ok := replayed[1].(*RunnerFailedEvent)
+	if !ok || failed.Host != "etcd01" || failed.Result.Message != "etcd did not start" {
+		t.Errorf("unexpected event %#v", replayed[1])
+	}
+	if len(r.Playbooks) != 1 || r.Playbooks[0] != "kubernetes.yaml" || len(r.Nodes) != 1 || r.Nodes[0] != "etcd01" {
+		t.Errorf("unexpected playbooks %v and nodes %v", r.Playbooks, r.Nodes)
+	}
+
+	// the recorded events can only be replayed once
+	if _, err = r.StartPlaybook(context.Background(), "kubernetes.yaml", Inventory{}, ClusterCatalog{}); err == nil {
+		t.Errorf("expected an error when replaying the events twice")
+	}
+}
@@ -1,17 +1,23 @@
 package ansible
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
-	"math/rand"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
+
+	"github.com/apprenda/kismatic/pkg/ssh"
+	"github.com/apprenda/kismatic/pkg/util"
 )
 
 const (
@@ -24,18 +30,66 @@ const (
 // OutputFormat is used for controlling the STDOUT format of the Ansible runner
 type OutputFormat string
 
+// workDirPrefix is the prefix of the private directory that holds the files that are only
+// needed while ansible runs
+const workDirPrefix = "kismatic-run-"
+
+// StopTimeout is how long ansible is given to stop after it is interrupted, before it is killed
+var StopTimeout = 30 * time.Second
+
+// endOfEvents marks the end of the event stream. It is written to the named pipe by the runner
+// once ansible has exited, as the pipe is held open by the runner, and possibly by processes
+// that ansible left behind, so the reader of the pipe never gets to the end of the file.
+const endOfEvents = "kismatic:end-of-events"
+
+// running are the ansible commands that were started, and did not exit yet, with the
+// private directories of their runs
+var running = struct {
+	sync.Mutex
+	cmds map[*exec.Cmd]string
+}{cmds: map[*exec.Cmd]string{}}
+
+// KillRunning kills the process groups of the ansible commands that are running, and removes
+// the private directories of their runs, which hold secrets, so that they are not left behind
+// when kismatic exits without waiting for them
+func KillRunning() {
+	running.Lock()
+	defer running.Unlock()
+	for cmd, workDir := range running.cmds {
+		signalProcessGroup(cmd, syscall.SIGKILL)
+		if workDir != "" {
+			os.RemoveAll(workDir)
+		}
+	}
+}
+
+func trackCommand(cmd *exec.Cmd, workDir string) {
+	running.Lock()
+	running.cmds[cmd] = workDir
+	running.Unlock()
+}
+
+func untrackCommand(cmd *exec.Cmd) {
+	running.Lock()
+	delete(running.cmds, cmd)
+	running.Unlock()
+}
+
 // Runner for running Ansible playbooks
 type Runner interface {
 	// StartPlaybook runs the playbook asynchronously with the given inventory and extra vars.
 	// It returns a read-only channel that must be consumed for the playbook execution to proceed.
-	StartPlaybook(playbookFile string, inventory Inventory, cc ClusterCatalog) (<-chan Event, error)
-	// WaitPlaybook blocks until the execution of the playbook is complete. If an error occurred,
+	StartPlaybook(ctx context.Context, playbookFile string, inventory Inventory, cc ClusterCatalog) (<-chan Event, error)
+	// WaitPlaybook blocks until the execution of the playbook is complete, and all of its events
+	// were read from the event stream, which is then closed. If an error occurred,
 	// it is returned. Otherwise, returns nil to signal the completion of the playbook.
-	WaitPlaybook() error
+	// When the context is done, ansible is interrupted, and killed if it does not stop
+	// within the StopTimeout.
+	WaitPlaybook(ctx context.Context) error
 	// StartPlaybookOnNode runs the playbook asynchronously with the given inventory and extra vars
 	// against the specific node.
 	// It returns a read-only channel that must be consumed for the playbook execution to proceed.
-	StartPlaybookOnNode(playbookFile string, inventory Inventory, cc ClusterCatalog, node ...string) (<-chan Event, error)
+	StartPlaybookOnNode(ctx context.Context, playbookFile string, inventory Inventory, cc ClusterCatalog, node ...string) (<-chan Event, error)
 }
 
 type runner struct {
@@ -44,70 +98,125 @@ type runner struct {
 	// ErrOut is the stderr writer for the Ansible process
 	errOut io.Writer
 
-	pythonPath   string
-	ansibleDir   string
-	runDir       string
-	waitPlaybook func() error
-	namedPipe    string
+	python     *Python
+	ansibleDir string
+	runDir     string
+	cmd        *exec.Cmd
+	// workDir is the private directory of the run, that holds the files that are only
+	// needed while ansible runs. It is outside of the run directory, as the files hold secrets.
+	workDir string
+	// controlDir is the private directory of the ssh control sockets of the run, when it
+	// is not in the private directory of the run
+	controlDir string
+	namedPipe  string
+	// pipe is the named pipe that ansible writes the event stream to
+	pipe *os.File
+	// drained is closed once the event stream was read up to the end of the events
+	drained chan struct{}
+	// events records the event stream
+	events *eventRecording
+	// agent serves the decrypted SSH keys to ansible while the playbook runs
+	agent *ssh.LocalAgent
 }
 
-// NewRunner returns a new runner for running Ansible playbooks.
-func NewRunner(out, errOut io.Writer, ansibleDir string, runDir string) (Runner, error) {
-	// Ansible depends on python 2.7 being installed and on the path as "python".
-	// Validate that it is available
-	if _, err := exec.LookPath("python"); err != nil {
-		return nil, fmt.Errorf("Could not find 'python' in the PATH. Ensure that python 2.7 is installed and in the path as 'python'.")
+// NewRunner returns a new runner for running Ansible playbooks with the python interpreter.
+func NewRunner(out, errOut io.Writer, ansibleDir string, runDir string, python *Python) (Runner, error) {
+	if python == nil {
+		return nil, fmt.Errorf("the python interpreter that runs ansible is required")
 	}
-
-	ppath, err := getPythonPath()
-	if err != nil {
-		return nil, err
-	}
-
 	return &runner{
 		out:        out,
 		errOut:     errOut,
-		pythonPath: ppath,
+		python:     python,
 		ansibleDir: ansibleDir,
 		runDir:     runDir,
 	}, nil
 }
 
-// WaitPlaybook blocks until the ansible process running the playbook exits.
-// If the process exits with a non-zero status, it will return an error.
-func (r *runner) WaitPlaybook() error {
-	if r.waitPlaybook == nil {
+// WaitPlaybook blocks until the ansible process running the playbook exits, and its events
+// were read. If the process exits with a non-zero status, it will return an error.
+func (r *runner) WaitPlaybook(ctx context.Context) error {
+	if r.cmd == nil {
 		return fmt.Errorf("wait called, but playbook not started")
 	}
-	execErr := r.waitPlaybook()
-	// Process exited, we can clean up named pipe
-	removeErr := os.Remove(r.namedPipe)
+	execErr := waitCommand(ctx, r.cmd, StopTimeout)
+	untrackCommand(r.cmd)
+	r.cmd = nil
+	if r.agent != nil {
+		r.agent.Close()
+		r.agent = nil
+	}
+	recordErr := r.endEvents()
+	// Process exited, we can clean up the files that were only needed while it ran
+	removeErr := r.removeWorkDir()
 	if removeErr != nil && execErr != nil {
-		return fmt.Errorf("an error occurred running ansible: %v. Removing named pipe at %q failed: %v", execErr, r.namedPipe, removeErr)
+		return fmt.Errorf("an error occurred running ansible: %v. Removing %q failed: %v", execErr, r.workDir, removeErr)
 	}
 	if removeErr != nil {
-		return fmt.Errorf("failed to clean up named pipe at %q: %v", r.namedPipe, removeErr)
+		return fmt.Errorf("failed to clean up %q: %v", r.workDir, removeErr)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("ansible was stopped: %v", ctx.Err())
 	}
 	if execErr != nil {
 		return fmt.Errorf("error running ansible: %v", execErr)
 	}
+	if recordErr != nil {
+		return fmt.Errorf("error recording the events of the run: %v", recordErr)
+	}
 	return nil
 }
 
+// waitCommand waits for the command to exit. When the context is done first, the
+// process group of the command is interrupted, and killed if it does not exit within
+// the stop timeout.
+func waitCommand(ctx context.Context, cmd *exec.Cmd, stopTimeout time.Duration) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	signalProcessGroup(cmd, syscall.SIGINT)
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(stopTimeout):
+	}
+	signalProcessGroup(cmd, syscall.SIGKILL)
+	return <-done
+}
+
+// signalProcessGroup sends the signal to the process group of the command, which
+// includes the ssh connections opened by ansible
+func signalProcessGroup(cmd *exec.Cmd, sig syscall.Signal) {
+	if cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid {
+		syscall.Kill(-cmd.Process.Pid, sig)
+		return
+	}
+	cmd.Process.Signal(sig)
+}
+
 // RunPlaybook with the given inventory and extra vars
-func (r *runner) StartPlaybook(playbookFile string, inv Inventory, cc ClusterCatalog) (<-chan Event, error) {
-	return r.startPlaybook(playbookFile, inv, cc) // Don't set the --limit arg
+func (r *runner) StartPlaybook(ctx context.Context, playbookFile string, inv Inventory, cc ClusterCatalog) (<-chan Event, error) {
+	return r.startPlaybook(ctx, playbookFile, inv, cc) // Don't set the --limit arg
 }
 
 // StartPlaybookOnNode runs the playbook asynchronously with the given inventory and extra vars
 // against the specific node.
 // It returns a read-only channel that must be consumed for the playbook execution to proceed.
-func (r *runner) StartPlaybookOnNode(playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
+func (r *runner) StartPlaybookOnNode(ctx context.Context, playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
 	// set the --limit arg to the node we want to target
-	return r.startPlaybook(playbookFile, inv, cc, nodes...)
+	return r.startPlaybook(ctx, playbookFile, inv, cc, nodes...)
 }
 
-func (r *runner) startPlaybook(playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
+func (r *runner) startPlaybook(ctx context.Context, playbookFile string, inv Inventory, cc ClusterCatalog, nodes ...string) (<-chan Event, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("playbook %q was not started: %v", playbookFile, ctx.Err())
+	}
 	playbook := filepath.Join(r.ansibleDir, "playbooks", playbookFile)
 	if _, err := os.Stat(playbook); os.IsNotExist(err) {
 		return nil, fmt.Errorf("playbook %q does not exist", playbook)
@@ -117,26 +226,88 @@ func (r *runner) startPlaybook(playbookFile string, inv Inventory, cc ClusterCat
 	if err != nil {
 		return nil, fmt.Errorf("error writing cluster catalog data to yaml: %v", err)
 	}
-	clusterCatalogFile := filepath.Join(r.ansibleDir, "clustercatalog.yaml")
-	if err = ioutil.WriteFile(clusterCatalogFile, yamlBytes, 0644); err != nil {
+	// The files that are only needed while ansible runs, some of which contain secrets,
+	// are kept in a private directory, so that runs never share them. The directory is
+	// outside of the run directory, which must never hold secrets, even when kismatic
+	// exits before removing the directory.
+	workDir, err := ioutil.TempDir("", workDirPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("error creating private directory of the run: %v", err)
+	}
+	r.workDir = workDir
+	started := false
+	defer func() {
+		if !started {
+			r.removeWorkDir()
+			if r.events != nil {
+				r.events.close()
+			}
+		}
+	}()
+
+	// the cluster catalog contains secrets, and is only readable by the current user
+	clusterCatalogFile := filepath.Join(workDir, "clustercatalog.yaml")
+	if err = ioutil.WriteFile(clusterCatalogFile, yamlBytes, 0600); err != nil {
 		return nil, fmt.Errorf("error writing cluster catalog file to %q: %v", clusterCatalogFile, err)
 	}
 
-	inventoryFile := filepath.Join(r.ansibleDir, "inventory.ini")
-	if err := ioutil.WriteFile(inventoryFile, inv.ToINI(), 0644); err != nil {
+	inventoryFile := filepath.Join(r.runDir, "inventory.ini")
+	if err = ioutil.WriteFile(inventoryFile, inv.ToINI(), 0644); err != nil {
 		return nil, fmt.Errorf("error writing inventory file to %q: %v", inventoryFile, err)
 	}
 
-	if err := copyFileContents(clusterCatalogFile, filepath.Join(r.runDir, "clustercatalog.yaml")); err != nil {
-		return nil, fmt.Errorf("error copying clustercatalog.yaml to %q: %v", r.runDir, err)
+	// record the cluster catalog in the run directory, without the secrets
+	redacted := cc.Redacted()
+	redactedBytes, err := redacted.ToYAML()
+	if err != nil {
+		return nil, fmt.Errorf("error writing cluster catalog data to yaml: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(r.runDir, "clustercatalog.yaml"), redactedBytes, 0644); err != nil {
+		return nil, fmt.Errorf("error writing clustercatalog.yaml to %q: %v", r.runDir, err)
+	}
+
+	// the ansible configuration of the run keeps the temporary and retry files of
+	// ansible in the run directory
+	shippedConfig, err := ioutil.ReadFile(filepath.Join(r.ansibleDir, "playbooks", "ansible.cfg"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading ansible configuration: %v", err)
+	}
+	runDir, err := filepath.Abs(r.runDir)
+	if err != nil {
+		return nil, fmt.Errorf("error getting absolute path of run directory %q: %v", r.runDir, err)
+	}
+	configFile := filepath.Join(r.runDir, "ansible.cfg")
+	controlDir, err := r.createControlDir()
+	if err != nil {
+		return nil, err
+	}
+	config := runConfig(shippedConfig, []configSetting{
+		{"defaults", "local_tmp", filepath.Join(workDir, "tmp")},
+		{"defaults", "retry_files_save_path", runDir},
+		// the ssh connections of ansible are not shared with other runs
+		{"ssh_connection", "control_path", filepath.Join(controlDir, "%%h-%%p-%%r")},
+	})
+	if err = ioutil.WriteFile(configFile, config, 0644); err != nil {
+		return nil, fmt.Errorf("error writing ansible configuration to %q: %v", configFile, err)
 	}
-	if err := copyFileContents(inventoryFile, filepath.Join(r.runDir, "inventory.ini")); err != nil {
-		return nil, fmt.Errorf("error copying inventory.ini to %q: %v", r.runDir, err)
+
+	// ssh only connects to hosts with a known host key, so the keys of the
+	// hosts that were not contacted before are recorded first
+	if inv.KnownHostsFile != "" {
+		if err = scanHostKeys(inv); err != nil {
+			return nil, err
+		}
 	}
 
-	cmd := exec.Command(filepath.Join(r.ansibleDir, "bin", "ansible-playbook"), "-i", inventoryFile, "-s", playbook, "--extra-vars", "@"+clusterCatalogFile)
+	// ansible-playbook is run by the interpreter, which might not be the one of its shebang
+	cmd := exec.Command(r.python.Path, filepath.Join(r.ansibleDir, "bin", "ansible-playbook"), "-i", inventoryFile, "-s", playbook, "--extra-vars", "@"+clusterCatalogFile)
 	cmd.Stdout = r.out
 	cmd.Stderr = r.errOut
+	// When the run can be cancelled, ansible runs in its own process group, so that it
+	// does not get the signals of the terminal, and is stopped by WaitPlaybook instead
+	if ctx.Done() != nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
 
 	log.SetOutput(r.out)
 
@@ -150,77 +321,320 @@ func (r *runner) startPlaybook(playbookFile string, inv Inventory, cc ClusterCat
 	cmd.Args = append(cmd.Args, "-vvvv")
 
 	// Create named pipe
-	np, err := createTempNamedPipe()
+	r.namedPipe = filepath.Join(workDir, "events")
+	if err = syscall.Mkfifo(r.namedPipe, 0600); err != nil {
+		return nil, fmt.Errorf("error creating named pipe %q: %v", r.namedPipe, err)
+	}
+	// the event stream is recorded in the run directory, so that the run can be replayed
+	eventsFile := filepath.Join(r.runDir, EventsFilename)
+	eventsF, err := os.Create(eventsFile)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("error creating events file %q: %v", eventsFile, err)
+	}
+	r.events = &eventRecording{f: eventsF, now: time.Now}
+
+	// host keys are verified against the inventory's known_hosts file
+	hostKeyChecking := "False"
+	if inv.KnownHostsFile != "" {
+		hostKeyChecking = "True"
 	}
-	r.namedPipe = np
+	// the environment is only set for ansible, so that runs do not affect each other
+	var env []string
+	if r.python.path != "" {
+		env = append(env, "PYTHONPATH="+r.python.path)
+	}
+	env = append(env,
+		"ANSIBLE_CALLBACK_PLUGINS="+filepath.Join(r.ansibleDir, "playbooks", "callback"),
+		"ANSIBLE_CALLBACK_WHITELIST=json_lines",
+		"ANSIBLE_CONFIG="+configFile,
+		"ANSIBLE_JSON_LINES_PIPE="+r.namedPipe,
+		"ANSIBLE_HOST_KEY_CHECKING="+hostKeyChecking,
+	)
+	cmd.Env = append(os.Environ(), env...)
 
-	os.Setenv("PYTHONPATH", r.pythonPath)
-	os.Setenv("ANSIBLE_CALLBACK_PLUGINS", filepath.Join(r.ansibleDir, "playbooks", "callback"))
-	os.Setenv("ANSIBLE_CALLBACK_WHITELIST", "json_lines")
-	os.Setenv("ANSIBLE_CONFIG", filepath.Join(r.ansibleDir, "playbooks", "ansible.cfg"))
-	os.Setenv("ANSIBLE_JSON_LINES_PIPE", r.namedPipe)
+	// ansible cannot prompt for the passphrase of encrypted keys, so they
+	// are decrypted once and served to ssh by a local ssh-agent
+	if keys := encryptedKeys(inv); len(keys) > 0 {
+		a, err := ssh.StartAgent(keys...)
+		if err != nil {
+			return nil, err
+		}
+		r.agent = a
+		cmd.Env = append(cmd.Env, ssh.AgentEnvVar+"="+a.Socket)
+	}
 
 	// Print Ansible command
-	fmt.Fprintf(r.out, "export PYTHONPATH=%v\n", os.Getenv("PYTHONPATH"))
-	fmt.Fprintf(r.out, "export ANSIBLE_CALLBACK_PLUGINS=%v\n", os.Getenv("ANSIBLE_CALLBACK_PLUGINS"))
-	fmt.Fprintf(r.out, "export ANSIBLE_CALLBACK_WHITELIST=%v\n", os.Getenv("ANSIBLE_CALLBACK_WHITELIST"))
-	fmt.Fprintf(r.out, "export ANSIBLE_CONFIG=%v\n", os.Getenv("ANSIBLE_CONFIG"))
-	fmt.Fprintf(r.out, "export ANSIBLE_JSON_LINES_PIPE=%v\n", os.Getenv("ANSIBLE_JSON_LINES_PIPE"))
+	fmt.Fprintf(r.out, "Running %s\n", r.python)
+	for _, e := range env {
+		fmt.Fprintf(r.out, "export %s\n", e)
+	}
 	fmt.Fprintln(r.out, strings.Join(cmd.Args, " "))
 
 	// Starts async execution of ansible, which will block until
 	// we start reading from the named pipe
 	err = cmd.Start()
 	if err != nil {
+		if r.agent != nil {
+			r.agent.Close()
+			r.agent = nil
+		}
 		return nil, fmt.Errorf("error running playbook: %v", err)
 	}
-	r.waitPlaybook = cmd.Wait
+	r.cmd = cmd
+	trackCommand(cmd, workDir)
+	started = true
 
 	// Create the event stream out of the named pipe
-	eventStreamFile, err := os.OpenFile(r.namedPipe, os.O_RDWR, os.ModeNamedPipe)
+	r.pipe, err = os.OpenFile(r.namedPipe, os.O_RDWR, os.ModeNamedPipe)
 	if err != nil {
+		r.events.close()
 		return nil, fmt.Errorf("error openning event stream pipe: %v", err)
 	}
-	eventStream := EventStream(eventStreamFile)
-	return eventStream, nil
+	r.drained = make(chan struct{})
+	events, w := io.Pipe()
+	go copyEvents(w, r.pipe, r.events, r.drained)
+	return EventStream(events), nil
 }
 
-// create a named pipe for getting json events out of ansible.
-// add random int to file name to avoid collision.
-func createTempNamedPipe() (string, error) {
-	start := time.Now()
-	np := filepath.Join(os.TempDir(), fmt.Sprintf("ansible-pipe-%d-%s", rand.Int(), start.Format("2006-01-02-15-04-05.99999")))
-	if err := syscall.Mkfifo(np, 0644); err != nil {
-		return "", fmt.Errorf("error creating named pipe %q: %v", np, err)
+// copyEvents copies the lines of the named pipe to the event stream and to the recording of
+// the events, until the end of the events. The pipe and the recording are closed, and drained
+// is closed once all the events were read.
+func copyEvents(w *io.PipeWriter, pipe *os.File, recording *eventRecording, drained chan<- struct{}) {
+	defer close(drained)
+	defer recording.close()
+	defer pipe.Close()
+	lr := util.NewLineReader(pipe, 64*1024)
+	for {
+		line, err := lr.Read()
+		if err != nil {
+			w.CloseWithError(err)
+			return
+		}
+		if string(line) == endOfEvents {
+			w.Close()
+			return
+		}
+		if len(line) == 0 {
+			continue
+		}
+		recording.record(line)
+		line = append(line, '\n')
+		if _, err = w.Write(line); err != nil {
+			return
+		}
 	}
-	return np, nil
 }
 
-func getPythonPath() (string, error) {
-	wd, err := os.Getwd()
-	if err != nil {
-		return "", fmt.Errorf("error getting working dir: %v", err)
+// endEvents marks the end of the event stream, and waits until the events that ansible
+// wrote before it exited are read. Returns the error of the recording of the events.
+func (r *runner) endEvents() error {
+	if r.pipe == nil {
+		return nil
 	}
-	lib := filepath.Join(wd, "ansible", "lib", "python2.7", "site-packages")
-	lib64 := filepath.Join(wd, "ansible", "lib64", "python2.7", "site-packages")
-	return fmt.Sprintf("%s:%s", lib, lib64), nil
+	// a line that was cut short by ansible is ended first
+	fmt.Fprintf(r.pipe, "\n%s\n", endOfEvents)
+	<-r.drained
+	r.pipe = nil
+	return r.events.err
 }
 
-func copyFileContents(src, dst string) (err error) {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
+// scanHostKeys verifies the host keys of the inventory nodes against the known_hosts file,
+// and records the keys of unknown nodes
+func scanHostKeys(inv Inventory) error {
+	type target struct {
+		host    string
+		port    int
+		bastion *ssh.Bastion
 	}
-	defer in.Close()
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
+	seen := map[string]bool{}
+	targets := []target{}
+	for _, role := range inv.Roles {
+		for _, n := range role.Nodes {
+			key := fmt.Sprintf("%s:%d", n.PublicIP, n.SSHPort)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			t := target{host: n.PublicIP, port: n.SSHPort}
+			if n.SSHBastion != nil {
+				t.bastion = &ssh.Bastion{
+					Host: n.SSHBastion.Host,
+					Port: n.SSHBastion.SSHPort,
+					User: n.SSHBastion.SSHUser,
+					Key:  n.SSHBastion.SSHPrivateKey,
+				}
+			}
+			targets = append(targets, t)
+		}
 	}
-	defer out.Close()
-	if _, err = io.Copy(out, in); err != nil {
-		return err
+	errs := make(chan error, len(targets))
+	for _, t := range targets {
+		go func(t target) {
+			errs <- ssh.ScanHostKey(t.host, t.port, t.bastion)
+		}(t)
+	}
+	var scanErrs []string
+	for range targets {
+		if err := <-errs; err != nil {
+			scanErrs = append(scanErrs, err.Error())
+		}
+	}
+	if len(scanErrs) > 0 {
+		return fmt.Errorf("error verifying host keys: %s", strings.Join(scanErrs, "; "))
+	}
+	return nil
+}
+
+// encryptedKeys returns the SSH keys of the inventory that are protected by a passphrase
+func encryptedKeys(inv Inventory) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, role := range inv.Roles {
+		for _, n := range role.Nodes {
+			nodeKeys := []string{n.SSHPrivateKey}
+			if n.SSHBastion != nil {
+				nodeKeys = append(nodeKeys, n.SSHBastion.SSHPrivateKey)
+			}
+			for _, k := range nodeKeys {
+				if k == "" || seen[k] {
+					continue
+				}
+				seen[k] = true
+				if ssh.EncryptedKey(k) {
+					keys = append(keys, k)
+				}
+			}
+		}
+	}
+	return keys
+}
+
+// maxControlDirLength is the length of the longest directory of the ssh control sockets.
+// The path of a unix socket is limited to 108 bytes, and ssh appends the host, port and user
+// to the directory, and a random suffix while the socket is created.
+const maxControlDirLength = 60
+
+// createControlDir creates the private directory of the ssh control sockets of the run, in
+// the private directory of the run, unless its path is too long for a unix socket, such as
+// when the temporary directory of the user is deeply nested
+func (r *runner) createControlDir() (string, error) {
+	dir := filepath.Join(r.workDir, "cp")
+	if len(dir) > maxControlDirLength {
+		tmp, err := ioutil.TempDir("/tmp", "kismatic-cp")
+		if err != nil {
+			return "", fmt.Errorf("error creating directory for ssh control sockets: %v", err)
+		}
+		r.controlDir = tmp
+		return tmp, nil
+	}
+	if err := os.Mkdir(dir, 0700); err != nil {
+		return "", fmt.Errorf("error creating directory %q: %v", dir, err)
+	}
+	return dir, nil
+}
+
+// removeWorkDir removes the private directory of the run
+func (r *runner) removeWorkDir() error {
+	if r.controlDir != "" {
+		os.RemoveAll(r.controlDir)
+		r.controlDir = ""
+	}
+	if r.workDir == "" {
+		return nil
+	}
+	err := os.RemoveAll(r.workDir)
+	r.workDir = ""
+	return err
+}
+
+// configSetting is a setting of a section of the ansible configuration
+type configSetting struct {
+	section string
+	key     string
+	value   string
+}
+
+// runConfig returns the ansible configuration of a run, which is the configuration that
+// ships with kismatic, with the given settings
+func runConfig(shipped []byte, settings []configSetting) []byte {
+	var sections []string
+	added := map[string]*bytes.Buffer{}
+	overridden := map[string]map[string]bool{}
+	for _, s := range settings {
+		if added[s.section] == nil {
+			sections = append(sections, s.section)
+			added[s.section] = &bytes.Buffer{}
+			overridden[s.section] = map[string]bool{}
+		}
+		fmt.Fprintf(added[s.section], "%s = %s\n", s.key, s.value)
+		overridden[s.section][s.key] = true
+	}
+	var config bytes.Buffer
+	section := ""
+	found := map[string]bool{}
+	for _, line := range strings.SplitAfter(string(shipped), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			config.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				config.WriteString("\n")
+			}
+			if added[section] != nil && !found[section] {
+				found[section] = true
+				config.Write(added[section].Bytes())
+			}
+			continue
+		}
+		if i := strings.IndexAny(trimmed, "=:"); i > 0 && overridden[section][strings.TrimSpace(trimmed[:i])] {
+			continue
+		}
+		config.WriteString(line)
+	}
+	// the sections that are not in the shipped configuration are added before it
+	var missing bytes.Buffer
+	for _, section := range sections {
+		if !found[section] {
+			fmt.Fprintf(&missing, "[%s]\n%s", section, added[section].String())
+		}
+	}
+	return append(missing.Bytes(), config.Bytes()...)
+}
+
+// eventRecording records the event stream in the run directory, with the time each event
+// was read. A recording error does not stop the event stream, and is returned once the
+// event stream is done.
+type eventRecording struct {
+	f   *os.File
+	now func() time.Time
+	err error
+}
+
+func (r *eventRecording) record(line []byte) {
+	if r.err != nil {
+		return
+	}
+	_, r.err = fmt.Fprintf(r.f, "%s\n", recordedLine(line, r.now()))
+}
+
+func (r *eventRecording) close() error {
+	if err := r.f.Close(); r.err == nil {
+		r.err = err
+	}
+	return r.err
+}
+
+// recordedLine adds the time of the event to the JSON line of the event
+func recordedLine(line []byte, t time.Time) []byte {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(line, &fields); err != nil {
+		// the line is recorded as is, and skipped when it is replayed
+		return line
+	}
+	fields["time"], _ = json.Marshal(t)
+	recorded, err := json.Marshal(fields)
+	if err != nil {
+		return line
 	}
-	return out.Sync()
+	return recorded
 }
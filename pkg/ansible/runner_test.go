@@ -1,17 +1,385 @@
 package ansible
 
 import (
+	"context"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestWaitPlaybook(t *testing.T) {
-	r, err := NewRunner(ioutil.Discard, ioutil.Discard, "", "/tmp")
+	r, err := NewRunner(ioutil.Discard, ioutil.Discard, "", "/tmp", &Python{})
 	if err != nil {
 		t.Fatalf("Error creating runner: %v", err)
 	}
-	err = r.WaitPlaybook()
+	err = r.WaitPlaybook(context.Background())
 	if err.Error() != "wait called, but playbook not started" {
 		t.Error("Did not get the expected error when calling WaitPlaybook")
 	}
 }
+
+func TestWaitCommandStopsProcessWhenCancelled(t *testing.T) {
+	tests := []struct {
+		script string
+		signal syscall.Signal
+	}{
+		{"sleep 30", syscall.SIGINT},
+		// processes that ignore the interrupt are killed
+		{"trap '' INT; sleep 30", syscall.SIGKILL},
+	}
+	for _, test := range tests {
+		cmd := exec.Command("sh", "-c", test.script)
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("error starting command: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		err := waitCommand(ctx, cmd, 500*time.Millisecond)
+		cancel()
+		if err == nil {
+			t.Errorf("%q: expected an error when the command is stopped", test.script)
+		}
+		if time.Since(start) > 10*time.Second {
+			t.Errorf("%q: the command was not stopped", test.script)
+		}
+		status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+		if !ok || !status.Signaled() || status.Signal() != test.signal {
+			t.Errorf("%q: expected the command to be stopped by %v, but got %v", test.script, test.signal, cmd.ProcessState)
+		}
+	}
+}
+
+func TestKillRunning(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "trap '' INT TERM; sleep 30")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("error starting command: %v", err)
+	}
+	workDir, err := ioutil.TempDir("", "work-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+	trackCommand(cmd, workDir)
+	defer untrackCommand(cmd)
+	KillRunning()
+	if err := cmd.Wait(); err == nil {
+		t.Errorf("expected an error when the command is killed")
+	}
+	status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGKILL {
+		t.Errorf("expected the command to be killed, but got %v", cmd.ProcessState)
+	}
+	if _, err = os.Stat(workDir); !os.IsNotExist(err) {
+		t.Errorf("expected the private directory of the run to be removed, got: %v", err)
+	}
+}
+
+func TestWaitCommandExits(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 3")
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("error starting command: %v", err)
+	}
+	if err := waitCommand(context.Background(), cmd, time.Second); err == nil {
+		t.Errorf("expected the exit status of the command")
+	}
+}
+
+func TestRunConfig(t *testing.T) {
+	shipped := "[defaults]\ntimeout = 60\nlocal_tmp = /tmp/ansible\n\n[ssh_connection]\nssh_args = -o ControlMaster=auto\n"
+	got := string(runConfig([]byte(shipped), []configSetting{{"defaults", "local_tmp", "/run/tmp"}, {"defaults", "retry_files_save_path", "/run"}}))
+	expected := "[defaults]\nlocal_tmp = /run/tmp\nretry_files_save_path = /run\ntimeout = 60\n\n[ssh_connection]\nssh_args = -o ControlMaster=auto\n"
+	if got != expected {
+		t.Errorf("expected config:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// the settings are added to the config when it has no [defaults] section
+	got = string(runConfig([]byte("[ssh_connection]\nssh_args = -o ControlMaster=auto\n"), []configSetting{{"defaults", "local_tmp", "/run/tmp"}}))
+	expected = "[defaults]\nlocal_tmp = /run/tmp\n[ssh_connection]\nssh_args = -o ControlMaster=auto\n"
+	if got != expected {
+		t.Errorf("expected config:\n%s\ngot:\n%s", expected, got)
+	}
+
+	// the settings of the other sections are overridden in the same way
+	shipped = "[defaults]\ntimeout = 60\n\n[ssh_connection]\nssh_args = -o ControlMaster=auto\ncontrol_path = /tmp/ssh-%%h\n"
+	got = string(runConfig([]byte(shipped), []configSetting{{"defaults", "local_tmp", "/run/tmp"}, {"ssh_connection", "control_path", "/run/cp/%%h"}}))
+	expected = "[defaults]\nlocal_tmp = /run/tmp\ntimeout = 60\n\n[ssh_connection]\ncontrol_path = /run/cp/%%h\nssh_args = -o ControlMaster=auto\n"
+	if got != expected {
+		t.Errorf("expected config:\n%s\ngot:\n%s", expected, got)
+	}
+}
+
+func TestCreateControlDirOutsideLongRunDirectory(t *testing.T) {
+	r := &runner{workDir: "/" + strings.Repeat("d", maxControlDirLength)}
+	dir, err := r.createControlDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.HasPrefix(dir, r.workDir) || len(dir) > maxControlDirLength {
+		t.Errorf("expected a shorter directory for the ssh control sockets, but got %q", dir)
+	}
+	r.workDir = ""
+	if err = r.removeWorkDir(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the directory of the ssh control sockets to be removed, got: %v", err)
+	}
+}
+
+func TestStartPlaybookKeepsFilesInRunDirectory(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	runDir, err := ioutil.TempDir("", "run-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(runDir)
+	for _, dir := range []string{"bin", "playbooks"} {
+		if err = os.MkdirAll(filepath.Join(ansibleDir, dir), 0755); err != nil {
+			t.Fatalf("error creating dir: %v", err)
+		}
+	}
+	files := map[string]string{
+		"playbooks/kubernetes.yaml": "",
+		"playbooks/ansible.cfg":     "[defaults]\ntimeout = 60\n",
+		// the fake ansible records its environment and the files of the run
+		"bin/ansible-playbook": "#!/bin/sh\nenv > " + filepath.Join(runDir, "env") + "\ndirname $ANSIBLE_JSON_LINES_PIPE > " + filepath.Join(runDir, "work-dir") + "\nls $(dirname $ANSIBLE_JSON_LINES_PIPE) > " + filepath.Join(runDir, "work-files") + "\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(ansibleDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	// the fake ansible is run by the shell, instead of python
+	r, err := NewRunner(ioutil.Discard, ioutil.Discard, ansibleDir, runDir, &Python{Path: "/bin/sh"})
+	if err != nil {
+		t.Fatalf("Error creating runner: %v", err)
+	}
+	if _, err = r.StartPlaybook(context.Background(), "kubernetes.yaml", Inventory{}, ClusterCatalog{}); err != nil {
+		t.Fatalf("error starting playbook: %v", err)
+	}
+	if err = r.WaitPlaybook(context.Background()); err != nil {
+		t.Fatalf("error waiting for playbook: %v", err)
+	}
+
+	env, err := ioutil.ReadFile(filepath.Join(runDir, "env"))
+	if err != nil {
+		t.Fatalf("error reading environment of ansible: %v", err)
+	}
+	if !strings.Contains(string(env), "ANSIBLE_CONFIG="+filepath.Join(runDir, "ansible.cfg")+"\n") {
+		t.Errorf("ansible did not use the configuration of the run directory:\n%s", env)
+	}
+	out, err := ioutil.ReadFile(filepath.Join(runDir, "work-dir"))
+	if err != nil {
+		t.Fatalf("error reading private directory of the run: %v", err)
+	}
+	workDir := strings.TrimSpace(string(out))
+	if strings.HasPrefix(workDir, runDir) {
+		t.Errorf("the private directory of the run %q is in the run directory", workDir)
+	}
+	config, err := ioutil.ReadFile(filepath.Join(runDir, "ansible.cfg"))
+	if err != nil {
+		t.Fatalf("error reading ansible configuration of the run: %v", err)
+	}
+	if !strings.Contains(string(config), "control_path = "+filepath.Join(workDir, "cp")+"/%%h-%%p-%%r\n") {
+		t.Errorf("the ssh control sockets are not kept in the private directory of the run:\n%s", config)
+	}
+	if os.Getenv("ANSIBLE_CONFIG") != "" {
+		t.Errorf("the ansible environment was set in the current process")
+	}
+	workFiles, err := ioutil.ReadFile(filepath.Join(runDir, "work-files"))
+	if err != nil {
+		t.Fatalf("error reading files of the run: %v", err)
+	}
+	if string(workFiles) != "clustercatalog.yaml\ncp\nevents\n" {
+		t.Errorf("unexpected files in the private directory of the run:\n%s", workFiles)
+	}
+	for _, f := range []string{"ansible.cfg", "inventory.ini", "clustercatalog.yaml", EventsFilename} {
+		if _, err = os.Stat(filepath.Join(runDir, f)); err != nil {
+			t.Errorf("expected %s in the run directory: %v", f, err)
+		}
+	}
+	// the files that are only needed while ansible runs are removed
+	if _, err = os.Stat(workDir); !os.IsNotExist(err) {
+		t.Errorf("expected the private directory of the run to be removed, got: %v", err)
+	}
+	// nothing is written to the ansible directory
+	for _, f := range []string{"clustercatalog.yaml", "inventory.ini"} {
+		if _, err = os.Stat(filepath.Join(ansibleDir, f)); !os.IsNotExist(err) {
+			t.Errorf("expected no %s in the ansible directory, got: %v", f, err)
+		}
+	}
+}
+
+func TestStartPlaybookKeepsSecretsOutOfRunDirectory(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	runDir, err := ioutil.TempDir("", "run-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(runDir)
+	for _, dir := range []string{"bin", "playbooks"} {
+		if err = os.MkdirAll(filepath.Join(ansibleDir, dir), 0755); err != nil {
+			t.Fatalf("error creating dir: %v", err)
+		}
+	}
+	files := map[string]string{
+		"playbooks/kubernetes.yaml": "",
+		"playbooks/ansible.cfg":     "[defaults]\ntimeout = 60\n",
+		// the fake ansible records whether it was given the secrets, and the files of the run
+		// directory that hold them while it runs
+		"bin/ansible-playbook": "#!/bin/sh\ngrep -c s3cret $(dirname $ANSIBLE_JSON_LINES_PIPE)/clustercatalog.yaml > " + filepath.Join(runDir, "secrets") +
+			"\ngrep -rl -D skip s3cret " + runDir + " > " + filepath.Join(ansibleDir, "secret-files") + "\ntrue\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(ansibleDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	r, err := NewRunner(ioutil.Discard, ioutil.Discard, ansibleDir, runDir, &Python{Path: "/bin/sh"})
+	if err != nil {
+		t.Fatalf("Error creating runner: %v", err)
+	}
+	cc := ClusterCatalog{
+		AdminPassword:          "admin-s3cret",
+		DockerRegistryPassword: "registry-s3cret",
+	}
+	cc.CNI.Options.Weave.Password = "weave-s3cret"
+	if _, err = r.StartPlaybook(context.Background(), "kubernetes.yaml", Inventory{}, cc); err != nil {
+		t.Fatalf("error starting playbook: %v", err)
+	}
+	if err = r.WaitPlaybook(context.Background()); err != nil {
+		t.Fatalf("error waiting for playbook: %v", err)
+	}
+
+	secrets, err := ioutil.ReadFile(filepath.Join(runDir, "secrets"))
+	if err != nil {
+		t.Fatalf("error reading secrets given to ansible: %v", err)
+	}
+	if strings.TrimSpace(string(secrets)) != "3" {
+		t.Errorf("expected ansible to be given the 3 secrets, but it was given %s", secrets)
+	}
+	secretFiles, err := ioutil.ReadFile(filepath.Join(ansibleDir, "secret-files"))
+	if err != nil {
+		t.Fatalf("error reading files with secrets: %v", err)
+	}
+	if len(secretFiles) > 0 {
+		t.Errorf("the run directory has secrets while ansible runs in:\n%s", secretFiles)
+	}
+	err = filepath.Walk(runDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if strings.Contains(string(b), "s3cret") {
+			t.Errorf("the run directory has a secret in %s:\n%s", path, b)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error walking the run directory: %v", err)
+	}
+}
+
+func TestWaitPlaybookDrainsEventStream(t *testing.T) {
+	ansibleDir, err := ioutil.TempDir("", "ansible-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(ansibleDir)
+	runDir, err := ioutil.TempDir("", "run-dir")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(runDir)
+	for _, dir := range []string{"bin", "playbooks"} {
+		if err = os.MkdirAll(filepath.Join(ansibleDir, dir), 0755); err != nil {
+			t.Fatalf("error creating dir: %v", err)
+		}
+	}
+	files := map[string]string{
+		"playbooks/kubernetes.yaml": "",
+		"playbooks/ansible.cfg":     "[defaults]\n",
+		// the fake ansible writes the events and exits, before they are all read
+		"bin/ansible-playbook": "#!/bin/sh\ni=0\nwhile [ $i -lt 100 ]; do echo '{\"eventType\":\"PLAY_START\",\"eventData\":{\"Name\":\"play'$i'\"}}'; i=$((i+1)); done > $ANSIBLE_JSON_LINES_PIPE\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(ansibleDir, name), []byte(content), 0755); err != nil {
+			t.Fatalf("error writing file: %v", err)
+		}
+	}
+
+	r, err := NewRunner(ioutil.Discard, ioutil.Discard, ansibleDir, runDir, &Python{Path: "/bin/sh"})
+	if err != nil {
+		t.Fatalf("Error creating runner: %v", err)
+	}
+	events, err := r.StartPlaybook(context.Background(), "kubernetes.yaml", Inventory{}, ClusterCatalog{})
+	if err != nil {
+		t.Fatalf("error starting playbook: %v", err)
+	}
+	read := make(chan int)
+	go func() {
+		n := 0
+		for range events {
+			// a slow consumer
+			time.Sleep(time.Millisecond)
+			n++
+		}
+		read <- n
+	}()
+	if err = r.WaitPlaybook(context.Background()); err != nil {
+		t.Fatalf("error waiting for playbook: %v", err)
+	}
+	recorded, err := ioutil.ReadFile(filepath.Join(runDir, EventsFilename))
+	if err != nil {
+		t.Fatalf("error reading recorded events: %v", err)
+	}
+	if n := strings.Count(string(recorded), "\n"); n != 100 {
+		t.Errorf("expected 100 recorded events when the playbook is done, but got %d", n)
+	}
+	if n := strings.Count(string(recorded), `"time":`); n != 100 {
+		t.Errorf("expected the events to be recorded with their time, but got %d timed events", n)
+	}
+	select {
+	case n := <-read:
+		if n != 100 {
+			t.Errorf("expected 100 events, but got %d", n)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("the event stream was not closed after the playbook was done")
+	}
+}
+
+func TestEventRecordingError(t *testing.T) {
+	f, err := ioutil.TempFile("", "events")
+	if err != nil {
+		t.Fatalf("error creating temp file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	r := &eventRecording{f: f, now: time.Now}
+	r.record([]byte(`{"eventType":"PLAY_START","eventData":{"name":"play"}}`))
+	f.Close()
+	r.record([]byte(`{"eventType":"PLAY_START","eventData":{"name":"play"}}`))
+	if err = r.close(); err == nil {
+		t.Errorf("expected the error of writing the recording")
+	}
+}
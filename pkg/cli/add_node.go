@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -20,6 +21,7 @@ type addNodeOpts struct {
 	OutputFormat             string
 	Verbose                  bool
 	SkipPreFlight            bool
+	Timeout                  time.Duration
 }
 
 var validRoles = []string{"worker", "ingress", "storage"}
@@ -32,6 +34,7 @@ func NewCmdAddNode(out io.Writer, installOpts *installOpts) *cobra.Command {
 		Short:   "add a new node to an existing Kubernetes cluster",
 		Aliases: []string{"add-worker"},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(opts.GeneratedAssetsDirectory)
 			if len(args) < 2 || len(args) > 3 {
 				return cmd.Usage()
 			}
@@ -61,7 +64,12 @@ func NewCmdAddNode(out io.Writer, installOpts *installOpts) *cobra.Command {
 					newNode.Labels[pair[0]] = pair[1]
 				}
 			}
-			return doAddNode(out, installOpts.planFilename, opts, newNode)
+			if len(installOpts.planOverlays) != 0 {
+				return errors.New("add-node updates the plan file, and cannot be used with plan overlays")
+			}
+			return withPlanLock(installOpts.planFilename, func() error {
+				return doAddNode(out, installOpts.planFilename, opts, newNode)
+			})
 		},
 	}
 	cmd.Flags().StringSliceVar(&opts.Roles, "roles", []string{}, "roles separated by ',' (options \"worker\"|\"ingress\"|\"storage\")")
@@ -71,18 +79,25 @@ func NewCmdAddNode(out io.Writer, installOpts *installOpts) *cobra.Command {
 	cmd.Flags().BoolVar(&opts.Verbose, "verbose", false, "enable verbose logging from the installation")
 	cmd.Flags().StringVarP(&opts.OutputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
 	cmd.Flags().BoolVar(&opts.SkipPreFlight, "skip-preflight", false, "skip pre-flight checks, useful when rerunning kismatic")
+	cmd.Flags().DurationVar(&opts.Timeout, "timeout", 0, "the maximum duration of the command, such as 90m. The running playbook is stopped, and the run is recorded as aborted, when it is exceeded. The command does not time out when 0")
 	return cmd
 }
 
 func doAddNode(out io.Writer, planFile string, opts *addNodeOpts, newNode install.Node) error {
+	if err := checkNoJSONOutput(opts.OutputFormat); err != nil {
+		return err
+	}
 	planner := &install.FilePlanner{File: planFile}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
+	ctx, cancel := runContext(opts.Timeout)
+	defer cancel()
 	execOpts := install.ExecutorOptions{
 		GeneratedAssetsDirectory: opts.GeneratedAssetsDirectory,
 		OutputFormat:             opts.OutputFormat,
 		Verbose:                  opts.Verbose,
+		Context:                  ctx,
 	}
 	executor, err := install.NewExecutor(out, os.Stderr, execOpts)
 	if err != nil {
@@ -102,10 +117,15 @@ func doAddNode(out io.Writer, planFile string, opts *addNodeOpts, newNode instal
 		util.PrintValidationErrors(out, errs)
 		return errors.New("the plan file failed validation")
 	}
+	// the new node uses the SSH configuration of its node groups
+	nodeSSHConfig := validatePlan.SSHConfigForNode(newNode)
 	nodeSSHCon := &install.SSHConnection{
-		SSHConfig: &plan.Cluster.SSH,
+		SSHConfig: &nodeSSHConfig,
 		Node:      &newNode,
 	}
+	if err = validatePlan.ImportKnownHosts(); err != nil {
+		return err
+	}
 	if _, errs := install.ValidateSSHConnection(nodeSSHCon, "New node"); errs != nil {
 		util.PrintValidationErrors(out, errs)
 		return errors.New("could not establish SSH connection to the new node")
@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -21,6 +23,9 @@ type applyCmd struct {
 	skipPreFlight      bool
 	restartServices    bool
 	limit              []string
+	resume             bool
+	retryFailed        bool
+	ctx                context.Context
 }
 
 type applyOpts struct {
@@ -30,6 +35,9 @@ type applyOpts struct {
 	outputFormat       string
 	skipPreFlight      bool
 	limit              []string
+	resume             bool
+	retryFailed        bool
+	timeout            time.Duration
 }
 
 // NewCmdApply creates a cluter using the plan file
@@ -39,14 +47,18 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 		Use:   "apply",
 		Short: "apply your plan file to create a Kubernetes cluster",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(applyOpts.generatedAssetsDir)
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-			planner := &install.FilePlanner{File: installOpts.planFilename}
+			planner := &install.FilePlanner{File: installOpts.planFilename, Overlays: installOpts.planOverlays}
+			ctx, cancel := runContext(applyOpts.timeout)
+			defer cancel()
 			executorOpts := install.ExecutorOptions{
 				GeneratedAssetsDirectory: applyOpts.generatedAssetsDir,
 				OutputFormat:             applyOpts.outputFormat,
 				Verbose:                  applyOpts.verbose,
+				Context:                  ctx,
 			}
 			executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
 			if err != nil {
@@ -64,8 +76,11 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 				skipPreFlight:      applyOpts.skipPreFlight,
 				restartServices:    applyOpts.restartServices,
 				limit:              applyOpts.limit,
+				resume:             applyOpts.resume,
+				retryFailed:        applyOpts.retryFailed,
+				ctx:                ctx,
 			}
-			return applyCmd.run()
+			return withPlanLock(installOpts.planFilename, applyCmd.run)
 		},
 	}
 
@@ -74,21 +89,30 @@ func NewCmdApply(out io.Writer, installOpts *installOpts) *cobra.Command {
 	cmd.Flags().StringVar(&applyOpts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&applyOpts.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.Flags().BoolVar(&applyOpts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&applyOpts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVarP(&applyOpts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\"). With \"json\", the ansible events are written to stdout as JSON lines, and all other messages to stderr")
 	cmd.Flags().BoolVar(&applyOpts.skipPreFlight, "skip-preflight", false, "skip pre-flight checks, useful when rerunning kismatic")
+	cmd.Flags().DurationVar(&applyOpts.timeout, "timeout", 0, "the maximum duration of the command, such as 90m. The running playbook is stopped, and the run is recorded as aborted, when it is exceeded. The command does not time out when 0")
+	cmd.Flags().BoolVar(&applyOpts.retryFailed, "retry-failed", false, "run the playbook of the last failed installation again, limited to the nodes that failed or were unreachable, skipping the pre-flight checks")
+	cmd.Flags().BoolVar(&applyOpts.resume, "resume", false, "resume the last failed installation from the first play it did not complete, skipping the pre-flight checks. The plan file must not have changed since that installation")
 
 	return cmd
 }
 
 func (c *applyCmd) run() error {
-	// Validate and run pre-flight
+	out := messageWriter(c.out, c.outputFormat)
+	if c.retryFailed && (c.resume || len(c.limit) > 0) {
+		return fmt.Errorf("--retry-failed cannot be used with --resume or --limit")
+	}
+	// Validate and run pre-flight. The pre-flight checks are skipped when resuming
+	// or retrying, as they fail on partially installed nodes.
 	opts := &validateOpts{
 		planFile:           c.planFile,
 		verbose:            c.verbose,
 		outputFormat:       c.outputFormat,
-		skipPreFlight:      c.skipPreFlight,
+		skipPreFlight:      c.skipPreFlight || c.resume || c.retryFailed,
 		generatedAssetsDir: c.generatedAssetsDir,
 		limit:              c.limit,
+		ctx:                c.ctx,
 	}
 	err := doValidate(c.out, c.planner, opts)
 	if err != nil {
@@ -105,15 +129,23 @@ func (c *applyCmd) run() error {
 	}
 
 	// Generate kubeconfig
-	util.PrintHeader(c.out, "Generating Kubeconfig File", '=')
+	util.PrintHeader(out, "Generating Kubeconfig File", '=')
 	err = install.GenerateKubeconfig(plan, c.generatedAssetsDir)
 	if err != nil {
 		return fmt.Errorf("error generating kubeconfig file: %v", err)
 	}
-	util.PrettyPrintOk(c.out, "Generated kubeconfig file in the %q directory", c.generatedAssetsDir)
+	util.PrettyPrintOk(out, "Generated kubeconfig file in the %q directory", c.generatedAssetsDir)
 
 	// Perform the installation
-	if err := c.executor.Install(plan, c.restartServices, c.limit...); err != nil {
+	if c.resume {
+		if err := c.executor.ResumeInstall(plan, c.restartServices, c.limit...); err != nil {
+			return fmt.Errorf("error resuming installation: %v", err)
+		}
+	} else if c.retryFailed {
+		if err := c.executor.RetryInstall(plan, c.restartServices); err != nil {
+			return fmt.Errorf("error retrying installation: %v", err)
+		}
+	} else if err := c.executor.Install(plan, c.restartServices, c.limit...); err != nil {
 		return fmt.Errorf("error installing: %v", err)
 	}
 
@@ -125,16 +157,16 @@ func (c *applyCmd) run() error {
 		}
 	}
 
-	util.PrintColor(c.out, util.Green, "\nThe cluster was installed successfully!\n")
-	fmt.Fprintln(c.out)
+	util.PrintColor(out, util.Green, "\nThe cluster was installed successfully!\n")
+	fmt.Fprintln(out)
 
 	msg := "- To use the generated kubeconfig file with kubectl:" +
 		"\n    * use \"./kubectl --kubeconfig %s/kubeconfig\"" +
 		"\n    * or copy the config file \"cp %[1]s/kubeconfig ~/.kube/config\"\n"
-	util.PrintColor(c.out, util.Blue, msg, c.generatedAssetsDir)
-	util.PrintColor(c.out, util.Blue, "- To view the Kubernetes dashboard: \"./kismatic dashboard\"\n")
-	util.PrintColor(c.out, util.Blue, "- To SSH into a cluster node: \"./kismatic ssh etcd|master|worker|storage|$node.host\"\n")
-	fmt.Fprintln(c.out)
+	util.PrintColor(out, util.Blue, msg, c.generatedAssetsDir)
+	util.PrintColor(out, util.Blue, "- To view the Kubernetes dashboard: \"./kismatic dashboard\"\n")
+	util.PrintColor(out, util.Blue, "- To SSH into a cluster node: \"./kismatic ssh etcd|master|worker|storage|$node.host\"\n")
+	fmt.Fprintln(out)
 
 	return nil
 }
@@ -64,3 +64,20 @@ func TestApplyCmdInvalidPlanFound(t *testing.T) {
 // 		t.Errorf("did not read CA cert when skip CA generation was set to true")
 // 	}
 // }
+
+func TestApplyCmdRetryFailedWithLimit(t *testing.T) {
+	fe := &fakeExecutor{}
+	applyCmd := &applyCmd{
+		out:         &bytes.Buffer{},
+		planner:     &fakePlanner{exists: true, plan: &install.Plan{}},
+		executor:    fe,
+		retryFailed: true,
+		limit:       []string{"worker01"},
+	}
+	if err := applyCmd.run(); err == nil {
+		t.Error("expected an error when using --retry-failed with --limit")
+	}
+	if fe.retryCalled {
+		t.Error("retry was called with an invalid combination of flags")
+	}
+}
@@ -1,13 +1,163 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/ssh"
+	"github.com/apprenda/kismatic/pkg/util"
 	"github.com/spf13/pflag"
 )
 
-func addPlanFileFlag(flagSet *pflag.FlagSet, p *string) {
-	flagSet.StringVarP(p, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
+// etcdSnapshotOpts are the options of the etcd snapshot that is taken before the cluster
+// is upgraded or reset
+type etcdSnapshotOpts struct {
+	skip     bool
+	retained int
+}
+
+func addEtcdSnapshotFlags(flagSet *pflag.FlagSet, opts *etcdSnapshotOpts) {
+	flagSet.BoolVar(&opts.skip, "skip-etcd-snapshot", false, "do not take a snapshot of etcd before changing the cluster")
+	flagSet.IntVar(&opts.retained, "etcd-snapshots-retained", install.DefaultEtcdSnapshotsRetained, "the number of etcd snapshots kept in the etcd-snapshots directory of the generated assets directory. The oldest snapshots are removed when a snapshot is taken")
+}
+
+func validateEtcdSnapshotOpts(opts etcdSnapshotOpts) error {
+	if opts.retained < 1 {
+		return fmt.Errorf("etcd-snapshots-retained must be greater or equal to 1, got: %d", opts.retained)
+	}
+	return nil
+}
+
+// snapshotEtcd takes a snapshot of etcd, prints its path, and removes the snapshots that
+// are not retained
+func snapshotEtcd(out io.Writer, executor install.Executor, plan *install.Plan, generatedAssetsDir string, opts etcdSnapshotOpts, dryRun bool) error {
+	if opts.skip {
+		util.PrintHeader(out, "Taking Etcd Snapshot", '=')
+		util.PrettyPrintSkipped(out, "Taking etcd snapshot, as --skip-etcd-snapshot is set")
+		return nil
+	}
+	snapshot, err := executor.SnapshotEtcd(plan)
+	if err != nil {
+		return fmt.Errorf("error taking etcd snapshot: %v. Use --skip-etcd-snapshot to continue without a snapshot", err)
+	}
+	if dryRun {
+		util.PrettyPrintOk(out, "The etcd snapshot would be saved to %q", snapshot.Path)
+		return nil
+	}
+	util.PrettyPrintOk(out, "Saved etcd snapshot to %q", snapshot.Path)
+	return pruneEtcdSnapshots(out, generatedAssetsDir, opts.retained)
+}
+
+// pruneEtcdSnapshots removes the etcd snapshots that are not retained, and prints their paths
+func pruneEtcdSnapshots(out io.Writer, generatedAssetsDir string, retained int) error {
+	removed, err := install.PruneEtcdSnapshots(generatedAssetsDir, retained)
+	if err != nil {
+		return err
+	}
+	for _, r := range removed {
+		util.PrettyPrintOk(out, "Removed etcd snapshot %q", r)
+	}
+	return nil
+}
+
+// addPlanFilesFlag adds a plan-file flag that can be repeated. The first plan file
+// is the base plan, and the rest are overlays that are merged on top of it, in order.
+func addPlanFilesFlag(flagSet *pflag.FlagSet, p *string, overlays *[]string) {
+	*p = "kismatic-cluster.yaml"
+	flagSet.VarP(&planFilesValue{file: p, overlays: overlays}, "plan-file", "f", "path to the installation plan file. Repeat the flag to merge plan overlays on top of the first plan file")
+}
+
+type planFilesValue struct {
+	file     *string
+	overlays *[]string
+	changed  bool
+}
+
+func (v *planFilesValue) Set(s string) error {
+	if !v.changed {
+		*v.file = s
+		v.changed = true
+		return nil
+	}
+	*v.overlays = append(*v.overlays, s)
+	return nil
+}
+
+func (v *planFilesValue) String() string {
+	return strings.Join(append([]string{*v.file}, *v.overlays...), ",")
+}
+
+func (v *planFilesValue) Type() string {
+	return "string"
+}
+
+// setKnownHostsFile verifies the host keys of the nodes against the known_hosts file
+// in the generated assets directory
+func setKnownHostsFile(generatedAssetsDir string) {
+	ssh.SetKnownHostsFile(filepath.Join(generatedAssetsDir, ssh.KnownHostsFilename))
+}
+
+// messageWriter returns the writer for the messages of a command. With the "json"
+// output format, stdout only carries the ansible events, and messages go to stderr.
+func messageWriter(out io.Writer, outputFormat string) io.Writer {
+	if outputFormat == "json" {
+		return os.Stderr
+	}
+	return out
+}
+
+// checkNoJSONOutput returns an error when the "json" output format is requested from a
+// command that prints its messages to stdout, where they would be mixed with the JSON events.
+func checkNoJSONOutput(outputFormat string) error {
+	if outputFormat == "json" {
+		return fmt.Errorf("output format %q is not supported by this command, use \"simple\" or \"raw\"", outputFormat)
+	}
+	return nil
+}
+
+// runContext returns the context of the runs of a command, which is done when the
+// command is interrupted by SIGINT or SIGTERM, or when the timeout is exceeded, if set.
+// A second signal kills the running playbook and exits immediately.
+// The returned function must be called once the command is complete.
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "\nReceived %s, stopping the running playbook. Interrupt again to kill it and exit without waiting.\n", sig)
+			cancel()
+		case <-ctx.Done():
+		case <-stop:
+			return
+		}
+		select {
+		case sig := <-signals:
+			fmt.Fprintf(os.Stderr, "\nReceived %s, killing the running playbook.\n", sig)
+			ansible.KillRunning()
+			os.Exit(1)
+		case <-stop:
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(signals)
+		close(stop)
+		cancel()
+	}
 }
 
 type planFileNotFoundErr struct {
@@ -17,3 +167,14 @@ type planFileNotFoundErr struct {
 func (e planFileNotFoundErr) Error() string {
 	return fmt.Sprintf("Plan file not found at %q. If you don't have a plan file, you may generate one with 'kismatic install plan'", e.filename)
 }
+
+// withPlanLock runs the operation while holding the lock of the plan file, so that other
+// kismatic processes cannot run operations against the same plan file at the same time
+func withPlanLock(planFile string, run func() error) error {
+	lock, err := install.LockPlanFile(planFile)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+	return run()
+}
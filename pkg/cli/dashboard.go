@@ -19,13 +19,14 @@ const dashboardAdminKubeconfigFilename = "dashboard-admin-kubeconfig"
 type dashboardOpts struct {
 	generatedAssetsDir string
 	planFilename       string
+	planOverlays       []string
 }
 
 const url = "http://localhost:8001/api/v1/namespaces/kube-system/services/https:kubernetes-dashboard:/proxy/#!/login"
 
 // NewCmdDashboard opens or displays the dashboard URL
 func NewCmdDashboard(in io.Reader, out io.Writer) *cobra.Command {
-	opts := dashboardOpts{}
+	opts := &dashboardOpts{}
 
 	cmd := &cobra.Command{
 		Use:   "dashboard",
@@ -61,12 +62,13 @@ func NewCmdDashboard(in io.Reader, out io.Writer) *cobra.Command {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
+			setKnownHostsFile(opts.generatedAssetsDir)
 			return doDashboard(in, out, opts)
 		},
 	}
 
 	cmd.PersistentFlags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
-	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanFilesFlag(cmd.PersistentFlags(), &opts.planFilename, &opts.planOverlays)
 
 	cmd.AddCommand(NewCmdDashboardURL(out))
 	cmd.AddCommand(NewCmdDashboardToken(out, opts))
@@ -89,7 +91,7 @@ func NewCmdDashboardURL(out io.Writer) *cobra.Command {
 	}
 }
 
-func NewCmdDashboardToken(out io.Writer, opts dashboardOpts) *cobra.Command {
+func NewCmdDashboardToken(out io.Writer, opts *dashboardOpts) *cobra.Command {
 	return &cobra.Command{
 		Use:   "token",
 		Short: "Print the ServiceAccount 'kubernetes-dashboard-admin' token",
@@ -111,7 +113,7 @@ func NewCmdDashboardToken(out io.Writer, opts dashboardOpts) *cobra.Command {
 	}
 }
 
-func NewCmdDashboardKubeconfig(out io.Writer, opts dashboardOpts) *cobra.Command {
+func NewCmdDashboardKubeconfig(out io.Writer, opts *dashboardOpts) *cobra.Command {
 	return &cobra.Command{
 		Use:   "kubeconfig",
 		Short: "Generate a kubeconfig file with the ServiceAccount 'kubernetes-dashboard-admin' token",
@@ -119,6 +121,7 @@ func NewCmdDashboardKubeconfig(out io.Writer, opts dashboardOpts) *cobra.Command
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
+			setKnownHostsFile(opts.generatedAssetsDir)
 			kubeconfig := filepath.Join(opts.generatedAssetsDir, "kubeconfig")
 			if stat, err := os.Stat(kubeconfig); os.IsNotExist(err) || stat.IsDir() {
 				return fmt.Errorf("Did not find required kubeconfig file %q", kubeconfig)
@@ -126,7 +129,7 @@ func NewCmdDashboardKubeconfig(out io.Writer, opts dashboardOpts) *cobra.Command
 			adminKubeconfig := filepath.Join(opts.generatedAssetsDir, dashboardAdminKubeconfigFilename)
 			// Generate dashboard admin certificate if it does not exist
 			if _, err := os.Stat(adminKubeconfig); os.IsNotExist(err) {
-				if err := generateKubeconfig(opts.planFilename, opts.generatedAssetsDir, adminKubeconfig); err != nil {
+				if err := generateKubeconfig(opts.planFilename, opts.planOverlays, opts.generatedAssetsDir, adminKubeconfig); err != nil {
 					return err
 				}
 				fmt.Fprintf(out, "Generated kubeconfig in %q\n", adminKubeconfig)
@@ -138,7 +141,7 @@ func NewCmdDashboardKubeconfig(out io.Writer, opts dashboardOpts) *cobra.Command
 	}
 }
 
-func doDashboard(in io.Reader, out io.Writer, opts dashboardOpts) error {
+func doDashboard(in io.Reader, out io.Writer, opts *dashboardOpts) error {
 	kubeconfig := filepath.Join(opts.generatedAssetsDir, "kubeconfig")
 	if stat, err := os.Stat(kubeconfig); os.IsNotExist(err) || stat.IsDir() {
 		return fmt.Errorf("Did not find required kubeconfig file %q", kubeconfig)
@@ -147,7 +150,7 @@ func doDashboard(in io.Reader, out io.Writer, opts dashboardOpts) error {
 	adminKubeconfig := filepath.Join(opts.generatedAssetsDir, dashboardAdminKubeconfigFilename)
 	// Generate dashboard admin certificate if it does not exist
 	if _, err := os.Stat(adminKubeconfig); os.IsNotExist(err) {
-		generateErr = generateKubeconfig(opts.planFilename, opts.generatedAssetsDir, adminKubeconfig)
+		generateErr = generateKubeconfig(opts.planFilename, opts.planOverlays, opts.generatedAssetsDir, adminKubeconfig)
 	}
 
 	if generateErr != nil {
@@ -174,8 +177,8 @@ func doDashboard(in io.Reader, out io.Writer, opts dashboardOpts) error {
 	return nil
 }
 
-func generateKubeconfig(planeFile, generatedAssetsDir, outFile string) error {
-	planner := &install.FilePlanner{File: planeFile}
+func generateKubeconfig(planeFile string, planOverlays []string, generatedAssetsDir, outFile string) error {
+	planner := &install.FilePlanner{File: planeFile, Overlays: planOverlays}
 	plan, err := planner.Read()
 	if err != nil {
 		return fmt.Errorf("Error reading plan file: %v", err)
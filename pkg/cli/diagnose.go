@@ -11,9 +11,11 @@ import (
 )
 
 type diagsOpts struct {
-	planFilename string
-	verbose      bool
-	outputFormat string
+	planFilename       string
+	planOverlays       []string
+	verbose            bool
+	outputFormat       string
+	generatedAssetsDir string
 }
 
 // NewCmdDiagnostic collects diagnostic data on remote nodes
@@ -27,24 +29,28 @@ func NewCmdDiagnostic(out io.Writer) *cobra.Command {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-
+			setKnownHostsFile(opts.generatedAssetsDir)
 			return doDiagnostics(out, opts)
 		},
 	}
 
 	// PersistentFlags
-	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanFilesFlag(cmd.PersistentFlags(), &opts.planFilename, &opts.planOverlays)
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
 	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 
 	return cmd
 }
 
 func doDiagnostics(out io.Writer, opts *diagsOpts) error {
+	if err := checkNoJSONOutput(opts.outputFormat); err != nil {
+		return err
+	}
 	util.PrintHeader(out, "Gathering Diagnostic Data", '=')
 
 	planFile := opts.planFilename
-	planner := install.FilePlanner{File: planFile}
+	planner := install.FilePlanner{File: planFile, Overlays: opts.planOverlays}
 
 	// Read plan file
 	if !planner.PlanExists() {
@@ -59,6 +65,10 @@ func doDiagnostics(out io.Writer, opts *diagsOpts) error {
 	}
 
 	// Validate SSH connectivity to nodes
+	if err = plan.ImportKnownHosts(); err != nil {
+		util.PrettyPrintErr(out, "Validate SSH connectivity to nodes")
+		return err
+	}
 	if ok, errs := install.ValidatePlanSSHConnections(plan); !ok {
 		util.PrettyPrintErr(out, "Validate SSH connectivity to nodes")
 		util.PrintValidationErrors(out, errs)
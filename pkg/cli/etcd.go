@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdEtcd returns the etcd command
+func NewCmdEtcd(in io.Reader, out io.Writer) *cobra.Command {
+	var planFile string
+	var planOverlays []string
+	cmd := &cobra.Command{
+		Use:   "etcd",
+		Short: "back up and restore the etcd clusters of your Kubernetes cluster",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	addPlanFilesFlag(cmd.PersistentFlags(), &planFile, &planOverlays)
+	cmd.AddCommand(NewCmdEtcdBackup(out, &planFile, &planOverlays))
+	cmd.AddCommand(NewCmdEtcdRestore(in, out, &planFile, &planOverlays))
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type etcdBackupOpts struct {
+	generatedAssetsDir string
+	verbose            bool
+	outputFormat       string
+	timeout            time.Duration
+}
+
+// NewCmdEtcdBackup returns the command for taking a snapshot of the etcd clusters
+func NewCmdEtcdBackup(out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
+	opts := etcdBackupOpts{}
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "take a snapshot of the etcd clusters",
+		Long: `Take a snapshot of the etcd clusters, using the etcd client certificates of the
+generated assets directory.
+
+The snapshot is taken on the first node of the etcd node group of the plan, and saved in a
+directory of the etcd-backups directory of the generated assets directory. Unlike the
+snapshots taken before an upgrade or a reset, these snapshots are never removed by kismatic.
+The snapshot of the networking etcd cluster is included when Calico is the CNI provider.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(opts.generatedAssetsDir)
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			planner := &install.FilePlanner{File: *planFile, Overlays: *planOverlays}
+			return doEtcdBackup(out, planner, *planFile, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options simple|raw)`)
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "the maximum duration of the command, such as 30m. The running playbook is stopped, and the run is recorded as aborted, when it is exceeded. The command does not time out when 0")
+	return cmd
+}
+
+func doEtcdBackup(out io.Writer, planner install.Planner, planFile string, opts etcdBackupOpts) error {
+	if err := checkNoJSONOutput(opts.outputFormat); err != nil {
+		return err
+	}
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read plan file: %v", err)
+	}
+	ctx, cancel := runContext(opts.timeout)
+	defer cancel()
+	execOpts := install.ExecutorOptions{
+		GeneratedAssetsDirectory: opts.generatedAssetsDir,
+		OutputFormat:             opts.outputFormat,
+		Verbose:                  opts.verbose,
+		Context:                  ctx,
+	}
+	executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+	if err != nil {
+		return err
+	}
+	snapshot, err := executor.BackupEtcd(plan)
+	if err != nil {
+		return fmt.Errorf("error taking etcd snapshot: %v", err)
+	}
+	util.PrettyPrintOk(out, "Saved etcd snapshot to %q", snapshot.Path)
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "The etcd clusters can be restored from the snapshot with 'kismatic etcd restore %s'\n", snapshot.Path)
+	return nil
+}
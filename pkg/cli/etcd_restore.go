@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type etcdRestoreOpts struct {
+	generatedAssetsDir string
+	verbose            bool
+	outputFormat       string
+	timeout            time.Duration
+	force              bool
+}
+
+// NewCmdEtcdRestore returns the command for restoring the etcd clusters from a snapshot
+func NewCmdEtcdRestore(in io.Reader, out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
+	opts := etcdRestoreOpts{}
+	cmd := &cobra.Command{
+		Use:   "restore snapshot",
+		Short: "restore the etcd clusters from a snapshot",
+		Long: `Restore the etcd clusters from a snapshot taken by 'etcd backup', or taken before
+an upgrade or a reset.
+
+The snapshot is the path of the snapshot directory, or its name in the etcd-snapshots or
+etcd-backups directory of the generated assets directory.
+
+The Kubernetes control plane is stopped, every member of the etcd node group of the plan is
+restored from the snapshot, and the control plane is started once the etcd clusters are
+healthy. The data of each etcd member is moved aside, to a directory suffixed with
+".before-restore" and the time of the restore.
+
+WARNING all changes made to the cluster after the snapshot was taken will be lost.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(opts.generatedAssetsDir)
+			if len(args) != 1 {
+				return fmt.Errorf("the snapshot to restore is required")
+			}
+			snapshot, err := install.ReadEtcdSnapshot(opts.generatedAssetsDir, args[0])
+			if err != nil {
+				return err
+			}
+			if opts.force == false {
+				ans, err := util.PromptForString(in, out, fmt.Sprintf("Are you sure you want to restore the etcd clusters from the snapshot taken at %s? All changes made after the snapshot was taken will be lost", snapshot.Time.Format(time.RFC1123)), "N", []string{"N", "y"})
+				if err != nil {
+					return fmt.Errorf("error getting user response: %v", err)
+				}
+				if strings.ToLower(ans) != "y" {
+					os.Exit(0)
+				}
+			}
+			planner := &install.FilePlanner{File: *planFile, Overlays: *planOverlays}
+			return withPlanLock(*planFile, func() error {
+				return doEtcdRestore(out, planner, *planFile, *snapshot, opts)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options simple|raw)`)
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "the maximum duration of the command, such as 30m. The running playbook is stopped, and the run is recorded as aborted, when it is exceeded. The command does not time out when 0")
+	cmd.Flags().BoolVar(&opts.force, "force", false, `do not prompt`)
+	return cmd
+}
+
+func doEtcdRestore(out io.Writer, planner install.Planner, planFile string, snapshot install.EtcdSnapshot, opts etcdRestoreOpts) error {
+	if err := checkNoJSONOutput(opts.outputFormat); err != nil {
+		return err
+	}
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("failed to read plan file: %v", err)
+	}
+	ctx, cancel := runContext(opts.timeout)
+	defer cancel()
+	execOpts := install.ExecutorOptions{
+		GeneratedAssetsDirectory: opts.generatedAssetsDir,
+		OutputFormat:             opts.outputFormat,
+		Verbose:                  opts.verbose,
+		Context:                  ctx,
+	}
+	executor, err := install.NewExecutor(out, os.Stderr, execOpts)
+	if err != nil {
+		return err
+	}
+	if err := executor.RestoreEtcd(plan, snapshot); err != nil {
+		return fmt.Errorf("error restoring etcd snapshot: %v", err)
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "Restored the etcd clusters from the snapshot %q\n", snapshot.Path)
+	return nil
+}
@@ -28,6 +28,8 @@ func (fp *fakePlanner) Write(p *install.Plan) error {
 
 type fakeExecutor struct {
 	installCalled bool
+	resumeCalled  bool
+	retryCalled   bool
 	err           error
 }
 
@@ -44,6 +46,16 @@ func (fe *fakeExecutor) Install(p *install.Plan, restartServices bool, nodes ...
 	return fe.err
 }
 
+func (fe *fakeExecutor) ResumeInstall(p *install.Plan, restartServices bool, nodes ...string) error {
+	fe.resumeCalled = true
+	return fe.err
+}
+
+func (fe *fakeExecutor) RetryInstall(p *install.Plan, restartServices bool) error {
+	fe.retryCalled = true
+	return fe.err
+}
+
 func (fe *fakeExecutor) Reset(p *install.Plan, nodes ...string) error {
 	return nil
 }
@@ -60,7 +72,7 @@ func (fe *fakeExecutor) RunUpgradePreFlightCheck(*install.Plan, install.Listable
 	return nil
 }
 
-func (fe *fakeExecutor) UpgradeNodes(install.Plan, []install.ListableNode, bool, int, bool) error {
+func (fe *fakeExecutor) UpgradeNodes(install.Plan, []install.ListableNode, bool, int, bool, install.UpgradeGate) error {
 	return nil
 }
 
@@ -84,6 +96,18 @@ func (fe *fakeExecutor) RunPlay(string, *install.Plan, bool, ...string) error {
 	return nil
 }
 
+func (fe *fakeExecutor) SnapshotEtcd(*install.Plan) (*install.EtcdSnapshot, error) {
+	return nil, nil
+}
+
+func (fe *fakeExecutor) BackupEtcd(*install.Plan) (*install.EtcdSnapshot, error) {
+	return nil, nil
+}
+
+func (fe *fakeExecutor) RestoreEtcd(*install.Plan, install.EtcdSnapshot) error {
+	return nil
+}
+
 func (fe *fakeExecutor) AddVolume(*install.Plan, install.StorageVolume) error {
 	return nil
 }
@@ -13,8 +13,10 @@ import (
 )
 
 type infoOpts struct {
-	planFilename string
-	outputFormat string
+	planFilename       string
+	planOverlays       []string
+	outputFormat       string
+	generatedAssetsDir string
 }
 
 // NewCmdInfo returns the info command
@@ -27,17 +29,19 @@ func NewCmdInfo(out io.Writer) *cobra.Command {
 
 This will be retrieved by connecting to each node via ssh`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(opts.generatedAssetsDir)
 			return list(out, opts)
 		},
 	}
-	cmd.Flags().StringVarP(&opts.planFilename, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
+	addPlanFilesFlag(cmd.Flags(), &opts.planFilename, &opts.planOverlays)
 	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	return cmd
 }
 
 func list(out io.Writer, opts *infoOpts) error {
 	// Check if plan file exists
-	planner := &install.FilePlanner{File: opts.planFilename}
+	planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
 	if !planner.PlanExists() {
 		return fmt.Errorf("plan does not exist")
 	}
@@ -53,6 +57,9 @@ func list(out io.Writer, opts *infoOpts) error {
 	}
 
 	// Validate SSH connections
+	if err = plan.ImportKnownHosts(); err != nil {
+		return err
+	}
 	if ok, errs := install.ValidatePlanSSHConnections(plan); !ok {
 		util.PrintValidationErrors(out, errs)
 		return fmt.Errorf("error getting info from cluster nodes")
@@ -8,6 +8,7 @@ import (
 
 type installOpts struct {
 	planFilename string
+	planOverlays []string
 }
 
 // NewCmdInstall creates a new install command
@@ -30,7 +31,7 @@ func NewCmdInstall(in io.Reader, out io.Writer) *cobra.Command {
 	cmd.AddCommand(NewCmdStep(out, opts))
 
 	// PersistentFlags
-	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addPlanFilesFlag(cmd.PersistentFlags(), &opts.planFilename, &opts.planOverlays)
 
 	return cmd
 }
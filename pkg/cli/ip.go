@@ -9,7 +9,9 @@ import (
 )
 
 type ipOpts struct {
-	planFilename string
+	planFilename       string
+	planOverlays       []string
+	generatedAssetsDir string
 }
 
 // NewCmdIP prints the cluster's IP
@@ -23,13 +25,15 @@ func NewCmdIP(out io.Writer) *cobra.Command {
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-			planner := &install.FilePlanner{File: opts.planFilename}
+			setKnownHostsFile(opts.generatedAssetsDir)
+			planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
 			return doIP(out, planner, opts)
 		},
 	}
 
 	// PersistentFlags
-	cmd.PersistentFlags().StringVarP(&opts.planFilename, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
+	addPlanFilesFlag(cmd.PersistentFlags(), &opts.planFilename, &opts.planOverlays)
+	cmd.PersistentFlags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 
 	return cmd
 }
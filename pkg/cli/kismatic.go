@@ -3,11 +3,13 @@ package cli
 import (
 	"io"
 
+	"github.com/apprenda/kismatic/pkg/ansible"
 	"github.com/spf13/cobra"
 )
 
 // NewKismaticCommand creates the kismatic command
 func NewKismaticCommand(version string, buildDate string, in io.Reader, out, stderr io.Writer) (*cobra.Command, error) {
+	var python string
 	cmd := &cobra.Command{
 		Use:   "kismatic",
 		Short: "kismatic is the main tool for managing your Kubernetes cluster",
@@ -16,14 +18,20 @@ more documentation is available at https://github.com/apprenda/kismatic`,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmd.Help()
 		},
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			ansible.SetPythonInterpreter(python)
+		},
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
 
+	cmd.PersistentFlags().StringVar(&python, "python", "", "the python interpreter that runs Ansible, or the directory of a virtualenv. Defaults to the $"+ansible.PythonEnvVar+" environment variable, the active virtualenv, or \"python\" or \"python3\" in the PATH")
+
 	cmd.AddCommand(NewCmdVersion(buildDate, out))
 	cmd.AddCommand(NewCmdInstall(in, out))
 	cmd.AddCommand(NewCmdReset(in, out))
 	cmd.AddCommand(NewCmdVolume(in, out))
+	cmd.AddCommand(NewCmdEtcd(in, out))
 	cmd.AddCommand(NewCmdIP(out))
 	cmd.AddCommand(NewCmdDashboard(in, out))
 	cmd.AddCommand(NewCmdSSH(out))
@@ -32,6 +40,8 @@ more documentation is available at https://github.com/apprenda/kismatic`,
 	cmd.AddCommand(NewCmdDiagnostic(out))
 	cmd.AddCommand(NewCmdCertificates(out))
 	cmd.AddCommand(NewCmdSeedRegistry(out, stderr))
+	cmd.AddCommand(NewCmdPlanFile(out))
+	cmd.AddCommand(NewCmdRuns(out))
 
 	return cmd, nil
 }
@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+type planDiffOpts struct {
+	planFile      string
+	planOverlays  []string
+	runsDirectory string
+	outputFormat  string
+}
+
+// NewCmdPlanDiff creates a new command for comparing the plan file with the last applied plan
+func NewCmdPlanDiff(out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
+	opts := &planDiffOpts{}
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "show the changes made to the plan file since it was last applied",
+		Long: `Show the changes made to the plan file since it was last applied.
+
+The plan file is compared with the plan used by the last successful run that
+applied the plan to the cluster, such as "kismatic apply" or "kismatic install add-node".
+The differences are grouped by their impact on the cluster: nodes added and removed,
+add-on changes, component option overrides and changes that affect the cluster certificates.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			opts.planFile = *planFile
+			opts.planOverlays = *planOverlays
+			return doPlanDiff(out, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	cmd.Flags().StringVar(&opts.runsDirectory, "runs-dir", install.DefaultRunsDirectory, "path to the directory where the runs are kept")
+	return cmd
+}
+
+func doPlanDiff(out io.Writer, opts *planDiffOpts) error {
+	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	planner := &install.FilePlanner{File: opts.planFile, Overlays: opts.planOverlays}
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: opts.planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	lastFile, err := install.LastAppliedPlanFile(opts.runsDirectory)
+	if err != nil {
+		return err
+	}
+	// the secrets behind the references of the last applied plan are not needed to compare it
+	lastPlanner := &install.FilePlanner{File: lastFile, KeepSecretRefs: true}
+	lastPlan, err := lastPlanner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading last applied plan file %q: %v", lastFile, err)
+	}
+	diff, err := install.DiffPlans(lastPlan, plan)
+	if err != nil {
+		return fmt.Errorf("error comparing plan files: %v", err)
+	}
+
+	if opts.outputFormat == "json" {
+		b, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error marshalling plan diff: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+
+	fmt.Fprintf(out, "Comparing %q with the last applied plan %q\n", opts.planFile, lastFile)
+	if diff.Empty() {
+		fmt.Fprintln(out, "No changes")
+		return nil
+	}
+	if len(diff.NodesAdded) > 0 {
+		printNodeDiffs(out, "Nodes Added", diff.NodesAdded)
+	}
+	if len(diff.NodesRemoved) > 0 {
+		printNodeDiffs(out, "Nodes Removed", diff.NodesRemoved)
+	}
+	sections := []struct {
+		title string
+		diffs []install.PlanFieldDiff
+	}{
+		{"Add-On Changes", diff.AddOns},
+		{"Component Option Overrides", diff.ComponentOptions},
+		{"Certificate Changes", diff.Certificates},
+		{"Other Changes", diff.Other},
+	}
+	for _, s := range sections {
+		if len(s.diffs) > 0 {
+			printFieldDiffs(out, s.title, s.diffs)
+		}
+	}
+	if len(diff.Certificates) > 0 {
+		util.PrintColor(out, util.Orange, "\nThe cluster certificates must be regenerated to apply these changes.\n")
+	}
+	return nil
+}
+
+func printNodeDiffs(out io.Writer, title string, nodes []install.PlanNodeDiff) {
+	util.PrintHeader(out, title, '-')
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "Group\tHost\tIP\n")
+	for _, n := range nodes {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", n.Group, n.Host, n.IP)
+	}
+	w.Flush()
+}
+
+func printFieldDiffs(out io.Writer, title string, diffs []install.PlanFieldDiff) {
+	util.PrintHeader(out, title, '-')
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "Field\tFrom\tTo\n")
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Field, printableFieldValue(d.From), printableFieldValue(d.To))
+	}
+	w.Flush()
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPlanFile creates a new command for managing the plan file
+func NewCmdPlanFile(out io.Writer) *cobra.Command {
+	var planFile string
+	var planOverlays []string
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "manage your plan file",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+		},
+	}
+	addPlanFilesFlag(cmd.PersistentFlags(), &planFile, &planOverlays)
+	cmd.AddCommand(NewCmdPlanMigrate(out, &planFile, &planOverlays))
+	cmd.AddCommand(NewCmdPlanDiff(out, &planFile, &planOverlays))
+	cmd.AddCommand(NewCmdPlanRender(out, &planFile, &planOverlays))
+	cmd.AddCommand(NewCmdPlanSchema(out))
+	return cmd
+}
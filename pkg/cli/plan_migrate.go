@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPlanMigrate creates a new command for migrating the plan file to the current version
+func NewCmdPlanMigrate(out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "rewrite the plan file using the current plan file version",
+		Long: `Rewrite the plan file using the current plan file version.
+
+Fields that were deprecated in previous versions are moved to their current
+location, and a report of every field that was changed is printed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			if len(*planOverlays) != 0 {
+				return fmt.Errorf("plan overlays cannot be migrated, only the base plan file can be rewritten")
+			}
+			planner := &install.FilePlanner{File: *planFile}
+			if !planner.PlanExists() {
+				return planFileNotFoundErr{filename: *planFile}
+			}
+			return doPlanMigrate(out, planner)
+		},
+	}
+	return cmd
+}
+
+func doPlanMigrate(out io.Writer, planner *install.FilePlanner) error {
+	changes, err := planner.Migrate()
+	if err != nil {
+		return fmt.Errorf("error migrating plan file: %v", err)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintf(out, "Plan file %q is already up to date\n", planner.File)
+		return nil
+	}
+	fmt.Fprintf(out, "Migrated plan file %q:\n", planner.File)
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprint(w, "Version\tField\tFrom\tTo\n")
+	for _, c := range changes {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Version, c.Field, printableFieldValue(c.From), printableFieldValue(c.To))
+	}
+	return w.Flush()
+}
+
+func printableFieldValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
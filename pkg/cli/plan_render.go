@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// NewCmdPlanRender creates a new command for printing the plan after merging the plan overlays
+func NewCmdPlanRender(out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "print the plan file with all plan overlays merged",
+		Long: `Print the plan file with all plan overlays merged.
+
+Plan overlays are given by repeating the --plan-file flag, and are merged
+on top of the first plan file in order:
+
+- Mappings, such as option_overrides and add-on blocks, are merged key by key,
+  and the values of the overlay win.
+- A key that is set to null in an overlay is removed from the merged plan.
+- Node lists are merged by host: an overlay node is merged into the node with
+  the same host, and other nodes are appended.
+- Any other list is replaced by the list of the overlay.
+
+The rendered plan includes the default values, and uses the current plan file version.
+Sensitive fields that are set with a secret reference are printed with the reference,
+instead of the secret value.`,
+		Example: `  # Print the plan of the production cluster, which is composed of a base plan
+  # shared by all environments and a production overlay
+  kismatic plan render -f base.yaml -f prod.yaml
+		`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			planner := &install.FilePlanner{File: *planFile, Overlays: *planOverlays}
+			if !planner.PlanExists() {
+				return planFileNotFoundErr{filename: *planFile}
+			}
+			return doPlanRender(out, planner)
+		},
+	}
+	return cmd
+}
+
+func doPlanRender(out io.Writer, planner install.Planner) error {
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file: %v", err)
+	}
+	b, err := yaml.Marshal(install.WithSecretRefs(plan))
+	if err != nil {
+		return fmt.Errorf("error marshalling plan: %v", err)
+	}
+	fmt.Fprint(out, string(b))
+	return nil
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdPlanSchema creates a new command for printing the JSON schema of the plan file
+func NewCmdPlanSchema(out io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "print the JSON schema of the plan file",
+		Long: `Print the JSON schema of the plan file.
+
+The schema can be used by editors and linters to validate plan files
+without running kismatic.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			_, err := fmt.Fprintln(out, install.PlanJSONSchema)
+			return err
+		},
+	}
+	return cmd
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -13,12 +14,15 @@ import (
 
 type resetOpts struct {
 	planFilename       string
+	planOverlays       []string
 	generatedAssetsDir string
 	verbose            bool
 	outputFormat       string
 	limit              []string
 	force              bool
 	removeAssets       bool
+	timeout            time.Duration
+	etcdSnapshot       etcdSnapshotOpts
 }
 
 // NewCmdReset resets nodes
@@ -27,10 +31,18 @@ func NewCmdReset(in io.Reader, out io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "reset",
 		Short: "reset any changes made to the hosts by 'apply'",
+		Long: `reset any changes made to the hosts by 'apply'
+
+A snapshot of etcd is taken before the nodes are reset, and saved in the etcd-snapshots
+directory of the generated assets directory.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(opts.generatedAssetsDir)
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
+			if err := validateEtcdSnapshotOpts(opts.etcdSnapshot); err != nil {
+				return err
+			}
 			if opts.force == false {
 				ans, err := util.PromptForString(in, out, "Are you sure you want to reset the cluster? All data will be lost", "N", []string{"N", "y"})
 				if err != nil {
@@ -40,7 +52,9 @@ func NewCmdReset(in io.Reader, out io.Writer) *cobra.Command {
 					os.Exit(0)
 				}
 			}
-			return doReset(out, opts)
+			return withPlanLock(opts.planFilename, func() error {
+				return doReset(out, opts)
+			})
 		},
 	}
 
@@ -50,14 +64,19 @@ func NewCmdReset(in io.Reader, out io.Writer) *cobra.Command {
 	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
 	cmd.Flags().BoolVar(&opts.force, "force", false, `do not prompt`)
 	cmd.Flags().BoolVar(&opts.removeAssets, "remove-assets", false, "remove generated-assets-dir")
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 0, "the maximum duration of the command, such as 90m. The running playbook is stopped, and the run is recorded as aborted, when it is exceeded. The command does not time out when 0")
 
-	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFilename)
+	addEtcdSnapshotFlags(cmd.Flags(), &opts.etcdSnapshot)
+	addPlanFilesFlag(cmd.PersistentFlags(), &opts.planFilename, &opts.planOverlays)
 
 	return cmd
 }
 
 func doReset(out io.Writer, opts *resetOpts) error {
-	planner := &install.FilePlanner{File: opts.planFilename}
+	if err := checkNoJSONOutput(opts.outputFormat); err != nil {
+		return err
+	}
+	planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: opts.planFilename}
 	}
@@ -65,15 +84,21 @@ func doReset(out io.Writer, opts *resetOpts) error {
 	if err != nil {
 		return fmt.Errorf("failed to read plan file: %v", err)
 	}
+	ctx, cancel := runContext(opts.timeout)
+	defer cancel()
 	executorOpts := install.ExecutorOptions{
 		GeneratedAssetsDirectory: opts.generatedAssetsDir,
 		OutputFormat:             opts.outputFormat,
 		Verbose:                  opts.verbose,
+		Context:                  ctx,
 	}
 	executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
 	if err != nil {
 		return err
 	}
+	if err := snapshotEtcd(out, executor, plan, opts.generatedAssetsDir, opts.etcdSnapshot, false); err != nil {
+		return err
+	}
 	if err := executor.Reset(plan, opts.limit...); err != nil {
 		return fmt.Errorf("error running reset: %v", err)
 	}
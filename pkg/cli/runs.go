@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdRuns returns the command for browsing the runs of kismatic
+func NewCmdRuns(out io.Writer) *cobra.Command {
+	var runsDirectory string
+	cmd := &cobra.Command{
+		Use:   "runs",
+		Short: "browse the history of the runs of kismatic against your cluster",
+		Long: `Browse the history of the runs of kismatic against your cluster.
+
+Every execution of kismatic, such as "kismatic install apply" or "kismatic upgrade",
+records its plan file, ansible log, events and summary in a run directory named
+<runs-dir>/<task>/<start time>. The ID of a run is the path of its directory in
+the runs directory, such as "apply/2018-06-01-10-00-00".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Usage()
+		},
+	}
+	cmd.PersistentFlags().StringVar(&runsDirectory, "runs-dir", install.DefaultRunsDirectory, "path to the directory where the runs are kept")
+	cmd.AddCommand(NewCmdRunsList(out, &runsDirectory))
+	cmd.AddCommand(NewCmdRunsShow(out, &runsDirectory))
+	cmd.AddCommand(NewCmdRunsReplay(out, &runsDirectory))
+	cmd.AddCommand(NewCmdRunsPrune(out, &runsDirectory))
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+type runsListOpts struct {
+	runsDirectory string
+	outputFormat  string
+}
+
+// NewCmdRunsList returns the command for listing the runs
+func NewCmdRunsList(out io.Writer, runsDirectory *string) *cobra.Command {
+	opts := &runsListOpts{}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the runs, most recent first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			opts.runsDirectory = *runsDirectory
+			return doRunsList(out, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	return cmd
+}
+
+func doRunsList(out io.Writer, opts *runsListOpts) error {
+	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	runs, err := install.ListRuns(opts.runsDirectory)
+	if err != nil {
+		return err
+	}
+	if opts.outputFormat == "json" {
+		if runs == nil {
+			runs = []install.RunSummary{}
+		}
+		b, err := json.MarshalIndent(runs, "", "    ")
+		if err != nil {
+			return fmt.Errorf("marshal error: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	if len(runs) == 0 {
+		fmt.Fprintf(out, "No runs were found in %q\n", opts.runsDirectory)
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "ID\tTASK\tSTART\tDURATION\tOUTCOME\tNODES")
+	for _, r := range runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Name, r.Start.Format("2006-01-02 15:04:05"), runDuration(r), r.Outcome, runNodes(r))
+	}
+	return w.Flush()
+}
+
+func runDuration(r install.RunSummary) string {
+	if r.Duration() == 0 {
+		return "-"
+	}
+	return (r.Duration() / time.Second * time.Second).String()
+}
+
+func runNodes(r install.RunSummary) string {
+	if len(r.Nodes) == 0 {
+		return "-"
+	}
+	return strings.Join(r.Nodes, ",")
+}
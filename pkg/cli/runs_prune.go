@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+type runsPruneOpts struct {
+	runsDirectory string
+	keep          int
+}
+
+// NewCmdRunsPrune returns the command for removing old runs
+func NewCmdRunsPrune(out io.Writer, runsDirectory *string) *cobra.Command {
+	opts := &runsPruneOpts{}
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "remove all but the most recent runs of each task",
+		Long: `Remove all but the most recent runs of each task.
+
+The run that holds the plan file that was last applied to the cluster is always
+kept, as it is used by "kismatic plan diff".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			opts.runsDirectory = *runsDirectory
+			return doRunsPrune(out, opts)
+		},
+	}
+	cmd.Flags().IntVar(&opts.keep, "keep", 10, "the number of runs to keep for each task")
+	return cmd
+}
+
+func doRunsPrune(out io.Writer, opts *runsPruneOpts) error {
+	removed, err := install.PruneRuns(opts.runsDirectory, opts.keep)
+	for _, id := range removed {
+		fmt.Fprintf(out, "Removed run %q\n", id)
+	}
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		fmt.Fprintln(out, "No runs were removed")
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+type runsReplayOpts struct {
+	runsDirectory string
+	outputFormat  string
+	verbose       bool
+}
+
+// NewCmdRunsReplay returns the command for rendering the output of a run again
+func NewCmdRunsReplay(out io.Writer, runsDirectory *string) *cobra.Command {
+	opts := &runsReplayOpts{}
+	cmd := &cobra.Command{
+		Use:   "replay RUN_ID",
+		Short: "render the output of a run again, from the ansible events that it recorded",
+		Long: `Render the output of a run again, from the ansible events that it recorded.
+
+The output can be rendered in a different format than the one the run used, such as
+"json" for a run that printed its "simple" output. The "raw" output is the ansible log
+of the run. Runs of older versions of kismatic did not record their events, and can only
+be replayed in the "raw" format.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			opts.runsDirectory = *runsDirectory
+			return install.ReplayRun(out, opts.runsDirectory, args[0], opts.outputFormat, opts.verbose)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"raw"|"json")`)
+	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "render the verbose output of the run")
+	return cmd
+}
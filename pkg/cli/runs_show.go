@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+)
+
+type runsShowOpts struct {
+	runsDirectory string
+	outputFormat  string
+}
+
+// NewCmdRunsShow returns the command for showing the details of a run
+func NewCmdRunsShow(out io.Writer, runsDirectory *string) *cobra.Command {
+	opts := &runsShowOpts{}
+	cmd := &cobra.Command{
+		Use:   "show RUN_ID",
+		Short: "show the details of a run, including the tasks that failed and the hosts they failed on",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 1 {
+				return cmd.Usage()
+			}
+			opts.runsDirectory = *runsDirectory
+			return doRunsShow(out, args[0], opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	return cmd
+}
+
+func doRunsShow(out io.Writer, id string, opts *runsShowOpts) error {
+	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
+	}
+	r, err := install.ReadRun(opts.runsDirectory, id)
+	if err != nil {
+		return err
+	}
+	if opts.outputFormat == "json" {
+		b, err := json.MarshalIndent(r, "", "    ")
+		if err != nil {
+			return fmt.Errorf("marshal error: %v", err)
+		}
+		fmt.Fprintln(out, string(b))
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", r.ID)
+	fmt.Fprintf(w, "Task:\t%s\n", r.Name)
+	if r.Playbook != "" {
+		fmt.Fprintf(w, "Playbook:\t%s\n", r.Playbook)
+	}
+	fmt.Fprintf(w, "Start:\t%s\n", r.Start.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Duration:\t%s\n", runDuration(*r))
+	fmt.Fprintf(w, "Outcome:\t%s\n", r.Outcome)
+	fmt.Fprintf(w, "Nodes:\t%s\n", runNodes(*r))
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	if len(r.Failures) == 0 {
+		return nil
+	}
+	fmt.Fprintln(out, "\nFailures:")
+	for _, f := range r.Failures {
+		status := "failed"
+		if f.Unreachable {
+			status = "unreachable"
+		}
+		fmt.Fprintf(out, "- %s %s\n    Play: %s\n    Task: %s\n", f.Host, status, f.Play, f.Task)
+		if f.Message != "" {
+			fmt.Fprintf(out, "    Message: %s\n", f.Message)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunsListAndShow(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-runs")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	run := filepath.Join(tmp, "apply", "2018-06-01-10-00-00")
+	if err = os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	summary := `name: apply
+playbook: kubernetes.yaml
+start: 2018-06-01T10:00:00Z
+end: 2018-06-01T10:12:30Z
+outcome: failure
+nodes:
+- etcd01
+- master01
+failures:
+- play: Install etcd
+  task: start etcd
+  host: etcd01
+  message: etcd did not start
+`
+	if err = ioutil.WriteFile(filepath.Join(run, "summary.yaml"), []byte(summary), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if err = doRunsList(out, &runsListOpts{runsDirectory: tmp, outputFormat: "simple"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []string{"apply/2018-06-01-10-00-00", "12m30s", "failure", "etcd01,master01"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected the list of runs to contain %q, but got:\n%s", s, out.String())
+		}
+	}
+
+	out.Reset()
+	if err = doRunsShow(out, "apply/2018-06-01-10-00-00", &runsShowOpts{runsDirectory: tmp, outputFormat: "simple"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, s := range []string{"etcd01 failed", "Task: start etcd", "Message: etcd did not start"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("expected the run details to contain %q, but got:\n%s", s, out.String())
+		}
+	}
+}
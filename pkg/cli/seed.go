@@ -40,6 +40,7 @@ type seedRegistryOptions struct {
 	listOnly            bool
 	verbose             bool
 	planFile            string
+	planOverlays        []string
 	imagesManifestsFile string
 	registryServer      string
 }
@@ -79,7 +80,7 @@ func NewCmdSeedRegistry(stdout, stderr io.Writer) *cobra.Command {
 	cmd.Flags().BoolVar(&options.verbose, "verbose", false, "enable verbose logging")
 	cmd.Flags().StringVar(&options.registryServer, "server", "", "set to the location of the registry server, without the protocol (e.g. localhost:5000)")
 	cmd.Flags().StringVar(&options.imagesManifestsFile, "images-manifest-file", "", "path to the container images manifest file")
-	addPlanFileFlag(cmd.Flags(), &options.planFile)
+	addPlanFilesFlag(cmd.Flags(), &options.planFile, &options.planOverlays)
 	return cmd
 }
 
@@ -90,7 +91,7 @@ func doListImages(stdout io.Writer, options seedRegistryOptions) error {
 	versions := install.VersionOverrides()
 
 	// try to read the plan file to get component versions
-	planner := install.FilePlanner{File: options.planFile}
+	planner := install.FilePlanner{File: options.planFile, Overlays: options.planOverlays}
 	if planner.PlanExists() {
 		plan, err := planner.Read()
 		if err != nil {
@@ -130,7 +131,7 @@ func doSeedRegistry(stdout, stderr io.Writer, options seedRegistryOptions) error
 	server := options.registryServer
 	if server == "" {
 		// we need to get the server from the plan file
-		planner := install.FilePlanner{File: options.planFile}
+		planner := install.FilePlanner{File: options.planFile, Overlays: options.planOverlays}
 		if !planner.PlanExists() {
 			util.PrettyPrintErr(stdout, "Reading installation plan file %q", options.planFile)
 			fmt.Fprintln(stdout, `Run "kismatic install plan" to generate it or use the "--server" option`)
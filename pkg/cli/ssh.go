@@ -13,10 +13,12 @@ import (
 )
 
 type sshOpts struct {
-	planFilename string
-	host         string
-	pty          bool
-	arguments    []string
+	planFilename       string
+	planOverlays       []string
+	generatedAssetsDir string
+	host               string
+	pty                bool
+	arguments          []string
 }
 
 // NewCmdSSH returns an ssh shell
@@ -30,7 +32,14 @@ func NewCmdSSH(out io.Writer) *cobra.Command {
 
 HOST must be one of the following:
 - A hostname defined in the plan filepath
-- An alias: master, etcd, worker, ingress or storage. This will ssh into the first defined node of that type.`,
+- An alias: master, etcd, worker, ingress or storage. This will ssh into the first defined node of that type.
+
+The host key of the node is verified against the known_hosts file in the generated
+assets directory. The key of a node that was never contacted before is trusted and
+added to the file.
+
+The connection is made with a native SSH client. Set the KISMATIC_SSH_CLIENT
+environment variable to "external" to use the ssh binary found in the PATH instead.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) < 1 {
 				return cmd.Usage()
@@ -41,8 +50,9 @@ HOST must be one of the following:
 			}
 
 			opts.host = args[0]
+			setKnownHostsFile(opts.generatedAssetsDir)
 
-			planner := &install.FilePlanner{File: opts.planFilename}
+			planner := &install.FilePlanner{File: opts.planFilename, Overlays: opts.planOverlays}
 			// Check if plan file exists
 			if !planner.PlanExists() {
 				return planFileNotFoundErr{filename: opts.planFilename}
@@ -57,9 +67,13 @@ HOST must be one of the following:
 		},
 	}
 
-	cmd.Flags().StringVarP(&opts.planFilename, "plan-file", "f", "kismatic-cluster.yaml", "path to the installation plan file")
+	addPlanFilesFlag(cmd.PersistentFlags(), &opts.planFilename, &opts.planOverlays)
+	cmd.PersistentFlags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVarP(&opts.pty, "pty", "t", false, "force PTY \"-t\" flag on the SSH connection")
 
+	// Subcommands
+	cmd.AddCommand(NewCmdSSHKnownHosts(out, opts))
+
 	return cmd
 }
 
@@ -76,16 +90,20 @@ func doSSH(out io.Writer, planner install.Planner, opts *sshOpts) error {
 	}
 
 	// validate SSH access to node
+	if err = plan.ImportKnownHosts(); err != nil {
+		return err
+	}
 	ok, errs := install.ValidateSSHConnection(con, "")
 	if !ok {
 		util.PrintValidationErrors(out, errs)
 		return fmt.Errorf("cannot validate SSH connection to node %q", opts.host)
 	}
 
-	client, err := ssh.NewClient(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.User, con.SSHConfig.Key)
+	client, err := ssh.NewClient(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.User, con.SSHConfig.Key, con.SSHConfig.SSHBastion())
 	if err != nil {
 		return fmt.Errorf("error creating SSH client: %v", err)
 	}
+	defer client.Close()
 
 	if err = client.Shell(opts.pty, opts.arguments...); err != nil {
 		return fmt.Errorf("error running command: %v", err)
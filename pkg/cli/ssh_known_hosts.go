@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/apprenda/kismatic/pkg/ssh"
+	"github.com/apprenda/kismatic/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdSSHKnownHosts returns the command for managing the known_hosts file
+func NewCmdSSHKnownHosts(out io.Writer, opts *sshOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "known-hosts",
+		Short: "manage the host keys of the cluster nodes",
+		Long: `Manage the host keys of the cluster nodes.
+
+The host keys are recorded in the known_hosts file in the generated assets directory
+the first time a node is contacted, and are verified on every subsequent connection.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cmd.Help()
+		},
+	}
+	cmd.AddCommand(NewCmdSSHKnownHostsList(out, opts))
+	cmd.AddCommand(NewCmdSSHKnownHostsReset(out, opts))
+	return cmd
+}
+
+// NewCmdSSHKnownHostsList returns the command for listing the known host keys
+func NewCmdSSHKnownHostsList(out io.Writer, opts *sshOpts) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the host keys of the cluster nodes",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			setKnownHostsFile(opts.generatedAssetsDir)
+			return doSSHKnownHostsList(out)
+		},
+	}
+	return cmd
+}
+
+func doSSHKnownHostsList(out io.Writer) error {
+	hosts, err := ssh.ListKnownHosts()
+	if err != nil {
+		return err
+	}
+	if len(hosts) == 0 {
+		fmt.Fprintln(out, "No host keys have been recorded")
+		return nil
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tKEY TYPE\tFINGERPRINT")
+	for _, h := range hosts {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", strings.Join(h.Hosts, ","), h.KeyType, h.Fingerprint)
+	}
+	return w.Flush()
+}
+
+// NewCmdSSHKnownHostsReset returns the command for removing known host keys
+func NewCmdSSHKnownHostsReset(out io.Writer, opts *sshOpts) *cobra.Command {
+	var all bool
+	cmd := &cobra.Command{
+		Use:   "reset HOST...",
+		Short: "remove the host keys of cluster nodes",
+		Long: `Remove the recorded host keys of cluster nodes, so that their current host keys
+are trusted on the next connection. This is required after a node is reinstalled.
+
+HOST can be a hostname defined in the plan file, or an address of the form
+"host", "host:port" or "[host]:port".`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && !all {
+				return fmt.Errorf("at least one host must be provided, or use --all to remove all host keys")
+			}
+			if len(args) != 0 && all {
+				return fmt.Errorf("hosts cannot be provided when using --all")
+			}
+			setKnownHostsFile(opts.generatedAssetsDir)
+			return doSSHKnownHostsReset(out, opts.planFilename, opts.planOverlays, args)
+		},
+	}
+	cmd.Flags().BoolVar(&all, "all", false, "remove the host keys of all nodes")
+	return cmd
+}
+
+func doSSHKnownHostsReset(out io.Writer, planFile string, planOverlays []string, hosts []string) error {
+	addresses := hosts
+	// resolve the hostnames of the plan to the addresses used for connecting to the nodes
+	planner := &install.FilePlanner{File: planFile, Overlays: planOverlays}
+	if len(hosts) != 0 && planner.PlanExists() {
+		plan, err := planner.Read()
+		if err != nil {
+			return fmt.Errorf("error reading plan file: %v", err)
+		}
+		addresses = []string{}
+		for _, h := range hosts {
+			addresses = append(addresses, h)
+			con, err := plan.GetSSHConnection(h)
+			if err != nil {
+				continue
+			}
+			addresses = append(addresses, net.JoinHostPort(con.Node.IP, strconv.Itoa(con.SSHConfig.Port)))
+		}
+	}
+	n, err := ssh.RemoveKnownHosts(addresses...)
+	if err != nil {
+		return err
+	}
+	util.PrettyPrintOk(out, "Removed %d host key(s)", n)
+	return nil
+}
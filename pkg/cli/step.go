@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/install"
 	"github.com/apprenda/kismatic/pkg/util"
@@ -23,6 +24,7 @@ type stepCmd struct {
 	verbose            bool
 	outputFormat       string
 	limit              []string
+	timeout            time.Duration
 }
 
 // NewCmdStep returns the step command
@@ -35,13 +37,20 @@ func NewCmdStep(out io.Writer, opts *installOpts) *cobra.Command {
 		Use:   "step PLAY_NAME",
 		Short: "run a specific task of the installation workflow (debug feature)",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(stepCmd.generatedAssetsDir)
 			if len(args) != 1 {
 				return cmd.Usage()
 			}
+			if err := checkNoJSONOutput(stepCmd.outputFormat); err != nil {
+				return err
+			}
+			ctx, cancel := runContext(stepCmd.timeout)
+			defer cancel()
 			execOpts := install.ExecutorOptions{
 				GeneratedAssetsDirectory: stepCmd.generatedAssetsDir,
 				OutputFormat:             stepCmd.outputFormat,
 				Verbose:                  stepCmd.verbose,
+				Context:                  ctx,
 			}
 			executor, err := install.NewExecutor(out, os.Stderr, execOpts)
 			if err != nil {
@@ -49,9 +58,9 @@ func NewCmdStep(out io.Writer, opts *installOpts) *cobra.Command {
 			}
 			stepCmd.task = args[0]
 			stepCmd.planFile = opts.planFilename
-			stepCmd.planner = &install.FilePlanner{File: stepCmd.planFile}
+			stepCmd.planner = &install.FilePlanner{File: stepCmd.planFile, Overlays: opts.planOverlays}
 			stepCmd.executor = executor
-			return stepCmd.run()
+			return withPlanLock(stepCmd.planFile, stepCmd.run)
 		},
 	}
 	cmd.Flags().StringSliceVar(&stepCmd.limit, "limit", []string{}, "comma-separated list of hostnames to limit the execution to a subset of nodes")
@@ -59,6 +68,7 @@ func NewCmdStep(out io.Writer, opts *installOpts) *cobra.Command {
 	cmd.Flags().BoolVar(&stepCmd.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.Flags().BoolVar(&stepCmd.verbose, "verbose", false, "enable verbose logging from the installation")
 	cmd.Flags().StringVarP(&stepCmd.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.Flags().DurationVar(&stepCmd.timeout, "timeout", 0, "the maximum duration of the command, such as 90m. The running playbook is stopped, and the run is recorded as aborted, when it is exceeded. The command does not time out when 0")
 	return cmd
 }
 
@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/apprenda/kismatic/pkg/data"
 	"github.com/apprenda/kismatic/pkg/install"
@@ -21,10 +24,17 @@ type upgradeOpts struct {
 	ignoreSafetyChecks bool
 	online             bool
 	planFile           string
+	planOverlays       []string
 	restartServices    bool
 	partialAllowed     bool
 	maxParallelWorkers int
 	dryRun             bool
+	timeout            time.Duration
+	canary             bool
+	healthGateCommand  string
+	healthGateTimeout  time.Duration
+	healthGateFailure  string
+	etcdSnapshot       etcdSnapshotOpts
 }
 
 // NewCmdUpgrade returns the upgrade command
@@ -44,6 +54,17 @@ Nodes in the cluster are upgraded in the following order:
 1. Etcd nodes
 2. Master nodes
 3. Worker nodes (regardless of specialization)
+
+With --canary, a single worker node is upgraded first. Before each later batch of worker nodes,
+the upgraded worker nodes must pass the health gates: the node is Ready, all the pods on the
+node are running and Ready, and the --health-gate-command, if any, succeeds on the node over
+SSH. When a gate fails, the upgrade is aborted, or paused until the operator decides how to
+proceed, as set with --on-health-gate-failure.
+
+A snapshot of etcd is taken before the cluster is upgraded, and saved in the etcd-snapshots
+directory of the generated assets directory.
+
+Use "kismatic upgrade plan" to print the computed plan of the upgrade, without upgrading the cluster.
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cmd.Help()
@@ -52,16 +73,23 @@ Nodes in the cluster are upgraded in the following order:
 
 	cmd.PersistentFlags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.PersistentFlags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.PersistentFlags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\")")
+	cmd.PersistentFlags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\"). With \"json\", the ansible events are written to stdout as JSON lines, and all other messages to stderr")
 	cmd.PersistentFlags().BoolVar(&opts.skipPreflight, "skip-preflight", false, "skip upgrade pre-flight checks")
 	cmd.PersistentFlags().BoolVar(&opts.restartServices, "restart-services", false, "force restart cluster services (Use with care)")
 	cmd.PersistentFlags().BoolVar(&opts.partialAllowed, "partial-ok", false, "allow the upgrade of ready nodes, and skip nodes that have been deemed unready for upgrade")
-	cmd.PersistentFlags().BoolVar(&opts.dryRun, "dry-run", false, "simulate the upgrade, but don't actually upgrade the cluster")
-	addPlanFileFlag(cmd.PersistentFlags(), &opts.planFile)
+	cmd.PersistentFlags().BoolVar(&opts.dryRun, "dry-run", false, "simulate the upgrade, but don't actually upgrade the cluster. The playbooks that would be run are printed, and their inventory and cluster catalog are written to the runs/dry-run directory")
+	cmd.PersistentFlags().DurationVar(&opts.timeout, "timeout", 0, "the maximum duration of the command, such as 90m. The running playbook is stopped, and the run is recorded as aborted, when it is exceeded. The command does not time out when 0")
+	cmd.PersistentFlags().BoolVar(&opts.canary, "canary", false, "upgrade a single worker node first, and check the health gates on the upgraded worker nodes before upgrading the next batch of worker nodes")
+	cmd.PersistentFlags().StringVar(&opts.healthGateCommand, "health-gate-command", "", "with --canary, a command that is run over SSH on each upgraded worker node, and must succeed for the upgrade to continue")
+	cmd.PersistentFlags().DurationVar(&opts.healthGateTimeout, "health-gate-timeout", 5*time.Minute, "with --canary, the maximum duration to wait for the health gates to pass on the upgraded worker nodes")
+	cmd.PersistentFlags().StringVar(&opts.healthGateFailure, "on-health-gate-failure", install.HealthGateFailureAbort, "with --canary, the action taken when the health gates fail (options \"abort\"|\"pause\"). With \"pause\", you are asked whether to check the gates again, continue, or stop the upgrade")
+	addEtcdSnapshotFlags(cmd.PersistentFlags(), &opts.etcdSnapshot)
+	addPlanFilesFlag(cmd.PersistentFlags(), &opts.planFile, &opts.planOverlays)
 
 	// Subcommands
 	cmd.AddCommand(NewCmdUpgradeOffline(in, out, &opts))
 	cmd.AddCommand(NewCmdUpgradeOnline(in, out, &opts))
+	cmd.AddCommand(NewCmdUpgradePlan(out, &opts))
 	return cmd
 }
 
@@ -81,7 +109,9 @@ availability. For this reason, this method should not be used for clusters that
 production workloads.
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doUpgrade(in, out, opts)
+			return withPlanLock(opts.planFile, func() error {
+				return doUpgrade(in, out, opts)
+			})
 		},
 	}
 	cmd.Flags().IntVar(&opts.maxParallelWorkers, "max-parallel-workers", 1, "the maximum number of worker nodes to be upgraded in parallel")
@@ -104,33 +134,46 @@ before any changes are applied.
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			opts.online = true
-			return doUpgrade(in, out, opts)
+			return withPlanLock(opts.planFile, func() error {
+				return doUpgrade(in, out, opts)
+			})
 		},
 	}
 	cmd.PersistentFlags().BoolVar(&opts.ignoreSafetyChecks, "ignore-safety-checks", false, "ignore upgrade safety checks and continue with the upgrade")
 	return &cmd
 }
 
-func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
+func doUpgrade(in io.Reader, stdout io.Writer, opts *upgradeOpts) error {
+	out := messageWriter(stdout, opts.outputFormat)
+	setKnownHostsFile(opts.generatedAssetsDir)
 	if opts.maxParallelWorkers < 1 {
 		return fmt.Errorf("max-parallel-workers must be greater or equal to 1, got: %d", opts.maxParallelWorkers)
 	}
+	if err := validateHealthGateOpts(*opts); err != nil {
+		return err
+	}
+	if err := validateEtcdSnapshotOpts(opts.etcdSnapshot); err != nil {
+		return err
+	}
 
 	planFile := opts.planFile
-	planner := install.FilePlanner{File: planFile}
+	planner := install.FilePlanner{File: planFile, Overlays: opts.planOverlays}
+	ctx, cancel := runContext(opts.timeout)
+	defer cancel()
 	executorOpts := install.ExecutorOptions{
 		GeneratedAssetsDirectory: opts.generatedAssetsDir,
 		OutputFormat:             opts.outputFormat,
 		Verbose:                  opts.verbose,
 		DryRun:                   opts.dryRun,
+		Context:                  ctx,
 	}
-	executor, err := install.NewExecutor(out, os.Stderr, executorOpts)
+	executor, err := install.NewExecutor(stdout, os.Stderr, executorOpts)
 	if err != nil {
 		return err
 	}
 	preflightExecOpts := executorOpts
 	preflightExecOpts.DryRun = false // We always want to run preflight, even if doing a dry-run
-	preflightExec, err := install.NewPreFlightExecutor(out, os.Stderr, preflightExecOpts)
+	preflightExec, err := install.NewPreFlightExecutor(stdout, os.Stderr, preflightExecOpts)
 	if err != nil {
 		return err
 	}
@@ -181,17 +224,7 @@ func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
 	}
 
 	// Figure out which nodes to upgrade
-	var toUpgrade []install.ListableNode
-	var toSkip []install.ListableNode
-	for _, n := range cv.Nodes {
-		// run if KET version or component versions are different
-		// don't check component versions if the node has only "etcd" role
-		if install.IsOlderVersion(n.Version) || (!(len(n.Roles) == 1 && n.Roles[0] == "etcd") && plan.Cluster.Version != n.ComponentVersions.Kubernetes) {
-			toUpgrade = append(toUpgrade, n)
-		} else {
-			toSkip = append(toSkip, n)
-		}
-	}
+	toUpgrade, toSkip := install.NodesToUpgrade(*plan, cv.Nodes)
 
 	// Print the nodes that will be skipped
 	if len(toSkip) > 0 {
@@ -206,7 +239,7 @@ func doUpgrade(in io.Reader, out io.Writer, opts *upgradeOpts) error {
 	if len(toUpgrade) == 0 {
 		fmt.Fprintln(out, "All nodes are at the target version. Skipping node upgrades.")
 	} else {
-		if err = upgradeNodes(in, out, *plan, *opts, toUpgrade, executor, preflightExec); err != nil {
+		if err = upgradeNodes(ctx, in, out, *plan, *opts, toUpgrade, executor, preflightExec); err != nil {
 			return err
 		}
 	}
@@ -224,6 +257,14 @@ without the "--partial-ok" flag to perform a full upgrade.
 		return nil
 	}
 
+	// Take a snapshot of etcd before upgrading the cluster services, unless it was taken
+	// before upgrading the nodes
+	if len(toUpgrade) == 0 {
+		if err := snapshotEtcd(out, executor, plan, opts.generatedAssetsDir, opts.etcdSnapshot, opts.dryRun); err != nil {
+			return err
+		}
+	}
+
 	// Upgrade the cluster services
 	util.PrintHeader(out, "Upgrade: Cluster Services", '=')
 	if err := executor.UpgradeClusterServices(*plan); err != nil {
@@ -240,11 +281,15 @@ without the "--partial-ok" flag to perform a full upgrade.
 		fmt.Fprintln(out)
 		util.PrintColor(out, util.Green, "The cluster was upgraded successfully!\n")
 		fmt.Fprintln(out)
+	} else {
+		fmt.Fprintln(out)
+		util.PrintColor(out, util.Green, "Dry-run complete. The playbooks that would be run are listed in the \"steps\" file of the latest directory in %q.\n", filepath.Join(install.DefaultRunsDirectory, "dry-run"))
+		fmt.Fprintln(out)
 	}
 	return nil
 }
 
-func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOpts, nodesNeedUpgrade []install.ListableNode, executor install.Executor, preflightExec install.PreFlightExecutor) error {
+func upgradeNodes(ctx context.Context, in io.Reader, out io.Writer, plan install.Plan, opts upgradeOpts, nodesNeedUpgrade []install.ListableNode, executor install.Executor, preflightExec install.PreFlightExecutor) error {
 	// Run safety checks if doing an online upgrade
 	unsafeNodes := []install.ListableNode{}
 	if opts.online {
@@ -254,6 +299,7 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 		if err != nil {
 			return fmt.Errorf("error getting SSH client: %v", err)
 		}
+		defer client.Close()
 		kubeClient := data.RemoteKubectl{SSHClient: client}
 		for _, node := range nodesNeedUpgrade {
 			util.PrettyPrint(out, "%s %v", node.Node.Host, node.Roles)
@@ -266,7 +312,7 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 				}
 				fmt.Fprintln(out)
 				for _, err := range errs {
-					fmt.Println("-", err.Error())
+					fmt.Fprintln(out, "-", err.Error())
 				}
 				unsafeNodes = append(unsafeNodes, node)
 			} else {
@@ -365,9 +411,97 @@ func upgradeNodes(in io.Reader, out io.Writer, plan install.Plan, opts upgradeOp
 		}
 	}
 
+	// Check the health gates after each batch of worker nodes in canary mode
+	var gate install.UpgradeGate
+	if opts.canary {
+		gate = healthGate(ctx, in, out, plan, opts)
+	}
+
+	// Take a snapshot of etcd before changing the cluster
+	if err := snapshotEtcd(out, executor, &plan, opts.generatedAssetsDir, opts.etcdSnapshot, opts.dryRun); err != nil {
+		return err
+	}
+
 	// Run the upgrade on the nodes that need it
-	if err := executor.UpgradeNodes(plan, toUpgrade, opts.online, opts.maxParallelWorkers, opts.restartServices); err != nil {
+	if err := executor.UpgradeNodes(plan, toUpgrade, opts.online, opts.maxParallelWorkers, opts.restartServices, gate); err != nil {
 		return fmt.Errorf("Failed to upgrade nodes: %v", err)
 	}
 	return nil
 }
+
+func validateHealthGateOpts(opts upgradeOpts) error {
+	if opts.healthGateFailure != install.HealthGateFailureAbort && opts.healthGateFailure != install.HealthGateFailurePause {
+		return fmt.Errorf("on-health-gate-failure must be %q or %q, got: %q", install.HealthGateFailureAbort, install.HealthGateFailurePause, opts.healthGateFailure)
+	}
+	if opts.healthGateTimeout <= 0 {
+		return fmt.Errorf("health-gate-timeout must be greater than 0, got: %v", opts.healthGateTimeout)
+	}
+	if !opts.canary && opts.healthGateCommand != "" {
+		return errors.New("health-gate-command can only be used with --canary")
+	}
+	return nil
+}
+
+// healthGate returns the gate that waits for the upgraded worker nodes to pass the health gates,
+// and aborts or pauses the upgrade when they fail
+func healthGate(ctx context.Context, in io.Reader, out io.Writer, plan install.Plan, opts upgradeOpts) install.UpgradeGate {
+	gates := install.UpgradeHealthGates{
+		Command:  opts.healthGateCommand,
+		Timeout:  opts.healthGateTimeout,
+		Interval: 10 * time.Second,
+	}
+	runCommand := func(node install.Node, command string) error {
+		client, err := plan.GetSSHClient(node.Host)
+		if err != nil {
+			return fmt.Errorf("error getting SSH client: %v", err)
+		}
+		defer client.Close()
+		if output, err := client.Output(false, command); err != nil {
+			return fmt.Errorf("%v: %s", err, strings.TrimSpace(output))
+		}
+		return nil
+	}
+	return func(upgraded []install.ListableNode) error {
+		var hosts []string
+		for _, n := range upgraded {
+			hosts = append(hosts, n.Node.Host)
+		}
+		for {
+			util.PrintHeader(out, fmt.Sprintf("Health Gates: %s", strings.Join(hosts, ", ")), '=')
+			// Use the first master node for running kubectl
+			client, err := plan.GetSSHClient(plan.Master.Nodes[0].Host)
+			if err != nil {
+				return fmt.Errorf("error getting SSH client: %v", err)
+			}
+			kubeClient := data.RemoteKubectl{SSHClient: client}
+			errs := install.WaitForUpgradeHealth(ctx, upgraded, gates, kubeClient, runCommand)
+			client.Close()
+			if len(errs) == 0 {
+				util.PrettyPrintOk(out, "The upgraded nodes passed the health gates")
+				return nil
+			}
+			util.PrettyPrintErr(out, "The upgraded nodes failed the health gates")
+			for _, err := range errs {
+				fmt.Fprintln(out, "-", err.Error())
+			}
+			gateErr := errors.New("the health gates failed on the upgraded worker nodes")
+			if opts.healthGateFailure != install.HealthGateFailurePause || ctx.Err() != nil {
+				return gateErr
+			}
+			fmt.Fprintln(out)
+			ans, err := util.PromptForString(in, out, "Health gates failed. Check the gates again (r), continue the upgrade anyway (y), or stop the upgrade (N)?", "N", []string{"N", "y", "r"})
+			if err != nil {
+				return fmt.Errorf("error getting user response: %v", err)
+			}
+			switch strings.ToLower(ans) {
+			case "y":
+				util.PrettyPrintWarn(out, "\nIgnoring the health gates and continuing with the upgrade")
+				return nil
+			case "r":
+				continue
+			default:
+				return gateErr
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/apprenda/kismatic/pkg/data"
+	"github.com/apprenda/kismatic/pkg/install"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type upgradePlanOpts struct {
+	outputFormat       string
+	offline            bool
+	ignoreSafetyChecks bool
+	maxParallelWorkers int
+}
+
+// NewCmdUpgradePlan returns the command for printing the computed plan of an upgrade
+func NewCmdUpgradePlan(out io.Writer, opts *upgradeOpts) *cobra.Command {
+	planOpts := &upgradePlanOpts{}
+	cmd := &cobra.Command{
+		Use:   "plan",
+		Short: "Print the computed plan of an upgrade of your Kubernetes cluster, without upgrading it",
+		Long: `Print the computed plan of an upgrade of your Kubernetes cluster, without upgrading it.
+
+The plan lists the nodes that are out of date, and the phases and batches in which they
+are upgraded: etcd nodes first, then master nodes, one node at a time, and then the rest of
+the nodes, in batches of up to --max-parallel-workers nodes. With --canary, the first batch
+of the rest of the nodes is a single node. The plan of an online upgrade
+includes the safety checks that failed on each node, and whether they block the upgrade.
+The cluster is only read, and the upgrade pre-flight checks are not run.
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("Unexpected args: %v", args)
+			}
+			return doUpgradePlan(out, opts, planOpts)
+		},
+	}
+	cmd.Flags().StringVarP(&planOpts.outputFormat, "output", "o", "yaml", `output format (options "yaml"|"json")`)
+	cmd.Flags().BoolVar(&planOpts.offline, "offline", false, "compute the plan of an offline upgrade, which does not run the safety checks")
+	cmd.Flags().BoolVar(&planOpts.ignoreSafetyChecks, "ignore-safety-checks", false, "compute the plan of an upgrade that ignores the safety checks")
+	cmd.Flags().IntVar(&planOpts.maxParallelWorkers, "max-parallel-workers", 1, "the maximum number of worker nodes to be upgraded in parallel")
+	return cmd
+}
+
+func doUpgradePlan(out io.Writer, opts *upgradeOpts, planOpts *upgradePlanOpts) error {
+	if planOpts.outputFormat != "yaml" && planOpts.outputFormat != "json" {
+		return fmt.Errorf("output format %q is not supported", planOpts.outputFormat)
+	}
+	if planOpts.maxParallelWorkers < 1 {
+		return fmt.Errorf("max-parallel-workers must be greater or equal to 1, got: %d", planOpts.maxParallelWorkers)
+	}
+	setKnownHostsFile(opts.generatedAssetsDir)
+	planner := install.FilePlanner{File: opts.planFile, Overlays: opts.planOverlays}
+	if !planner.PlanExists() {
+		return planFileNotFoundErr{filename: opts.planFile}
+	}
+	plan, err := planner.Read()
+	if err != nil {
+		return fmt.Errorf("error reading plan file %q: %v", opts.planFile, err)
+	}
+	// the progress is written to stderr, so that stdout only carries the upgrade plan
+	if err = validatePlan(os.Stderr, plan); err != nil {
+		return err
+	}
+	if err = validateSSHConnectivity(os.Stderr, plan); err != nil {
+		return err
+	}
+	cv, err := install.ListVersions(plan)
+	if err != nil {
+		return fmt.Errorf("error listing cluster versions: %v", err)
+	}
+
+	var kubeClient data.RemoteKubectl
+	if !planOpts.offline {
+		// Use the first master node for running kubectl
+		client, err := plan.GetSSHClient(plan.Master.Nodes[0].Host)
+		if err != nil {
+			return fmt.Errorf("error getting SSH client: %v", err)
+		}
+		defer client.Close()
+		kubeClient = data.RemoteKubectl{SSHClient: client}
+	}
+	up := install.ComputeUpgradePlan(*plan, cv.Nodes, install.UpgradePlanOptions{
+		Online:             !planOpts.offline,
+		PartialAllowed:     opts.partialAllowed,
+		IgnoreSafetyChecks: planOpts.ignoreSafetyChecks,
+		MaxParallelWorkers: planOpts.maxParallelWorkers,
+		Canary:             opts.canary,
+	}, kubeClient)
+	return printUpgradePlan(out, up, planOpts.outputFormat)
+}
+
+func printUpgradePlan(out io.Writer, up install.UpgradePlan, outputFormat string) error {
+	var b []byte
+	var err error
+	if outputFormat == "json" {
+		b, err = json.MarshalIndent(up, "", "    ")
+		b = append(b, '\n')
+	} else {
+		b, err = yaml.Marshal(up)
+	}
+	if err != nil {
+		return fmt.Errorf("error marshalling upgrade plan: %v", err)
+	}
+	_, err = out.Write(b)
+	return err
+}
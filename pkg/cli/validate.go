@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -19,6 +20,8 @@ type validateOpts struct {
 	outputFormat       string
 	skipPreFlight      bool
 	limit              []string
+	// ctx stops the pre-flight checks when done, if set
+	ctx context.Context
 }
 
 // NewCmdValidate creates a new install validate command
@@ -28,10 +31,11 @@ func NewCmdValidate(out io.Writer, installOpts *installOpts) *cobra.Command {
 		Use:   "validate",
 		Short: "validate your plan file",
 		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(opts.generatedAssetsDir)
 			if len(args) != 0 {
 				return fmt.Errorf("Unexpected args: %v", args)
 			}
-			planner := &install.FilePlanner{File: installOpts.planFilename}
+			planner := &install.FilePlanner{File: installOpts.planFilename, Overlays: installOpts.planOverlays}
 			opts.planFile = installOpts.planFilename
 			return doValidate(out, planner, opts)
 		},
@@ -39,12 +43,13 @@ func NewCmdValidate(out io.Writer, installOpts *installOpts) *cobra.Command {
 	cmd.Flags().StringSliceVar(&opts.limit, "limit", []string{}, "comma-separated list of hostnames to limit the execution to a subset of nodes")
 	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging from the installation")
-	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options simple|raw)")
+	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", "installation output format (options \"simple\"|\"raw\"|\"json\"). With \"json\", the ansible events are written to stdout as JSON lines, and all other messages to stderr")
 	cmd.Flags().BoolVar(&opts.skipPreFlight, "skip-preflight", false, "skip pre-flight checks")
 	return cmd
 }
 
-func doValidate(out io.Writer, planner install.Planner, opts *validateOpts) error {
+func doValidate(stdout io.Writer, planner install.Planner, opts *validateOpts) error {
+	out := messageWriter(stdout, opts.outputFormat)
 	util.PrintHeader(out, "Validating", '=')
 	// Check if plan file exists
 	if !planner.PlanExists() {
@@ -94,8 +99,9 @@ func doValidate(out io.Writer, planner install.Planner, opts *validateOpts) erro
 	options := install.ExecutorOptions{
 		OutputFormat: opts.outputFormat,
 		Verbose:      opts.verbose,
+		Context:      opts.ctx,
 	}
-	e, err := install.NewPreFlightExecutor(out, os.Stderr, options)
+	e, err := install.NewPreFlightExecutor(stdout, os.Stderr, options)
 	if err != nil {
 		return err
 	}
@@ -129,6 +135,10 @@ func validatePlan(out io.Writer, plan *install.Plan) error {
 }
 
 func validateSSHConnectivity(out io.Writer, plan *install.Plan) error {
+	if err := plan.ImportKnownHosts(); err != nil {
+		util.PrettyPrintErr(out, "Validating SSH connectivity to nodes")
+		return err
+	}
 	ok, errs := install.ValidatePlanSSHConnections(plan)
 	if !ok {
 		util.PrettyPrintErr(out, "Validating SSH connectivity to nodes")
@@ -9,6 +9,7 @@ import (
 // NewCmdVolume returns the storage command
 func NewCmdVolume(in io.Reader, out io.Writer) *cobra.Command {
 	var planFile string
+	var planOverlays []string
 	cmd := &cobra.Command{
 		Use:   "volume",
 		Short: "manage storage volumes on your Kubernetes cluster",
@@ -16,9 +17,9 @@ func NewCmdVolume(in io.Reader, out io.Writer) *cobra.Command {
 			return cmd.Usage()
 		},
 	}
-	addPlanFileFlag(cmd.PersistentFlags(), &planFile)
-	cmd.AddCommand(NewCmdVolumeAdd(out, &planFile))
-	cmd.AddCommand(NewCmdVolumeList(out, &planFile))
-	cmd.AddCommand(NewCmdVolumeDelete(in, out, &planFile))
+	addPlanFilesFlag(cmd.PersistentFlags(), &planFile, &planOverlays)
+	cmd.AddCommand(NewCmdVolumeAdd(out, &planFile, &planOverlays))
+	cmd.AddCommand(NewCmdVolumeList(out, &planFile, &planOverlays))
+	cmd.AddCommand(NewCmdVolumeDelete(in, out, &planFile, &planOverlays))
 	return cmd
 }
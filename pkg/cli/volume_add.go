@@ -25,7 +25,7 @@ type volumeAddOptions struct {
 }
 
 // NewCmdVolumeAdd returns the command for adding storage volumes
-func NewCmdVolumeAdd(out io.Writer, planFile *string) *cobra.Command {
+func NewCmdVolumeAdd(out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
 	opts := volumeAddOptions{}
 	cmd := &cobra.Command{
 		Use:   "add size_in_gigabytes [volume-name]",
@@ -34,7 +34,10 @@ func NewCmdVolumeAdd(out io.Writer, planFile *string) *cobra.Command {
 
 This function requires a target cluster that has storage nodes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doVolumeAdd(out, opts, *planFile, args)
+			setKnownHostsFile(opts.generatedAssetsDir)
+			return withPlanLock(*planFile, func() error {
+				return doVolumeAdd(out, opts, *planFile, *planOverlays, args)
+			})
 		},
 		Example: `  # Create a 10GB distributed and replicated volume named "storage01"
   # with StorageClass "durable". Grant access to the volume to any client with an IP
@@ -54,7 +57,10 @@ This function requires a target cluster that has storage nodes.`,
 	return cmd
 }
 
-func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, args []string) error {
+func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, planOverlays []string, args []string) error {
+	if err := checkNoJSONOutput(opts.outputFormat); err != nil {
+		return err
+	}
 	// get volume name and size from arguments
 	var volumeName string
 	var volumeSizeStrGB string
@@ -76,7 +82,7 @@ func doVolumeAdd(out io.Writer, opts volumeAddOptions, planFile string, args []s
 	}
 
 	// setup ansible for execution
-	planner := &install.FilePlanner{File: planFile}
+	planner := &install.FilePlanner{File: planFile, Overlays: planOverlays}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
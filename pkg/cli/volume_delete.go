@@ -19,7 +19,7 @@ type volumeDeleteOptions struct {
 }
 
 // NewCmdVolumeDelete returns the command for deleting storage volumes
-func NewCmdVolumeDelete(in io.Reader, out io.Writer, planFile *string) *cobra.Command {
+func NewCmdVolumeDelete(in io.Reader, out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
 	opts := volumeDeleteOptions{}
 	cmd := &cobra.Command{
 		Use:   "delete volume-name",
@@ -28,6 +28,7 @@ func NewCmdVolumeDelete(in io.Reader, out io.Writer, planFile *string) *cobra.Co
 		
 WARNING all data in the volume will be lost.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			setKnownHostsFile(opts.generatedAssetsDir)
 			if opts.force == false {
 				ans, err := util.PromptForString(in, out, "Are you sure you want to delete this volume? All data will be lost", "N", []string{"N", "y"})
 				if err != nil {
@@ -37,7 +38,9 @@ WARNING all data in the volume will be lost.`,
 					os.Exit(0)
 				}
 			}
-			return doVolumeDelete(out, opts, *planFile, args)
+			return withPlanLock(*planFile, func() error {
+				return doVolumeDelete(out, opts, *planFile, *planOverlays, args)
+			})
 		},
 	}
 	cmd.Flags().BoolVar(&opts.verbose, "verbose", false, "enable verbose logging")
@@ -47,7 +50,10 @@ WARNING all data in the volume will be lost.`,
 	return cmd
 }
 
-func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, args []string) error {
+func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, planOverlays []string, args []string) error {
+	if err := checkNoJSONOutput(opts.outputFormat); err != nil {
+		return err
+	}
 	// get volume name and size from arguments
 	var volumeName string
 	switch len(args) {
@@ -58,7 +64,7 @@ func doVolumeDelete(out io.Writer, opts volumeDeleteOptions, planFile string, ar
 	}
 
 	// setup ansible for execution
-	planner := &install.FilePlanner{File: planFile}
+	planner := &install.FilePlanner{File: planFile, Overlays: planOverlays}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
@@ -13,11 +13,12 @@ import (
 )
 
 type volumeListOptions struct {
-	outputFormat string
+	outputFormat       string
+	generatedAssetsDir string
 }
 
 // NewCmdVolumeList returns the command for listgin storage volumes
-func NewCmdVolumeList(out io.Writer, planFile *string) *cobra.Command {
+func NewCmdVolumeList(out io.Writer, planFile *string, planOverlays *[]string) *cobra.Command {
 	opts := volumeListOptions{}
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -25,22 +26,24 @@ func NewCmdVolumeList(out io.Writer, planFile *string) *cobra.Command {
 		Long: `List storage volumes to the Kubernetes cluster.
 This function requires a target cluster that has storage nodes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return doVolumeList(out, opts, *planFile, args)
+			setKnownHostsFile(opts.generatedAssetsDir)
+			return doVolumeList(out, opts, *planFile, *planOverlays, args)
 		},
 	}
 
 	cmd.Flags().StringVarP(&opts.outputFormat, "output", "o", "simple", `output format (options "simple"|"json")`)
+	cmd.Flags().StringVar(&opts.generatedAssetsDir, "generated-assets-dir", "generated", "path to the directory where assets generated during the installation process will be stored")
 	return cmd
 }
 
-func doVolumeList(out io.Writer, opts volumeListOptions, planFile string, args []string) error {
+func doVolumeList(out io.Writer, opts volumeListOptions, planFile string, planOverlays []string, args []string) error {
 	// verify command
 	if opts.outputFormat != "simple" && opts.outputFormat != "json" {
 		return fmt.Errorf("output format %q is not supported", opts.outputFormat)
 	}
 
 	// Setup ansible
-	planner := &install.FilePlanner{File: planFile}
+	planner := &install.FilePlanner{File: planFile, Overlays: planOverlays}
 	if !planner.PlanExists() {
 		return planFileNotFoundErr{filename: planFile}
 	}
@@ -55,6 +58,7 @@ func doVolumeList(out io.Writer, opts volumeListOptions, planFile string, args [
 	if err != nil {
 		return err
 	}
+	defer clientStorage.Close()
 	glusterClient := data.RemoteGlusterCLI{SSHClient: clientStorage}
 
 	// find master node
@@ -62,6 +66,7 @@ func doVolumeList(out io.Writer, opts volumeListOptions, planFile string, args [
 	if err != nil {
 		return err
 	}
+	defer clientMaster.Close()
 	kubernetesClient := data.RemoteKubectl{SSHClient: clientMaster}
 
 	resp, err := buildResponse(glusterClient, kubernetesClient)
@@ -46,6 +46,10 @@ func (g fakeKubernetesGetter) ListPersistentVolumes() (*data.PersistentVolumeLis
 	return data.UnmarshalPVs(string(g.pvList))
 }
 
+func (g fakeKubernetesGetter) ListPodDisruptionBudgets() (*data.PodDisruptionBudgetList, error) {
+	return &data.PodDisruptionBudgetList{}, nil
+}
+
 func (g fakeGlusterGetter) ListVolumes() (*data.GlusterVolumeInfoCliOutput, error) {
 	if g.isNil {
 		return nil, nil
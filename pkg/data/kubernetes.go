@@ -13,6 +13,16 @@ type PodLister interface {
 	ListPods() (*PodList, error)
 }
 
+// NodeGetter gets a node of a Kubernetes cluster
+type NodeGetter interface {
+	GetNode(name string) (*Node, error)
+}
+
+// PDBLister lists the pod disruption budgets of a Kubernetes cluster
+type PDBLister interface {
+	ListPodDisruptionBudgets() (*PodDisruptionBudgetList, error)
+}
+
 // PVLister lists persistent volumes that exist on a Kubernetes cluster
 type PVLister interface {
 	ListPersistentVolumes() (*PersistentVolumeList, error)
@@ -51,6 +61,7 @@ type StatefulSetGetter interface {
 type KubernetesClient interface {
 	PodLister
 	PVLister
+	PDBLister
 }
 
 // RemoteKubectl is a kubectl client that uses an underlying SSH connection
@@ -102,6 +113,43 @@ func UnmarshalPods(raw string) (*PodList, error) {
 	return &pods, nil
 }
 
+// ListPodDisruptionBudgets returns PodDisruptionBudget data with --all-namespaces=true flag
+func (k RemoteKubectl) ListPodDisruptionBudgets() (*PodDisruptionBudgetList, error) {
+	raw, err := k.SSHClient.Output(true, "sudo kubectl --kubeconfig /root/.kube/config get pdb --all-namespaces=true -o json")
+	if err != nil {
+		return nil, fmt.Errorf("error getting pod disruption budget data: %v", err)
+	}
+	return UnmarshalPodDisruptionBudgets(raw)
+}
+
+func UnmarshalPodDisruptionBudgets(raw string) (*PodDisruptionBudgetList, error) {
+	if isNoResourcesResponse(raw) {
+		return &PodDisruptionBudgetList{}, nil
+	}
+	var pdbs PodDisruptionBudgetList
+	if err := json.Unmarshal([]byte(raw), &pdbs); err != nil {
+		return nil, fmt.Errorf("error unmarshalling pod disruption budget data: %v", err)
+	}
+	return &pdbs, nil
+}
+
+// GetNode returns the Node with the given name. If not found, returns an error.
+func (k RemoteKubectl) GetNode(name string) (*Node, error) {
+	cmd := fmt.Sprintf("sudo kubectl --kubeconfig /root/.kube/config get node -o json %s", name)
+	raw, err := k.SSHClient.Output(true, cmd)
+	if err != nil {
+		return nil, fmt.Errorf("error getting node: %v", err)
+	}
+	if isNoResourcesResponse(raw) {
+		return nil, fmt.Errorf("Node %s was not found", name)
+	}
+	var n Node
+	if err := json.Unmarshal([]byte(raw), &n); err != nil {
+		return nil, fmt.Errorf("error unmarshalling node: %v", err)
+	}
+	return &n, nil
+}
+
 // GetDaemonSet returns the DaemonSet with the given namespace and name. If not found,
 // returns an error.
 func (k RemoteKubectl) GetDaemonSet(namespace, name string) (*DaemonSet, error) {
@@ -6,7 +6,84 @@ type PodList struct {
 
 type Pod struct {
 	ObjectMeta `json:"metadata,omitempty"`
-	Spec       PodSpec `json:"spec,omitempty"`
+	Spec       PodSpec   `json:"spec,omitempty"`
+	Status     PodStatus `json:"status,omitempty"`
+}
+
+// PodPhase is a label for the condition of a pod at the current time.
+type PodPhase string
+
+// The phases of a pod
+const (
+	PodPending   PodPhase = "Pending"
+	PodRunning   PodPhase = "Running"
+	PodSucceeded PodPhase = "Succeeded"
+	PodFailed    PodPhase = "Failed"
+	PodUnknown   PodPhase = "Unknown"
+)
+
+// PodStatus represents information about the status of a pod.
+type PodStatus struct {
+	Phase             PodPhase          `json:"phase,omitempty"`
+	Conditions        []PodCondition    `json:"conditions,omitempty"`
+	ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+}
+
+// PodCondition contains condition information for a pod.
+type PodCondition struct {
+	// Type of the condition, such as "Ready"
+	Type string `json:"type"`
+	// Status of the condition, one of "True", "False" or "Unknown"
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// ContainerStatus contains details for the current status of a container of a pod.
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	Ready        bool           `json:"ready"`
+	RestartCount int32          `json:"restartCount"`
+	State        ContainerState `json:"state,omitempty"`
+}
+
+// ContainerState holds the state of a container. Only the waiting state is used.
+type ContainerState struct {
+	Waiting *ContainerStateWaiting `json:"waiting,omitempty"`
+}
+
+// ContainerStateWaiting is the state of a container that is not running yet, such as
+// a container that is waiting to be restarted after crashing.
+type ContainerStateWaiting struct {
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Node is a worker node in Kubernetes.
+type Node struct {
+	ObjectMeta `json:"metadata,omitempty"`
+	Spec       NodeSpec   `json:"spec,omitempty"`
+	Status     NodeStatus `json:"status,omitempty"`
+}
+
+// NodeSpec describes the attributes that a node is created with.
+type NodeSpec struct {
+	Unschedulable bool `json:"unschedulable,omitempty"`
+}
+
+// NodeStatus is information about the current status of a node.
+type NodeStatus struct {
+	Conditions []NodeCondition `json:"conditions,omitempty"`
+}
+
+// NodeCondition contains condition information for a node.
+type NodeCondition struct {
+	// Type of the condition, such as "Ready"
+	Type string `json:"type"`
+	// Status of the condition, one of "True", "False" or "Unknown"
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 type ObjectMeta struct {
@@ -245,3 +322,98 @@ type StatefulSetStatus struct {
 	// Replicas is the number of actual replicas.
 	Replicas int32
 }
+
+// PodDisruptionBudgetList is a list of PodDisruptionBudgets.
+type PodDisruptionBudgetList struct {
+	Items []PodDisruptionBudget `json:"items"`
+}
+
+// PodDisruptionBudget is an object to define the max disruption that can be caused to a collection of pods.
+type PodDisruptionBudget struct {
+	TypeMeta   `json:",inline"`
+	ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PodDisruptionBudgetSpec   `json:"spec,omitempty"`
+	Status PodDisruptionBudgetStatus `json:"status,omitempty"`
+}
+
+// PodDisruptionBudgetSpec is a description of a PodDisruptionBudget.
+type PodDisruptionBudgetSpec struct {
+	// Selector is the label query over the pods whose evictions are managed by the budget.
+	// A nil or empty selector selects no pods.
+	Selector *LabelSelector `json:"selector,omitempty"`
+}
+
+// PodDisruptionBudgetStatus represents information about the status of a PodDisruptionBudget.
+type PodDisruptionBudgetStatus struct {
+	// PodDisruptionsAllowed is the number of pod disruptions that are currently allowed.
+	PodDisruptionsAllowed int32 `json:"disruptionsAllowed"`
+	// CurrentHealthy is the current number of healthy pods
+	CurrentHealthy int32 `json:"currentHealthy"`
+	// DesiredHealthy is the minimum desired number of healthy pods
+	DesiredHealthy int32 `json:"desiredHealthy"`
+	// ExpectedPods is the total number of pods counted by the budget
+	ExpectedPods int32 `json:"expectedPods"`
+}
+
+// LabelSelector is a label query over a set of resources. The requirements of MatchLabels
+// and MatchExpressions are ANDed.
+type LabelSelector struct {
+	MatchLabels      map[string]string          `json:"matchLabels,omitempty"`
+	MatchExpressions []LabelSelectorRequirement `json:"matchExpressions,omitempty"`
+}
+
+// LabelSelectorRequirement is a selector that contains values, a key, and an operator that
+// relates the key and values.
+type LabelSelectorRequirement struct {
+	Key string `json:"key"`
+	// Operator is one of "In", "NotIn", "Exists" and "DoesNotExist"
+	Operator string   `json:"operator"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// Matches returns true if the labels satisfy the selector. A nil or empty selector matches
+// no labels, as the selector of a PodDisruptionBudget.
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	if s == nil || (len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0) {
+		return false
+	}
+	for k, v := range s.MatchLabels {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	for _, r := range s.MatchExpressions {
+		l, ok := labels[r.Key]
+		switch r.Operator {
+		case "In":
+			if !ok || !contains(r.Values, l) {
+				return false
+			}
+		case "NotIn":
+			if ok && contains(r.Values, l) {
+				return false
+			}
+		case "Exists":
+			if !ok {
+				return false
+			}
+		case "DoesNotExist":
+			if ok {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func contains(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -67,14 +67,19 @@ func ListVersions(plan *Plan) (ClusterVersion, error) {
 		Nodes: []ListableNode{},
 	}
 
-	sshDeets := plan.Cluster.SSH
+	if err := plan.ImportKnownHosts(); err != nil {
+		return cv, err
+	}
+
 	ketVerFile := "/etc/kismatic-version"
 	componentVerFile := "/etc/component-versions"
 	for i, node := range nodes {
-		client, err := ssh.NewClient(node.IP, sshDeets.Port, sshDeets.User, sshDeets.Key)
+		sshDeets := plan.SSHConfigForNode(node)
+		client, err := ssh.NewClient(node.IP, sshDeets.Port, sshDeets.User, sshDeets.Key, sshDeets.SSHBastion())
 		if err != nil {
 			return cv, fmt.Errorf("error creating SSH client: %v", err)
 		}
+		defer client.Close()
 
 		// get KET version
 		ketOutput, err := client.Output(false, fmt.Sprintf("cat %s", ketVerFile))
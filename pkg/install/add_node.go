@@ -63,6 +63,7 @@ func (ae *ansibleExecutor) AddNode(originalPlan *Plan, newNode Node, roles []str
 		clusterCatalog: *cc,
 		explainer:      ae.defaultExplainer(),
 		limit:          []string{newNode.Host},
+		printTiming:    true,
 	}
 	if err = ae.execute(t); err != nil {
 		return nil, fmt.Errorf("error running playbook: %v", err)
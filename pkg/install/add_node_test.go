@@ -1,9 +1,13 @@
 package install
 
 import (
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/apprenda/kismatic/pkg/ansible"
@@ -139,6 +143,81 @@ func TestAddNodePlanIsUpdated(t *testing.T) {
 	}
 }
 
+func TestAddNodePlanKeepsSecretRefs(t *testing.T) {
+	tmp := mustGetTempDir(t)
+	defer os.RemoveAll(tmp)
+	passwordFile := filepath.Join(tmp, "admin-password")
+	if err := ioutil.WriteFile(passwordFile, []byte("adminsecret\n"), 0600); err != nil {
+		t.Fatalf("error writing secret file: %v", err)
+	}
+	os.Setenv("KET_TEST_REGISTRY_PASSWORD", "registrysecret")
+	defer os.Unsetenv("KET_TEST_REGISTRY_PASSWORD")
+	plan := `cluster:
+  version: v1.10.11
+  admin_password: file:` + passwordFile + `
+  networking:
+    service_cidr_block: 10.0.0.0/16
+docker_registry:
+  server: registry:443
+  password: env:KET_TEST_REGISTRY_PASSWORD
+master:
+  nodes:
+  - host: master
+    internalip: 10.10.2.20
+worker:
+  expected_count: 1
+  nodes:
+  - host: existingWorker
+`
+	planner := &FilePlanner{File: filepath.Join(tmp, "kismatic-cluster.yaml")}
+	if err := ioutil.WriteFile(planner.File, []byte(plan), 0644); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	originalPlan, err := planner.Read()
+	if err != nil {
+		t.Fatalf("error reading plan file: %v", err)
+	}
+	e := ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: tmp},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.RawFormat,
+		pki: &fakePKI{
+			caExists: true,
+		},
+		runnerExplainerFactory: fakeRunnerExplainer(nil),
+		certsDir:               mustGetTempDir(t),
+	}
+	updatedPlan, err := e.AddNode(originalPlan, Node{Host: "test"}, []string{"worker"}, true)
+	if err != nil {
+		t.Fatalf("unexpected error while adding worker: %v", err)
+	}
+	if err = planner.Write(updatedPlan); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(planner.File)
+	if err != nil {
+		t.Fatalf("error reading plan file: %v", err)
+	}
+	for _, ref := range []string{"file:" + passwordFile, "env:KET_TEST_REGISTRY_PASSWORD"} {
+		if !strings.Contains(string(b), ref) {
+			t.Errorf("expected the plan file to contain the secret reference %q, got:\n%s", ref, b)
+		}
+	}
+	for _, secret := range []string{"adminsecret", "registrysecret"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("expected the plan file not to contain %q", secret)
+		}
+	}
+	p, err := planner.Read()
+	if err != nil {
+		t.Fatalf("error reading updated plan file: %v", err)
+	}
+	if p.Cluster.AdminPassword != "adminsecret" || p.DockerRegistry.Password != "registrysecret" || len(p.Worker.Nodes) != 2 {
+		t.Errorf("unexpected updated plan: %+v", p)
+	}
+}
+
 func TestAddIngressPlanIsUpdated(t *testing.T) {
 	e := ansibleExecutor{
 		options:             ExecutorOptions{RunsDirectory: mustGetTempDir(t)},
@@ -537,14 +616,24 @@ type fakeRunner struct {
 	allNodesPlaybooks []string
 }
 
-func (f *fakeRunner) StartPlaybook(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog) (<-chan ansible.Event, error) {
+func (f *fakeRunner) StartPlaybook(ctx context.Context, playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog) (<-chan ansible.Event, error) {
 	f.allNodesPlaybooks = append(f.allNodesPlaybooks, playbookFile)
-	return f.eventChan, f.err
+	return f.events(), f.err
 }
-func (f *fakeRunner) WaitPlaybook() error { return f.err }
-func (f *fakeRunner) StartPlaybookOnNode(playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog, node ...string) (<-chan ansible.Event, error) {
+func (f *fakeRunner) WaitPlaybook(ctx context.Context) error { return f.err }
+func (f *fakeRunner) StartPlaybookOnNode(ctx context.Context, playbookFile string, inventory ansible.Inventory, cc ansible.ClusterCatalog, node ...string) (<-chan ansible.Event, error) {
 	f.incomingCatalog = cc
-	return f.eventChan, f.err
+	return f.events(), f.err
+}
+
+// events returns the event stream of the fake runner, which is closed when no events are set
+func (f *fakeRunner) events() chan ansible.Event {
+	if f.eventChan == nil {
+		events := make(chan ansible.Event)
+		close(events)
+		return events
+	}
+	return f.eventChan
 }
 
 func fakeRunnerExplainer(execError error) func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
@@ -0,0 +1,67 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+const (
+	// dryRunName is the name of the runs directory that holds the dry-runs
+	dryRunName = "dry-run"
+	// dryRunStepsFilename is the name of the file that lists the playbooks of a dry-run
+	dryRunStepsFilename = "steps"
+)
+
+// dryRun records what the task would execute, without running ansible. The inventory
+// and the cluster catalog, without its secrets, are written to a directory for each
+// task, and the playbook is added to the ordered list of steps of the dry-run.
+func (ae *ansibleExecutor) dryRun(t task) error {
+	if ae.dryRunDirectory == "" {
+		dir := filepath.Join(ae.options.RunsDirectory, dryRunName, time.Now().Format(runTimestampFormat))
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return fmt.Errorf("error creating dry-run directory: %v", err)
+		}
+		ae.dryRunDirectory = dir
+	}
+	ae.dryRunSteps++
+	stepDirectory := filepath.Join(ae.dryRunDirectory, fmt.Sprintf("%02d-%s", ae.dryRunSteps, t.name))
+	if err := os.MkdirAll(stepDirectory, 0777); err != nil {
+		return fmt.Errorf("error creating dry-run directory for %q: %v", t.name, err)
+	}
+	inventoryFile := filepath.Join(stepDirectory, "inventory.ini")
+	if err := ioutil.WriteFile(inventoryFile, t.inventory.ToINI(), 0644); err != nil {
+		return fmt.Errorf("error writing inventory file to %q: %v", inventoryFile, err)
+	}
+	redacted := t.clusterCatalog.Redacted()
+	catalog, err := redacted.ToYAML()
+	if err != nil {
+		return fmt.Errorf("error writing cluster catalog data to yaml: %v", err)
+	}
+	catalogFile := filepath.Join(stepDirectory, "clustercatalog.yaml")
+	if err = ioutil.WriteFile(catalogFile, catalog, 0644); err != nil {
+		return fmt.Errorf("error writing cluster catalog file to %q: %v", catalogFile, err)
+	}
+
+	step := fmt.Sprintf("%02d %s", ae.dryRunSteps, t.playbook)
+	if len(t.limit) > 0 {
+		step = fmt.Sprintf("%s --limit %s", step, strings.Join(t.limit, ","))
+	}
+	stepsFile := filepath.Join(ae.dryRunDirectory, dryRunStepsFilename)
+	f, err := os.OpenFile(stepsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening dry-run steps file: %v", err)
+	}
+	defer f.Close()
+	if _, err = fmt.Fprintln(f, step); err != nil {
+		return fmt.Errorf("error writing dry-run steps file: %v", err)
+	}
+	util.PrettyPrintOk(ae.stdout, "Dry-run: %s", step)
+	util.PrettyPrintOk(ae.stdout, "Dry-run: inventory and cluster catalog written to %q", stepDirectory)
+	return nil
+}
@@ -0,0 +1,72 @@
+package install
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDryRunUpgradeNodes(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-dry-run")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	out := &bytes.Buffer{}
+	e := ansibleExecutor{
+		options: ExecutorOptions{RunsDirectory: tmp, DryRun: true},
+		stdout:  out,
+	}
+	node := func(host string) Node {
+		return Node{Host: host, IP: "10.0.0." + host[len(host)-1:]}
+	}
+	plan := Plan{
+		Cluster: Cluster{
+			Version:    "v1.10.11",
+			Networking: NetworkConfig{ServiceCIDRBlock: "10.0.0.0/16"},
+		},
+		Etcd:   NodeGroup{Nodes: []Node{node("etcd1")}},
+		Master: MasterNodeGroup{Nodes: []Node{node("master2")}},
+		Worker: NodeGroup{Nodes: []Node{node("worker3"), node("worker4"), node("worker5")}},
+	}
+	var toUpgrade []ListableNode
+	for _, n := range []struct {
+		node Node
+		role string
+	}{
+		{node("worker3"), "worker"}, {node("master2"), "master"}, {node("worker4"), "worker"},
+		{node("etcd1"), "etcd"}, {node("worker5"), "worker"},
+	} {
+		toUpgrade = append(toUpgrade, ListableNode{Node: n.node, Roles: []string{n.role}})
+	}
+	if err = e.UpgradeNodes(plan, toUpgrade, false, 2, false, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	steps, err := ioutil.ReadFile(filepath.Join(e.dryRunDirectory, dryRunStepsFilename))
+	if err != nil {
+		t.Fatalf("error reading dry-run steps: %v", err)
+	}
+	expected := `01 upgrade-nodes.yaml --limit etcd1
+02 upgrade-nodes.yaml --limit master2
+03 upgrade-nodes.yaml --limit worker3,worker4
+04 upgrade-nodes.yaml --limit worker5
+`
+	if string(steps) != expected {
+		t.Errorf("expected dry-run steps:\n%s\nbut got:\n%s", expected, steps)
+	}
+	for _, f := range []string{"inventory.ini", "clustercatalog.yaml"} {
+		if _, err := os.Stat(filepath.Join(e.dryRunDirectory, "03-upgrade-nodes", f)); err != nil {
+			t.Errorf("expected %s to be written to the dry-run directory: %v", f, err)
+		}
+	}
+	if !strings.Contains(out.String(), "upgrade-nodes.yaml --limit worker3,worker4") {
+		t.Errorf("expected the dry-run steps to be printed, but got:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "upgrade-nodes")); !os.IsNotExist(err) {
+		t.Errorf("expected no run directory to be created during a dry-run")
+	}
+}
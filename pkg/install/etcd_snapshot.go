@@ -0,0 +1,307 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+const (
+	// etcdSnapshotsDirectory is the directory of the generated assets directory where
+	// the etcd snapshots are kept
+	etcdSnapshotsDirectory = "etcd-snapshots"
+	// etcdBackupsDirectory is the directory of the generated assets directory where the
+	// etcd snapshots taken on demand are kept. They are never removed by kismatic.
+	etcdBackupsDirectory = "etcd-backups"
+	// DefaultEtcdSnapshotsRetained is the number of etcd snapshots that are kept when
+	// no other number is configured
+	DefaultEtcdSnapshotsRetained = 5
+)
+
+// The files of an etcd snapshot, one for each etcd cluster
+const (
+	// EtcdK8sSnapshotFile is the snapshot of the Kubernetes etcd cluster
+	EtcdK8sSnapshotFile = "etcd_k8s.db"
+	// EtcdNetworkingSnapshotFile is the snapshot of the networking etcd cluster, which is
+	// only taken when the networking etcd cluster is used by Calico. Contiv also uses the
+	// networking etcd cluster, but its state cannot be included in the snapshots.
+	EtcdNetworkingSnapshotFile = "etcd_networking.db"
+)
+
+// EtcdSnapshot is a snapshot of the etcd clusters, copied to a directory of the generated
+// assets directory
+type EtcdSnapshot struct {
+	// Path of the snapshot directory
+	Path string
+	// Time the snapshot was taken
+	Time time.Time
+	// Files of the snapshot, one for each etcd cluster
+	Files []string
+	// Size of the snapshot files, in bytes
+	Size int64
+}
+
+// EtcdSnapshotsDirectory returns the directory where the etcd snapshots that are taken before
+// the cluster is changed are kept
+func EtcdSnapshotsDirectory(generatedAssetsDir string) string {
+	return filepath.Join(generatedAssetsDir, etcdSnapshotsDirectory)
+}
+
+// EtcdBackupsDirectory returns the directory where the etcd snapshots that are taken on demand
+// are kept
+func EtcdBackupsDirectory(generatedAssetsDir string) string {
+	return filepath.Join(generatedAssetsDir, etcdBackupsDirectory)
+}
+
+// snapshotsNetworkingEtcd returns whether the networking etcd cluster is included in the
+// snapshots. It is included when it is used by Calico, with the v3 API.
+func snapshotsNetworkingEtcd(p Plan) bool {
+	return p.AddOns.CNI != nil && !p.AddOns.CNI.Disable && p.AddOns.CNI.Provider == cniProviderCalico
+}
+
+// etcdSnapshotsSupported returns an error if the etcd clusters of the plan cannot be snapshotted.
+// Contiv keeps its state in the networking etcd cluster with the v2 API, which is not included
+// in the snapshots of the v3 API.
+func etcdSnapshotsSupported(p Plan) error {
+	if p.AddOns.CNI != nil && !p.AddOns.CNI.Disable && p.AddOns.CNI.Provider == cniProviderContiv {
+		return fmt.Errorf("etcd snapshots do not support the %q CNI provider, which keeps its state in the networking etcd cluster with the etcd v2 API", cniProviderContiv)
+	}
+	return nil
+}
+
+// ReadEtcdSnapshot returns the etcd snapshot of the directory. The path is the snapshot directory,
+// or the name of a snapshot directory in the etcd snapshots directory or in the etcd backups directory.
+func ReadEtcdSnapshot(generatedAssetsDir string, path string) (*EtcdSnapshot, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) && filepath.Base(path) == path {
+		for _, dir := range []string{EtcdSnapshotsDirectory(generatedAssetsDir), EtcdBackupsDirectory(generatedAssetsDir)} {
+			if info, err = os.Stat(filepath.Join(dir, path)); !os.IsNotExist(err) {
+				path = filepath.Join(dir, path)
+				break
+			}
+		}
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("etcd snapshot %q was not found", path)
+		}
+		return nil, fmt.Errorf("error reading etcd snapshot %q: %v", path, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("etcd snapshot %q is not a directory. An etcd snapshot is a directory of the %q or %q directory", path, EtcdSnapshotsDirectory(generatedAssetsDir), EtcdBackupsDirectory(generatedAssetsDir))
+	}
+	s := &EtcdSnapshot{Path: path}
+	if t, err := time.ParseInLocation(runTimestampFormat, filepath.Base(path), time.Local); err == nil {
+		s.Time = t
+	} else {
+		s.Time = info.ModTime()
+	}
+	for _, name := range []string{EtcdK8sSnapshotFile, EtcdNetworkingSnapshotFile} {
+		f, err := os.Stat(filepath.Join(path, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading etcd snapshot %q: %v", path, err)
+		}
+		s.Files = append(s.Files, name)
+		s.Size += f.Size()
+	}
+	if !s.hasFile(EtcdK8sSnapshotFile) {
+		return nil, fmt.Errorf("%q is not an etcd snapshot: %s was not found", path, EtcdK8sSnapshotFile)
+	}
+	return s, nil
+}
+
+func (s EtcdSnapshot) hasFile(name string) bool {
+	return util.Contains(name, s.Files)
+}
+
+// validateFor returns an error if the snapshot does not include the etcd clusters of the plan,
+// or if the etcd clusters of the plan cannot be restored from a snapshot
+func (s EtcdSnapshot) validateFor(p Plan) error {
+	if err := etcdSnapshotsSupported(p); err != nil {
+		return err
+	}
+	if snapshotsNetworkingEtcd(p) && !s.hasFile(EtcdNetworkingSnapshotFile) {
+		return fmt.Errorf("etcd snapshot %q does not include the networking etcd cluster used by Calico: %s was not found", s.Path, EtcdNetworkingSnapshotFile)
+	}
+	return nil
+}
+
+// ListEtcdSnapshots returns the etcd snapshots of the etcd snapshots directory of the generated
+// assets directory, most recent first. The snapshots taken on demand are not included.
+func ListEtcdSnapshots(generatedAssetsDir string) ([]EtcdSnapshot, error) {
+	dir := EtcdSnapshotsDirectory(generatedAssetsDir)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading etcd snapshots directory: %v", err)
+	}
+	var snapshots []EtcdSnapshot
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		if _, err := time.ParseInLocation(runTimestampFormat, f.Name(), time.Local); err != nil {
+			// not a snapshot taken by kismatic
+			continue
+		}
+		s, err := ReadEtcdSnapshot(generatedAssetsDir, filepath.Join(dir, f.Name()))
+		if err != nil {
+			// an incomplete snapshot
+			continue
+		}
+		snapshots = append(snapshots, *s)
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].Time.After(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// PruneEtcdSnapshots removes all but the most recent etcd snapshots of the etcd snapshots
+// directory, and returns the paths of the removed snapshots. The snapshots taken on demand
+// are never removed.
+func PruneEtcdSnapshots(generatedAssetsDir string, keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("the number of etcd snapshots to keep must be greater or equal to 1, got: %d", keep)
+	}
+	snapshots, err := ListEtcdSnapshots(generatedAssetsDir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for i, s := range snapshots {
+		if i < keep {
+			continue
+		}
+		if err := os.RemoveAll(s.Path); err != nil {
+			return removed, fmt.Errorf("error removing etcd snapshot %q: %v", s.Path, err)
+		}
+		removed = append(removed, s.Path)
+	}
+	return removed, nil
+}
+
+// createEtcdSnapshotDirectory creates a new snapshot directory in the parent directory,
+// named after the current time
+func createEtcdSnapshotDirectory(parent string) (string, time.Time, error) {
+	if err := os.MkdirAll(parent, 0700); err != nil {
+		return "", time.Time{}, fmt.Errorf("error creating etcd snapshots directory: %v", err)
+	}
+	parent, err := filepath.Abs(parent)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("error getting absolute path of etcd snapshots directory: %v", err)
+	}
+	t := time.Now()
+	for {
+		dir := filepath.Join(parent, t.Format(runTimestampFormat))
+		err := os.Mkdir(dir, 0700)
+		if err == nil {
+			return dir, t, nil
+		}
+		if !os.IsExist(err) {
+			return "", time.Time{}, fmt.Errorf("error creating etcd snapshot directory: %v", err)
+		}
+		t = t.Add(time.Second)
+	}
+}
+
+// SnapshotEtcd takes a snapshot of the etcd clusters on the first etcd node, and copies it
+// to a directory of the etcd snapshots directory of the generated assets directory
+func (ae *ansibleExecutor) SnapshotEtcd(p *Plan) (*EtcdSnapshot, error) {
+	return ae.snapshotEtcd(p, EtcdSnapshotsDirectory(ae.options.GeneratedAssetsDirectory))
+}
+
+// BackupEtcd takes a snapshot of the etcd clusters on the first etcd node, and copies it
+// to a directory of the etcd backups directory of the generated assets directory
+func (ae *ansibleExecutor) BackupEtcd(p *Plan) (*EtcdSnapshot, error) {
+	return ae.snapshotEtcd(p, EtcdBackupsDirectory(ae.options.GeneratedAssetsDirectory))
+}
+
+func (ae *ansibleExecutor) snapshotEtcd(p *Plan, parent string) (*EtcdSnapshot, error) {
+	if err := etcdSnapshotsSupported(*p); err != nil {
+		return nil, err
+	}
+	dir, t, err := createEtcdSnapshotDirectory(parent)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := ae.buildClusterCatalog(p)
+	if err != nil {
+		return nil, err
+	}
+	cc.EtcdSnapshotName = t.Format(runTimestampFormat)
+	cc.EtcdSnapshotDirectory = dir
+	tsk := task{
+		name:           "etcd-snapshot",
+		playbook:       "etcd-snapshot.yaml",
+		explainer:      ae.defaultExplainer(),
+		plan:           *p,
+		inventory:      buildInventoryFromPlan(p),
+		clusterCatalog: *cc,
+	}
+	util.PrintHeader(ae.stdout, "Taking Etcd Snapshot", '=')
+	if err := ae.execute(tsk); err != nil {
+		// do not leave an incomplete snapshot behind
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	if ae.options.DryRun {
+		// nothing was copied to the snapshot directory
+		os.Remove(dir)
+		return &EtcdSnapshot{Path: dir, Time: t}, nil
+	}
+	snapshot, err := ReadEtcdSnapshot(ae.options.GeneratedAssetsDirectory, dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := snapshot.validateFor(*p); err != nil {
+		return nil, err
+	}
+	for _, f := range snapshot.Files {
+		// the snapshot holds the secrets of the cluster
+		file := filepath.Join(dir, f)
+		if err := os.Chmod(file, 0600); err != nil {
+			return nil, fmt.Errorf("error setting the permissions of etcd snapshot %q: %v", file, err)
+		}
+	}
+	return snapshot, nil
+}
+
+// RestoreEtcd stops the control plane, restores every member of the etcd clusters from
+// the snapshot, and starts the control plane
+func (ae *ansibleExecutor) RestoreEtcd(p *Plan, snapshot EtcdSnapshot) error {
+	if err := snapshot.validateFor(*p); err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(snapshot.Path)
+	if err != nil {
+		return fmt.Errorf("error getting absolute path of etcd snapshot %q: %v", snapshot.Path, err)
+	}
+	cc, err := ae.buildClusterCatalog(p)
+	if err != nil {
+		return err
+	}
+	// the data of the etcd members is moved aside, and suffixed with the time of the restore
+	cc.EtcdSnapshotName = time.Now().Format(runTimestampFormat)
+	cc.EtcdSnapshotDirectory = dir
+	tsk := task{
+		name:           "etcd-restore",
+		playbook:       "etcd-restore.yaml",
+		explainer:      ae.defaultExplainer(),
+		plan:           *p,
+		inventory:      buildInventoryFromPlan(p),
+		clusterCatalog: *cc,
+	}
+	util.PrintHeader(ae.stdout, "Restoring Etcd Snapshot", '=')
+	return ae.execute(tsk)
+}
@@ -0,0 +1,253 @@
+package install
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPruneEtcdSnapshots(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-etcd-snapshots")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := EtcdSnapshotsDirectory(tmp)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("error creating snapshots dir: %v", err)
+	}
+	snapshots := []string{
+		"2018-06-13-10-00-00",
+		"2018-06-11-10-00-00",
+		"2018-06-12-10-00-00",
+		// not snapshots taken by kismatic, or incomplete
+		"latest",
+		"2018-06-14-10-00-00",
+	}
+	for i, name := range snapshots {
+		if err := os.Mkdir(filepath.Join(dir, name), 0700); err != nil {
+			t.Fatalf("error creating snapshot dir: %v", err)
+		}
+		if i == len(snapshots)-1 {
+			continue
+		}
+		for _, f := range []string{EtcdK8sSnapshotFile, EtcdNetworkingSnapshotFile} {
+			if err := ioutil.WriteFile(filepath.Join(dir, name, f), []byte("snapshot"), 0600); err != nil {
+				t.Fatalf("error writing file: %v", err)
+			}
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0600); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	listed, err := ListEtcdSnapshots(tmp)
+	if err != nil {
+		t.Fatalf("unexpected error listing snapshots: %v", err)
+	}
+	if len(listed) != 3 {
+		t.Fatalf("expected 3 snapshots, got %v", listed)
+	}
+	if filepath.Base(listed[0].Path) != snapshots[0] || filepath.Base(listed[2].Path) != snapshots[1] {
+		t.Errorf("expected the most recent snapshot first, got %v", listed)
+	}
+	if listed[0].Size != int64(2*len("snapshot")) || len(listed[0].Files) != 2 {
+		t.Errorf("expected the files and size of the snapshot, got %v", listed[0])
+	}
+
+	if _, err := PruneEtcdSnapshots(tmp, 0); err == nil {
+		t.Errorf("expected an error when keeping no snapshots")
+	}
+	removed, err := PruneEtcdSnapshots(tmp, 2)
+	if err != nil {
+		t.Fatalf("unexpected error pruning snapshots: %v", err)
+	}
+	if len(removed) != 1 || filepath.Base(removed[0]) != snapshots[1] {
+		t.Errorf("expected the oldest snapshot to be removed, got %v", removed)
+	}
+	for i, name := range snapshots {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if i == 1 && !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", name)
+		}
+		if i != 1 && err != nil {
+			t.Errorf("expected %s to be kept: %v", name, err)
+		}
+	}
+
+	// the snapshots directory does not exist
+	listed, err = ListEtcdSnapshots(filepath.Join(tmp, "missing"))
+	if err != nil || len(listed) != 0 {
+		t.Errorf("expected no snapshots, got %v: %v", listed, err)
+	}
+}
+
+func TestDryRunSnapshotEtcd(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-dry-run")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	out := &bytes.Buffer{}
+	assets := filepath.Join(tmp, "generated")
+	e := ansibleExecutor{
+		options: ExecutorOptions{RunsDirectory: tmp, GeneratedAssetsDirectory: assets, DryRun: true},
+		stdout:  out,
+	}
+	plan := &Plan{
+		Cluster: Cluster{
+			Version:    "v1.10.11",
+			Networking: NetworkConfig{ServiceCIDRBlock: "10.0.0.0/16"},
+		},
+		Etcd:   NodeGroup{Nodes: []Node{{Host: "etcd1", IP: "10.0.0.1"}}},
+		Master: MasterNodeGroup{Nodes: []Node{{Host: "master2", IP: "10.0.0.2"}}},
+	}
+	// the etcd clusters used by contiv cannot be snapshotted
+	plan.AddOns.CNI = &CNI{Provider: cniProviderContiv}
+	if _, err = e.SnapshotEtcd(plan); err == nil || !strings.Contains(err.Error(), "contiv") {
+		t.Errorf("expected an error with contiv, got: %v", err)
+	}
+	plan.AddOns.CNI = nil
+	snapshot, err := e.SnapshotEtcd(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir, _ := filepath.Abs(EtcdSnapshotsDirectory(assets))
+	if filepath.Dir(snapshot.Path) != dir || filepath.Base(snapshot.Path) != snapshot.Time.Format(runTimestampFormat) {
+		t.Errorf("expected the snapshot to be saved to %s, got %q", dir, snapshot.Path)
+	}
+
+	steps, err := ioutil.ReadFile(filepath.Join(e.dryRunDirectory, dryRunStepsFilename))
+	if err != nil {
+		t.Fatalf("error reading dry-run steps: %v", err)
+	}
+	if string(steps) != "01 etcd-snapshot.yaml\n" {
+		t.Errorf("expected the etcd-snapshot playbook, got:\n%s", steps)
+	}
+	cc, err := ioutil.ReadFile(filepath.Join(e.dryRunDirectory, "01-etcd-snapshot", "clustercatalog.yaml"))
+	if err != nil {
+		t.Fatalf("error reading cluster catalog: %v", err)
+	}
+	if !strings.Contains(string(cc), "etcd_snapshot_dir: "+snapshot.Path) || !strings.Contains(string(cc), "etcd_snapshot_name: "+filepath.Base(snapshot.Path)) {
+		t.Errorf("expected the snapshot directory in the cluster catalog, got:\n%s", cc)
+	}
+
+	// the snapshots taken on demand are kept apart, so that they are not pruned
+	backup, err := e.BackupEtcd(plan)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dir, _ = filepath.Abs(EtcdBackupsDirectory(assets))
+	if filepath.Dir(backup.Path) != dir {
+		t.Errorf("expected the backup to be saved to %s, got %q", dir, backup.Path)
+	}
+}
+
+func TestReadEtcdSnapshot(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-etcd-snapshots")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	dir := filepath.Join(EtcdSnapshotsDirectory(tmp), "2018-06-13-10-00-00")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatalf("error creating snapshot dir: %v", err)
+	}
+	if _, err := ReadEtcdSnapshot(tmp, dir); err == nil {
+		t.Errorf("expected an error when the snapshot of the kubernetes etcd cluster is missing")
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, EtcdK8sSnapshotFile), []byte("snapshot"), 0600); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+
+	// the snapshot is read by path, or by name
+	for _, path := range []string{dir, "2018-06-13-10-00-00"} {
+		s, err := ReadEtcdSnapshot(tmp, path)
+		if err != nil {
+			t.Fatalf("unexpected error reading snapshot %q: %v", path, err)
+		}
+		if s.Path != dir || s.Time.Format(runTimestampFormat) != "2018-06-13-10-00-00" {
+			t.Errorf("unexpected snapshot %v", s)
+		}
+	}
+	if _, err := ReadEtcdSnapshot(tmp, "2018-06-12-10-00-00"); err == nil {
+		t.Errorf("expected an error when the snapshot does not exist")
+	}
+
+	// the snapshots taken on demand are read by name, but are not listed for pruning
+	backup := filepath.Join(EtcdBackupsDirectory(tmp), "2018-06-12-10-00-00")
+	if err := os.MkdirAll(backup, 0700); err != nil {
+		t.Fatalf("error creating backup dir: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(backup, EtcdK8sSnapshotFile), []byte("snapshot"), 0600); err != nil {
+		t.Fatalf("error writing file: %v", err)
+	}
+	if s, err := ReadEtcdSnapshot(tmp, "2018-06-12-10-00-00"); err != nil || s.Path != backup {
+		t.Errorf("expected the backup %s, got %v: %v", backup, s, err)
+	}
+	if listed, err := ListEtcdSnapshots(tmp); err != nil || len(listed) != 1 || listed[0].Path != dir {
+		t.Errorf("expected only the snapshot %s to be listed, got %v: %v", dir, listed, err)
+	}
+
+	// the networking etcd cluster is only required with calico
+	s, _ := ReadEtcdSnapshot(tmp, dir)
+	p := Plan{AddOns: AddOns{CNI: &CNI{Provider: cniProviderCalico}}}
+	if err := s.validateFor(p); err == nil {
+		t.Errorf("expected an error when the snapshot of the networking etcd cluster is missing")
+	}
+	p.AddOns.CNI.Provider = cniProviderWeave
+	if err := s.validateFor(p); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	// the etcd clusters used by contiv cannot be restored
+	p.AddOns.CNI.Provider = cniProviderContiv
+	if err := s.validateFor(p); err == nil || !strings.Contains(err.Error(), "contiv") {
+		t.Errorf("expected an error with contiv, got: %v", err)
+	}
+}
+
+func TestDryRunRestoreEtcd(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-dry-run")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	e := ansibleExecutor{
+		options: ExecutorOptions{RunsDirectory: tmp, GeneratedAssetsDirectory: filepath.Join(tmp, "generated"), DryRun: true},
+		stdout:  &bytes.Buffer{},
+	}
+	plan := &Plan{
+		Cluster: Cluster{
+			Version:    "v1.10.11",
+			Networking: NetworkConfig{ServiceCIDRBlock: "10.0.0.0/16"},
+		},
+		AddOns: AddOns{CNI: &CNI{Provider: cniProviderCalico}},
+		Etcd:   NodeGroup{Nodes: []Node{{Host: "etcd1", IP: "10.0.0.1"}}},
+		Master: MasterNodeGroup{Nodes: []Node{{Host: "master2", IP: "10.0.0.2"}}},
+	}
+	snapshot := EtcdSnapshot{Path: filepath.Join(tmp, "2018-06-13-10-00-00"), Files: []string{EtcdK8sSnapshotFile}}
+	if err := e.RestoreEtcd(plan, snapshot); err == nil {
+		t.Errorf("expected an error when the snapshot of the networking etcd cluster is missing")
+	}
+	snapshot.Files = append(snapshot.Files, EtcdNetworkingSnapshotFile)
+	if err := e.RestoreEtcd(plan, snapshot); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	steps, err := ioutil.ReadFile(filepath.Join(e.dryRunDirectory, dryRunStepsFilename))
+	if err != nil {
+		t.Fatalf("error reading dry-run steps: %v", err)
+	}
+	if string(steps) != "01 etcd-restore.yaml\n" {
+		t.Errorf("expected the etcd-restore playbook, got:\n%s", steps)
+	}
+	cc, err := ioutil.ReadFile(filepath.Join(e.dryRunDirectory, "01-etcd-restore", "clustercatalog.yaml"))
+	if err != nil {
+		t.Fatalf("error reading cluster catalog: %v", err)
+	}
+	if !strings.Contains(string(cc), "etcd_snapshot_dir: "+snapshot.Path) {
+		t.Errorf("expected the snapshot directory in the cluster catalog, got:\n%s", cc)
+	}
+}
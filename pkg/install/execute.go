@@ -1,6 +1,7 @@
 package install
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -13,6 +14,7 @@ import (
 
 	"github.com/apprenda/kismatic/pkg/ansible"
 	"github.com/apprenda/kismatic/pkg/install/explain"
+	"github.com/apprenda/kismatic/pkg/ssh"
 	"github.com/apprenda/kismatic/pkg/tls"
 	"github.com/apprenda/kismatic/pkg/util"
 )
@@ -29,6 +31,8 @@ type PreFlightExecutor interface {
 type Executor interface {
 	PreFlightExecutor
 	Install(plan *Plan, restartServices bool, nodes ...string) error
+	ResumeInstall(plan *Plan, restartServices bool, nodes ...string) error
+	RetryInstall(plan *Plan, restartServices bool) error
 	Reset(plan *Plan, nodes ...string) error
 	GenerateCertificates(p *Plan, useExistingCA bool) error
 	RunSmokeTest(*Plan) error
@@ -36,9 +40,12 @@ type Executor interface {
 	RunPlay(name string, plan *Plan, restartServices bool, nodes ...string) error
 	AddVolume(*Plan, StorageVolume) error
 	DeleteVolume(*Plan, string) error
-	UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool) error
+	UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool, gate UpgradeGate) error
 	ValidateControlPlane(plan Plan) error
 	UpgradeClusterServices(plan Plan) error
+	SnapshotEtcd(plan *Plan) (*EtcdSnapshot, error)
+	BackupEtcd(plan *Plan) (*EtcdSnapshot, error)
+	RestoreEtcd(plan *Plan, snapshot EtcdSnapshot) error
 }
 
 // DiagnosticsExecutor will run diagnostics on the nodes after an install
@@ -51,7 +58,8 @@ type ExecutorOptions struct {
 	// GeneratedAssetsDirectory is the location where generated assets
 	// are to be stored
 	GeneratedAssetsDirectory string
-	// OutputFormat sets the format of the executor
+	// OutputFormat sets the format of the executor. When set to "json", the ansible
+	// events are written to stdout as JSON, and all other messages to errOut.
 	OutputFormat string
 	// Verbose output from the executor
 	Verbose bool
@@ -59,8 +67,13 @@ type ExecutorOptions struct {
 	RunsDirectory string
 	// DiagnosticsDirecty is where the doDiagnostics information about the cluster will be dumped
 	DiagnosticsDirecty string
-	// DryRun determines if the executor should actually run the task
+	// DryRun determines if the executor should actually run the task. When set, the
+	// inventory and cluster catalog of each task are written to a dry-run directory
+	// in the runs directory, along with the list of playbooks that would be run.
 	DryRun bool
+	// Context stops the runs of the executor when it is done, such as when the command
+	// is interrupted or times out. The runs are not stopped if it is not set.
+	Context context.Context
 }
 
 // NewExecutor returns an executor for performing installations according to the installation plan.
@@ -70,18 +83,16 @@ func NewExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (E
 		return nil, fmt.Errorf("GeneratedAssetsDirectory option cannot be empty")
 	}
 	if options.RunsDirectory == "" {
-		options.RunsDirectory = "./runs"
+		options.RunsDirectory = DefaultRunsDirectory
 	}
 
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, eventsOut, err := consoleOutput(options.OutputFormat, stdout)
+	if err != nil {
+		return nil, err
+	}
+	if eventsOut != nil {
+		stdout = errOut
 	}
 	certsDir := filepath.Join(options.GeneratedAssetsDirectory, "keys")
 	pki := &LocalPKI{
@@ -93,6 +104,7 @@ func NewExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (E
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		eventsOut:           eventsOut,
 		ansibleDir:          ansibleDir,
 		certsDir:            certsDir,
 		pki:                 pki,
@@ -103,23 +115,22 @@ func NewExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (E
 func NewPreFlightExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (PreFlightExecutor, error) {
 	ansibleDir := "ansible"
 	if options.RunsDirectory == "" {
-		options.RunsDirectory = "./runs"
+		options.RunsDirectory = DefaultRunsDirectory
 	}
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, eventsOut, err := consoleOutput(options.OutputFormat, stdout)
+	if err != nil {
+		return nil, err
+	}
+	if eventsOut != nil {
+		stdout = errOut
 	}
 
 	return &ansibleExecutor{
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		eventsOut:           eventsOut,
 		ansibleDir:          ansibleDir,
 	}, nil
 }
@@ -128,7 +139,7 @@ func NewPreFlightExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOp
 func NewDiagnosticsExecutor(stdout io.Writer, errOut io.Writer, options ExecutorOptions) (DiagnosticsExecutor, error) {
 	ansibleDir := "ansible"
 	if options.RunsDirectory == "" {
-		options.RunsDirectory = "./runs"
+		options.RunsDirectory = DefaultRunsDirectory
 	}
 	if options.DiagnosticsDirecty == "" {
 		wd, err := os.Getwd()
@@ -139,24 +150,38 @@ func NewDiagnosticsExecutor(stdout io.Writer, errOut io.Writer, options Executor
 	}
 
 	// Setup the console output format
-	var outFormat ansible.OutputFormat
-	switch options.OutputFormat {
-	case "raw":
-		outFormat = ansible.RawFormat
-	case "simple":
-		outFormat = ansible.JSONLinesFormat
-	default:
-		return nil, fmt.Errorf("Output format %q is not supported", options.OutputFormat)
+	outFormat, eventsOut, err := consoleOutput(options.OutputFormat, stdout)
+	if err != nil {
+		return nil, err
+	}
+	if eventsOut != nil {
+		stdout = errOut
 	}
 
 	return &ansibleExecutor{
 		options:             options,
 		stdout:              stdout,
 		consoleOutputFormat: outFormat,
+		eventsOut:           eventsOut,
 		ansibleDir:          ansibleDir,
 	}, nil
 }
 
+// consoleOutput returns the format of the ansible output on the console, and the
+// writer for the JSON events when the output format is "json"
+func consoleOutput(format string, stdout io.Writer) (ansible.OutputFormat, io.Writer, error) {
+	switch format {
+	case "raw":
+		return ansible.RawFormat, nil, nil
+	case "simple":
+		return ansible.JSONLinesFormat, nil, nil
+	case "json":
+		return ansible.JSONLinesFormat, stdout, nil
+	default:
+		return "", nil, fmt.Errorf("Output format %q is not supported", format)
+	}
+}
+
 type ansibleExecutor struct {
 	options             ExecutorOptions
 	stdout              io.Writer
@@ -165,6 +190,16 @@ type ansibleExecutor struct {
 	certsDir            string
 	pki                 PKI
 
+	// eventsOut is where the ansible events are written as JSON, if set
+	eventsOut io.Writer
+
+	// python is the interpreter that runs ansible, found before the first run
+	python *ansible.Python
+
+	// the directory of the dry-run, and the number of tasks it recorded
+	dryRunDirectory string
+	dryRunSteps     int
+
 	// Hook for testing purposes.. default implementation is used at runtime
 	runnerExplainerFactory func(explain.AnsibleEventExplainer, io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error)
 }
@@ -184,29 +219,54 @@ type task struct {
 	plan Plan
 	// run the task on specific nodes
 	limit []string
+	// the plays completed by a previous run that is resumed by the task
+	completedPlays []string
+	// print the slowest tasks and hosts at the end of the run
+	printTiming bool
 }
 
 // execute will run the given task, and setup all what's needed for us to run ansible.
 func (ae *ansibleExecutor) execute(t task) error {
 	if ae.options.DryRun {
-		return nil
+		return ae.dryRun(t)
 	}
 	runDirectory, err := ae.createRunDirectory(t.name)
 	if err != nil {
 		return fmt.Errorf("error creating working directory for %q: %v", t.name, err)
 	}
-	// Save the plan file that was used for this execution
+	// Save the plan file that was used for this execution, without the secrets
 	fp := FilePlanner{
-		File: filepath.Join(runDirectory, "kismatic-cluster.yaml"),
+		File: filepath.Join(runDirectory, runPlanFilename),
 	}
-	if err = fp.Write(&t.plan); err != nil {
+	if err = fp.Write(redactedPlan(&t.plan)); err != nil {
 		return fmt.Errorf("error recording plan file to %s: %v", fp.File, err)
 	}
+	if err = writeRunChecksum(runDirectory, &t.plan, t.limit); err != nil {
+		return err
+	}
+	checkpoint, err := newPlayCheckpoint(runDirectory, t.completedPlays)
+	if err != nil {
+		return err
+	}
+	recorder := newRunRecorder(t)
+	timer := newRunTimer()
+	// the timing of every run is recorded, but only printed when the task asks for it
+	var timingOut io.Writer
+	if t.printTiming {
+		timingOut = ae.stdout
+	}
 	ansibleLogFilename := filepath.Join(runDirectory, "ansible.log")
 	ansibleLogFile, err := os.Create(ansibleLogFilename)
 	if err != nil {
 		return fmt.Errorf("error creating ansible log file %q: %v", ansibleLogFilename, err)
 	}
+	// Verify the host keys of the nodes against the known_hosts file
+	if t.inventory.KnownHostsFile, err = ssh.KnownHostsFile(); err != nil {
+		return fmt.Errorf("error getting known_hosts file: %v", err)
+	}
+	if err = t.plan.ImportKnownHosts(); err != nil {
+		return err
+	}
 	runner, explainer, err := ae.ansibleRunnerWithExplainer(t.explainer, ansibleLogFile, runDirectory)
 	if err != nil {
 		return err
@@ -215,22 +275,46 @@ func (ae *ansibleExecutor) execute(t task) error {
 	// Start running ansible with the given playbook
 	var eventStream <-chan ansible.Event
 	if t.limit != nil && len(t.limit) != 0 {
-		eventStream, err = runner.StartPlaybookOnNode(t.playbook, t.inventory, t.clusterCatalog, t.limit...)
+		eventStream, err = runner.StartPlaybookOnNode(ae.context(), t.playbook, t.inventory, t.clusterCatalog, t.limit...)
 	} else {
-		eventStream, err = runner.StartPlaybook(t.playbook, t.inventory, t.clusterCatalog)
+		eventStream, err = runner.StartPlaybook(ae.context(), t.playbook, t.inventory, t.clusterCatalog)
 	}
 	if err != nil {
 		return fmt.Errorf("error running ansible playbook: %v", err)
 	}
 	// Ansible blocks until explainer starts reading from stream. Start
 	// explainer in a separate go routine
-	go explainer.Explain(eventStream)
-
-	// Wait until ansible exits
-	if err = runner.WaitPlaybook(); err != nil {
+	explained := make(chan struct{})
+	go func() {
+		explainer.Explain(timer.watch(recorder.watch(checkpoint.watch(eventStream))))
+		close(explained)
+	}()
+
+	// Wait until ansible exits, and until all of its events went through the watchers,
+	// before recording the run in the run directory
+	err = runner.WaitPlaybook(ae.context())
+	<-explained
+	if err != nil {
+		outcome := runOutcomeFailure
+		if ae.context().Err() != nil {
+			outcome = runOutcomeAborted
+		}
+		// the playbook error is more relevant than a failure to record the outcome
+		timer.write(timingOut, runDirectory)
+		recorder.write(runDirectory, outcome)
+		writeRunOutcome(runDirectory, outcome)
+		if outcome == runOutcomeAborted {
+			return fmt.Errorf("the run %q was aborted: %v", ae.runID(runDirectory), err)
+		}
 		return fmt.Errorf("error running playbook: %v", err)
 	}
-	return nil
+	if err = timer.write(timingOut, runDirectory); err != nil {
+		return err
+	}
+	if err = recorder.write(runDirectory, runOutcomeSuccess); err != nil {
+		return err
+	}
+	return writeRunOutcome(runDirectory, runOutcomeSuccess)
 }
 
 // GenerateCertificatesprivate generates keys and certificates for the cluster, if needed
@@ -297,6 +381,7 @@ func (ae *ansibleExecutor) Install(p *Plan, restartServices bool, nodes ...strin
 		clusterCatalog: *cc,
 		explainer:      ae.defaultExplainer(),
 		limit:          nodes,
+		printTiming:    true,
 	}
 	util.PrintHeader(ae.stdout, "Installing Cluster", '=')
 	return ae.execute(t)
@@ -516,63 +601,23 @@ func (ae *ansibleExecutor) DeleteVolume(plan *Plan, name string) error {
 // When a node is being upgraded, all the components of the node are upgraded, regardless of
 // which phase of the upgrade we are in. For example, when upgrading a node that is both an etcd and master,
 // the etcd components and the master components will be upgraded when we are in the upgrade etcd nodes
-// phase.
-func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool) error {
-	// Nodes can have multiple roles. For this reason, we need to keep track of which nodes
-	// have been upgraded to avoid re-upgrading them.
-	upgradedNodes := map[string]bool{}
-	// Upgrade etcd nodes
-	for _, nodeToUpgrade := range nodesToUpgrade {
-		for _, role := range nodeToUpgrade.Roles {
-			if role == "etcd" {
-				node := nodeToUpgrade
-				if err := ae.upgradeNodes(plan, onlineUpgrade, restartServices, node); err != nil {
-					return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
-				}
-				upgradedNodes[node.Node.IP] = true
-				break
-			}
-		}
-	}
-
-	// Upgrade master nodes
-	for _, nodeToUpgrade := range nodesToUpgrade {
-		if upgradedNodes[nodeToUpgrade.Node.IP] == true {
-			continue
+// phase. The nodes are upgraded in batches. When a gate is given, a single worker node is
+// upgraded first, and the gate is called after each batch of worker nodes. The gate is not
+// called in a dry-run, as the nodes are not upgraded.
+func (ae *ansibleExecutor) UpgradeNodes(plan Plan, nodesToUpgrade []ListableNode, onlineUpgrade bool, maxParallelWorkers int, restartServices bool, gate UpgradeGate) error {
+	for _, batch := range upgradeBatches(nodesToUpgrade, maxParallelWorkers, gate != nil) {
+		if err := ae.upgradeNodes(plan, onlineUpgrade, restartServices, batch.nodes...); err != nil {
+			return fmt.Errorf("error upgrading node %q: %v", batch.nodes[len(batch.nodes)-1].Node.Host, err)
 		}
-		for _, role := range nodeToUpgrade.Roles {
-			if role == "master" {
-				node := nodeToUpgrade
-				if err := ae.upgradeNodes(plan, onlineUpgrade, restartServices, node); err != nil {
-					return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
-				}
-				upgradedNodes[node.Node.IP] = true
-				break
-			}
-		}
-	}
-
-	var limitNodes []ListableNode
-	// Upgrade the rest of the nodes
-	for n, nodeToUpgrade := range nodesToUpgrade {
-		if upgradedNodes[nodeToUpgrade.Node.IP] == true {
+		if gate == nil || batch.phase != UpgradePhaseWorker || ae.options.DryRun {
 			continue
 		}
-		for _, role := range nodeToUpgrade.Roles {
-			if role != "etcd" && role != "master" {
-				node := nodeToUpgrade
-				limitNodes = append(limitNodes, node)
-				// don't forget to run the remaining nodes if its < maxParallelWorkers
-				if len(limitNodes) == maxParallelWorkers || n == len(nodesToUpgrade)-1 {
-					if err := ae.upgradeNodes(plan, onlineUpgrade, restartServices, limitNodes...); err != nil {
-						return fmt.Errorf("error upgrading node %q: %v", node.Node.Host, err)
-					}
-					// empty the slice
-					limitNodes = limitNodes[:0]
-				}
-				upgradedNodes[node.Node.IP] = true
-				break
+		if err := gate(batch.nodes); err != nil {
+			var hosts []string
+			for _, n := range batch.nodes {
+				hosts = append(hosts, n.Node.Host)
 			}
+			return fmt.Errorf("the upgrade was stopped after upgrading %s: %v", strings.Join(hosts, ", "), err)
 		}
 	}
 	return nil
@@ -602,6 +647,7 @@ func (ae *ansibleExecutor) upgradeNodes(plan Plan, onlineUpgrade bool, restartSe
 		plan:           plan,
 		explainer:      ae.defaultExplainer(),
 		limit:          limit,
+		printTiming:    true,
 	}
 	if len(limit) == 1 {
 		util.PrintHeader(ae.stdout, fmt.Sprintf("Upgrade Node: %s %s", limit, nodes[0].Roles), '=')
@@ -875,16 +921,32 @@ func (ae *ansibleExecutor) buildClusterCatalog(p *Plan) (*ansible.ClusterCatalog
 	return &cc, nil
 }
 
+// createRunDirectory creates the directory of a new run. Runs never share a directory:
+// when a run of the same name was started in the same second, the directory of the new run
+// is named after the next free second.
 func (ae *ansibleExecutor) createRunDirectory(runName string) (string, error) {
-	start := time.Now()
-	runDirectory := filepath.Join(ae.options.RunsDirectory, runName, start.Format("2006-01-02-15-04-05"))
-	if err := os.MkdirAll(runDirectory, 0777); err != nil {
+	parent := filepath.Join(ae.options.RunsDirectory, runName)
+	if err := os.MkdirAll(parent, 0777); err != nil {
 		return "", fmt.Errorf("error creating directory: %v", err)
 	}
-	return runDirectory, nil
+	start := time.Now()
+	for {
+		runDirectory := filepath.Join(parent, start.Format(runTimestampFormat))
+		err := os.Mkdir(runDirectory, 0777)
+		if err == nil {
+			return runDirectory, nil
+		}
+		if !os.IsExist(err) {
+			return "", fmt.Errorf("error creating directory: %v", err)
+		}
+		start = start.Add(time.Second)
+	}
 }
 
 func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleEventExplainer, ansibleLog io.Writer, runDirectory string) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+	if ae.eventsOut != nil {
+		explainer = explain.JSONExplainer(ae.runID(runDirectory), ae.eventsOut)
+	}
 	if ae.runnerExplainerFactory != nil {
 		return ae.runnerExplainerFactory(explainer, ansibleLog)
 	}
@@ -898,8 +960,21 @@ func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleE
 		ansibleOut = io.MultiWriter(ae.stdout, timestampWriter(ansibleLog))
 	}
 
+	// The python interpreter is found once, and fails the first run when it cannot run ansible
+	if ae.python == nil {
+		python, err := ansible.FindPython(ae.ansibleDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		ae.python = python
+	}
+	// the raw output already reports the interpreter, as the runner prints it
+	if ae.consoleOutputFormat != ansible.RawFormat {
+		fmt.Fprintf(ae.stdout, "Running %s\n", ae.python)
+	}
+
 	// Send stdout and stderr to ansibleOut
-	runner, err := ansible.NewRunner(ansibleOut, ansibleOut, ae.ansibleDir, runDirectory)
+	runner, err := ansible.NewRunner(ansibleOut, ansibleOut, ae.ansibleDir, runDirectory, ae.python)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error creating ansible runner: %v", err)
 	}
@@ -911,6 +986,23 @@ func (ae *ansibleExecutor) ansibleRunnerWithExplainer(explainer explain.AnsibleE
 	return runner, streamExplainer, nil
 }
 
+// context returns the context that stops the runs of the executor
+func (ae *ansibleExecutor) context() context.Context {
+	if ae.options.Context == nil {
+		return context.Background()
+	}
+	return ae.options.Context
+}
+
+// runID returns the identifier of the run, the path of its directory within the runs directory
+func (ae *ansibleExecutor) runID(runDirectory string) string {
+	id, err := filepath.Rel(ae.options.RunsDirectory, runDirectory)
+	if err != nil {
+		return filepath.Base(runDirectory)
+	}
+	return filepath.ToSlash(id)
+}
+
 func (ae *ansibleExecutor) defaultExplainer() explain.AnsibleEventExplainer {
 	var out io.Writer
 	switch ae.consoleOutputFormat {
@@ -936,26 +1028,26 @@ func (ae *ansibleExecutor) preflightExplainer() explain.AnsibleEventExplainer {
 func buildInventoryFromPlan(p *Plan) ansible.Inventory {
 	etcdNodes := []ansible.Node{}
 	for _, n := range p.Etcd.Nodes {
-		etcdNodes = append(etcdNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+		etcdNodes = append(etcdNodes, installNodeToAnsibleNode(&n, p.nodeSSHConfig(p.Etcd.SSH, n)))
 	}
 	masterNodes := []ansible.Node{}
 	for _, n := range p.Master.Nodes {
-		masterNodes = append(masterNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+		masterNodes = append(masterNodes, installNodeToAnsibleNode(&n, p.nodeSSHConfig(p.Master.SSH, n)))
 	}
 	workerNodes := []ansible.Node{}
 	for _, n := range p.Worker.Nodes {
-		workerNodes = append(workerNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+		workerNodes = append(workerNodes, installNodeToAnsibleNode(&n, p.nodeSSHConfig(p.Worker.SSH, n)))
 	}
 	ingressNodes := []ansible.Node{}
 	if p.Ingress.Nodes != nil {
 		for _, n := range p.Ingress.Nodes {
-			ingressNodes = append(ingressNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+			ingressNodes = append(ingressNodes, installNodeToAnsibleNode(&n, p.nodeSSHConfig(p.Ingress.SSH, n)))
 		}
 	}
 	storageNodes := []ansible.Node{}
 	if p.Storage.Nodes != nil {
 		for _, n := range p.Storage.Nodes {
-			storageNodes = append(storageNodes, installNodeToAnsibleNode(&n, &p.Cluster.SSH))
+			storageNodes = append(storageNodes, installNodeToAnsibleNode(&n, p.nodeSSHConfig(p.Storage.SSH, n)))
 		}
 	}
 
@@ -988,8 +1080,8 @@ func buildInventoryFromPlan(p *Plan) ansible.Inventory {
 }
 
 // Converts plan node to ansible node
-func installNodeToAnsibleNode(n *Node, s *SSHConfig) ansible.Node {
-	return ansible.Node{
+func installNodeToAnsibleNode(n *Node, s SSHConfig) ansible.Node {
+	node := ansible.Node{
 		Host:          n.Host,
 		PublicIP:      n.IP,
 		InternalIP:    n.InternalIP,
@@ -997,6 +1089,15 @@ func installNodeToAnsibleNode(n *Node, s *SSHConfig) ansible.Node {
 		SSHUser:       s.User,
 		SSHPort:       s.Port,
 	}
+	if s.Bastion != nil {
+		node.SSHBastion = &ansible.Bastion{
+			Host:          s.Bastion.Host,
+			SSHPrivateKey: s.Bastion.Key,
+			SSHUser:       s.Bastion.User,
+			SSHPort:       s.Bastion.Port,
+		}
+	}
+	return node
 }
 
 // Prepend each line of the incoming stream with a timestamp
@@ -0,0 +1,137 @@
+package explain
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+// JSONEventVersion is the version of the JSONEvent schema. Fields are only added to
+// the schema, and the version changes when existing fields are changed or removed.
+const JSONEventVersion = 1
+
+// The types of the JSON events
+const (
+	JSONPlaybookStart    = "playbook_start"
+	JSONPlaybookEnd      = "playbook_end"
+	JSONPlayStart        = "play_start"
+	JSONTaskStart        = "task_start"
+	JSONHandlerStart     = "handler_start"
+	JSONHostOK           = "host_ok"
+	JSONHostFailed       = "host_failed"
+	JSONHostSkipped      = "host_skipped"
+	JSONHostUnreachable  = "host_unreachable"
+	JSONHostItemOK       = "host_item_ok"
+	JSONHostItemFailed   = "host_item_failed"
+	JSONHostItemRetrying = "host_item_retrying"
+)
+
+// JSONEvent is the JSON representation of an ansible event. The playbook, play and
+// task are those that were running when the event was produced.
+type JSONEvent struct {
+	Version   int    `json:"version"`
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	RunID     string `json:"run_id"`
+	Playbook  string `json:"playbook,omitempty"`
+	Play      string `json:"play,omitempty"`
+	Task      string `json:"task,omitempty"`
+	Host      string `json:"host,omitempty"`
+	// PlayCount is the number of plays of the playbook, set on playbook_start
+	PlayCount    int    `json:"play_count,omitempty"`
+	Message      string `json:"message,omitempty"`
+	Stdout       string `json:"stdout,omitempty"`
+	Stderr       string `json:"stderr,omitempty"`
+	Item         string `json:"item,omitempty"`
+	Attempt      int    `json:"attempt,omitempty"`
+	MaxRetries   int    `json:"max_retries,omitempty"`
+	IgnoreErrors bool   `json:"ignore_errors,omitempty"`
+}
+
+type jsonExplainer struct {
+	out      *json.Encoder
+	runID    string
+	now      func() time.Time
+	playbook string
+	play     string
+	task     string
+}
+
+// JSONExplainer returns an explainer that writes one JSON object per line for
+// each ansible event of the run
+func JSONExplainer(runID string, out io.Writer) AnsibleEventExplainer {
+	return &jsonExplainer{
+		out:   json.NewEncoder(out),
+		runID: runID,
+		now:   time.Now,
+	}
+}
+
+// ExplainEvent writes the JSON representation of the ansible event. The timestamp is the
+// time the event was produced when it is known, such as when a recorded run is replayed.
+func (explainer *jsonExplainer) ExplainEvent(e ansible.Event) {
+	timestamp := explainer.now()
+	if te, ok := e.(ansible.TimedEvent); ok && !te.Time().IsZero() {
+		timestamp = te.Time()
+	}
+	je := JSONEvent{
+		Version:   JSONEventVersion,
+		Timestamp: timestamp.UTC().Format(time.RFC3339Nano),
+		RunID:     explainer.runID,
+	}
+	switch event := e.(type) {
+	case *ansible.PlaybookStartEvent:
+		explainer.playbook, explainer.play, explainer.task = event.Name, "", ""
+		je.Type = JSONPlaybookStart
+		je.PlayCount = event.Count
+	case *ansible.PlaybookEndEvent:
+		explainer.play, explainer.task = "", ""
+		je.Type = JSONPlaybookEnd
+	case *ansible.PlayStartEvent:
+		explainer.play, explainer.task = event.Name, ""
+		je.Type = JSONPlayStart
+	case *ansible.TaskStartEvent:
+		explainer.task = event.Name
+		je.Type = JSONTaskStart
+	case *ansible.HandlerTaskStartEvent:
+		explainer.task = event.Name
+		je.Type = JSONHandlerStart
+	case *ansible.RunnerOKEvent:
+		je.Type = JSONHostOK
+		je.Host = event.Host
+	case *ansible.RunnerFailedEvent:
+		je.Type = JSONHostFailed
+		je.Host, je.IgnoreErrors = event.Host, event.IgnoreErrors
+		je.Message, je.Stdout, je.Stderr = event.Result.Message, event.Result.Stdout, event.Result.Stderr
+		je.Item, je.Attempt, je.MaxRetries = event.Result.Item, event.Result.Attempts, event.Result.MaxRetries
+	case *ansible.RunnerSkippedEvent:
+		je.Type = JSONHostSkipped
+		je.Host = event.Host
+	case *ansible.RunnerUnreachableEvent:
+		je.Type = JSONHostUnreachable
+		je.Host, je.IgnoreErrors = event.Host, event.IgnoreErrors
+		je.Message, je.Stdout, je.Stderr = event.Result.Message, event.Result.Stdout, event.Result.Stderr
+		je.Item, je.Attempt, je.MaxRetries = event.Result.Item, event.Result.Attempts, event.Result.MaxRetries
+	case *ansible.RunnerItemOKEvent:
+		je.Type = JSONHostItemOK
+		je.Host = event.Host
+		je.Item = event.Result.Item
+	case *ansible.RunnerItemFailedEvent:
+		je.Type = JSONHostItemFailed
+		je.Host, je.IgnoreErrors = event.Host, event.IgnoreErrors
+		je.Message, je.Stdout, je.Stderr = event.Result.Message, event.Result.Stdout, event.Result.Stderr
+		je.Item, je.Attempt, je.MaxRetries = event.Result.Item, event.Result.Attempts, event.Result.MaxRetries
+	case *ansible.RunnerItemRetryEvent:
+		je.Type = JSONHostItemRetrying
+		je.Host, je.IgnoreErrors = event.Host, event.IgnoreErrors
+		je.Message, je.Stdout, je.Stderr = event.Result.Message, event.Result.Stdout, event.Result.Stderr
+		je.Item, je.Attempt, je.MaxRetries = event.Result.Item, event.Result.Attempts, event.Result.MaxRetries
+	default:
+		// events that are not part of the schema are not written
+		return
+	}
+	je.Playbook, je.Play, je.Task = explainer.playbook, explainer.play, explainer.task
+	explainer.out.Encode(je)
+}
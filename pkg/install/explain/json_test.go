@@ -0,0 +1,46 @@
+package explain
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+func TestJSONExplainer(t *testing.T) {
+	out := &bytes.Buffer{}
+	explainer := JSONExplainer("apply/2018-06-01-10-00-00", out).(*jsonExplainer)
+	explainer.now = func() time.Time { return time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC) }
+
+	playbook := &ansible.PlaybookStartEvent{Count: 2}
+	playbook.Name = "kubernetes.yaml"
+	play := &ansible.PlayStartEvent{}
+	play.Name = "Install etcd"
+	task := &ansible.TaskStartEvent{}
+	task.Name = "start etcd"
+	ok := &ansible.RunnerOKEvent{}
+	ok.Host = "etcd01"
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "etcd02"
+	failed.Result.Message = "etcd did not start"
+	failed.Result.Stderr = "timeout"
+	unreachable := &ansible.RunnerUnreachableEvent{}
+	unreachable.Host = "etcd03"
+	for _, e := range []ansible.Event{playbook, play, task, ok, failed, unreachable, &ansible.PlaybookEndEvent{}} {
+		explainer.ExplainEvent(e)
+	}
+
+	// the schema is stable, and must not change across releases
+	expected := `{"version":1,"type":"playbook_start","timestamp":"2018-06-01T10:00:00Z","run_id":"apply/2018-06-01-10-00-00","playbook":"kubernetes.yaml","play_count":2}
+{"version":1,"type":"play_start","timestamp":"2018-06-01T10:00:00Z","run_id":"apply/2018-06-01-10-00-00","playbook":"kubernetes.yaml","play":"Install etcd"}
+{"version":1,"type":"task_start","timestamp":"2018-06-01T10:00:00Z","run_id":"apply/2018-06-01-10-00-00","playbook":"kubernetes.yaml","play":"Install etcd","task":"start etcd"}
+{"version":1,"type":"host_ok","timestamp":"2018-06-01T10:00:00Z","run_id":"apply/2018-06-01-10-00-00","playbook":"kubernetes.yaml","play":"Install etcd","task":"start etcd","host":"etcd01"}
+{"version":1,"type":"host_failed","timestamp":"2018-06-01T10:00:00Z","run_id":"apply/2018-06-01-10-00-00","playbook":"kubernetes.yaml","play":"Install etcd","task":"start etcd","host":"etcd02","message":"etcd did not start","stderr":"timeout"}
+{"version":1,"type":"host_unreachable","timestamp":"2018-06-01T10:00:00Z","run_id":"apply/2018-06-01-10-00-00","playbook":"kubernetes.yaml","play":"Install etcd","task":"start etcd","host":"etcd03"}
+{"version":1,"type":"playbook_end","timestamp":"2018-06-01T10:00:00Z","run_id":"apply/2018-06-01-10-00-00","playbook":"kubernetes.yaml"}
+`
+	if out.String() != expected {
+		t.Errorf("expected:\n%s\nbut got:\n%s", expected, out.String())
+	}
+}
@@ -0,0 +1,48 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// PlanLock prevents concurrent operations against the same plan file
+type PlanLock struct {
+	file *os.File
+}
+
+// LockPlanFile acquires the lock of the plan file, which is held until it is unlocked or
+// the process exits. It fails when another process holds the lock.
+func LockPlanFile(planFile string) (*PlanLock, error) {
+	lockFile := planFile + ".lock"
+	f, err := os.OpenFile(lockFile, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error opening lock file %q: %v", lockFile, err)
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		holder, _ := ioutil.ReadAll(f)
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, fmt.Errorf("another operation is running against the plan file %q (%s). Wait for it to complete, and try again", planFile, strings.TrimSpace(string(holder)))
+		}
+		return nil, fmt.Errorf("error locking plan file %q: %v", planFile, err)
+	}
+	// record the process that holds the lock, so that it can be reported to other processes
+	if err = f.Truncate(0); err == nil {
+		_, err = fmt.Fprintf(f, "pid %d: %s\n", os.Getpid(), strings.Join(os.Args, " "))
+	}
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error writing lock file %q: %v", lockFile, err)
+	}
+	return &PlanLock{file: f}, nil
+}
+
+// Unlock releases the lock of the plan file. The lock file is not removed, as another
+// process might be waiting on it.
+func (l *PlanLock) Unlock() error {
+	l.file.Truncate(0)
+	return l.file.Close()
+}
@@ -0,0 +1,42 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLockPlanFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lock-plan-file")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	planFile := filepath.Join(dir, "kismatic-cluster.yaml")
+
+	lock, err := LockPlanFile(planFile)
+	if err != nil {
+		t.Fatalf("unexpected error locking the plan file: %v", err)
+	}
+	if _, err = LockPlanFile(planFile); err == nil || !strings.Contains(err.Error(), "another operation is running") {
+		t.Errorf("expected an error locking the plan file twice, got: %v", err)
+	}
+	// the lock of another plan file is independent
+	other, err := LockPlanFile(filepath.Join(dir, "other.yaml"))
+	if err != nil {
+		t.Errorf("unexpected error locking another plan file: %v", err)
+	} else {
+		other.Unlock()
+	}
+
+	if err = lock.Unlock(); err != nil {
+		t.Errorf("unexpected error unlocking the plan file: %v", err)
+	}
+	lock, err = LockPlanFile(planFile)
+	if err != nil {
+		t.Fatalf("unexpected error locking the plan file after it was unlocked: %v", err)
+	}
+	lock.Unlock()
+}
@@ -48,13 +48,19 @@ type Planner interface {
 // FilePlanner is a file-based installation planner
 type FilePlanner struct {
 	File string
+	// Overlays are plan files that are merged on top of File, in order.
+	// A plan that is composed of overlays cannot be written back.
+	Overlays []string
+	// KeepSecretRefs reads the secret references of the plan without resolving them, such
+	// as for the plans recorded in the runs directory, whose secrets might not exist anymore.
+	KeepSecretRefs bool
 }
 
 // Read the plan from the file system
 func (fp *FilePlanner) Read() (*Plan, error) {
-	d, err := ioutil.ReadFile(fp.File)
+	d, err := fp.readPlanFiles()
 	if err != nil {
-		return nil, fmt.Errorf("could not read file: %v", err)
+		return nil, err
 	}
 
 	p := &Plan{}
@@ -62,8 +68,17 @@ func (fp *FilePlanner) Read() (*Plan, error) {
 		return nil, fmt.Errorf("failed to unmarshal plan: %v", err)
 	}
 
-	// read deprecated fields and set it the new version of the cluster file
-	readDeprecatedFields(p)
+	// migrate fields from previous plan versions in memory,
+	// the plan file is only rewritten by the "plan migrate" command
+	if _, err = MigratePlan(p); err != nil {
+		return nil, err
+	}
+
+	if fp.KeepSecretRefs {
+		keepSecretRefs(p)
+	} else if err = resolveSecretRefs(p); err != nil {
+		return nil, err
+	}
 
 	// set nil values to defaults
 	setDefaults(p)
@@ -71,50 +86,34 @@ func (fp *FilePlanner) Read() (*Plan, error) {
 	return p, nil
 }
 
-func readDeprecatedFields(p *Plan) {
-	// set load_balancer from fqdn:6443
-	// set extra_sans from short_name
-	if p.Master.LoadBalancer == "" {
-		if p.Master.LoadBalancedShortName != nil && *p.Master.LoadBalancedShortName != "" {
-			if p.Cluster.Certificates.APIServerCertExtraSANs != "" {
-				p.Cluster.Certificates.APIServerCertExtraSANs = p.Cluster.Certificates.APIServerCertExtraSANs + ","
-			}
-			p.Cluster.Certificates.APIServerCertExtraSANs = p.Cluster.Certificates.APIServerCertExtraSANs + *p.Master.LoadBalancedFQDN
-		}
-		if p.Master.LoadBalancedFQDN != nil && *p.Master.LoadBalancedFQDN != "" {
-			p.Master.LoadBalancer = *p.Master.LoadBalancedFQDN + ":6443"
-		}
-	}
-	// only set if not already being set by the user
-	// package_manager moved from features: to add_ons: after KET v1.3.3
-	if p.Features != nil && p.Features.PackageManager != nil {
-		p.AddOns.PackageManager.Disable = !p.Features.PackageManager.Enabled
-		// KET v1.3.3 did not have a provider field
-		p.AddOns.PackageManager.Provider = ket133PackageManagerProvider
+// Migrate rewrites the plan file using the current plan API version, and returns
+// the list of fields that were changed. Defaults are not applied to the plan, and the
+// file is left untouched if no field was changed.
+func (fp *FilePlanner) Migrate() ([]PlanFieldChange, error) {
+	if len(fp.Overlays) != 0 {
+		return nil, errors.New("plan files composed of overlays cannot be migrated, only the base plan file can be rewritten")
 	}
-	// allow_package_installation renamed to disable_package_installation after KET v1.4.0
-	if p.Cluster.AllowPackageInstallation != nil {
-		p.Cluster.DisablePackageInstallation = !*p.Cluster.AllowPackageInstallation
+	d, err := ioutil.ReadFile(fp.File)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %v", err)
 	}
 
-	if p.DockerRegistry.Server == "" && p.DockerRegistry.Address != "" && p.DockerRegistry.Port != 0 {
-		p.DockerRegistry.Server = fmt.Sprintf("%s:%d", p.DockerRegistry.Address, p.DockerRegistry.Port)
+	p := &Plan{}
+	if err = yaml.Unmarshal(d, p); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal plan: %v", err)
 	}
 
-	if p.Docker.Storage.DirectLVM != nil && p.Docker.Storage.DirectLVM.Enabled && (p.Docker.Storage.Opts == nil || len(p.Docker.Storage.Opts) == 0) {
-		p.Docker.Storage.Driver = "devicemapper"
-		p.Docker.Storage.Opts = map[string]string{
-			"dm.thinpooldev":           "/dev/mapper/docker-thinpool",
-			"dm.use_deferred_removal":  "true",
-			"dm.use_deferred_deletion": fmt.Sprintf("%t", p.Docker.Storage.DirectLVM.EnableDeferredDeletion),
-		}
-		p.Docker.Storage.DirectLVMBlockDevice.Path = p.Docker.Storage.DirectLVM.BlockDevice
-		p.Docker.Storage.DirectLVMBlockDevice.ThinpoolPercent = "95"
-		p.Docker.Storage.DirectLVMBlockDevice.ThinpoolMetaPercent = "1"
-		p.Docker.Storage.DirectLVMBlockDevice.ThinpoolAutoextendThreshold = "80"
-		p.Docker.Storage.DirectLVMBlockDevice.ThinpoolAutoextendPercent = "20"
-		p.Docker.Storage.DirectLVM = nil
+	changes, err := MigratePlan(p)
+	if err != nil {
+		return nil, err
 	}
+	if len(changes) == 0 {
+		return changes, nil
+	}
+	if err = fp.Write(p); err != nil {
+		return nil, err
+	}
+	return changes, nil
 }
 
 func setDefaults(p *Plan) {
@@ -162,10 +161,6 @@ func setDefaults(p *Plan) {
 		p.AddOns.CNI.Provider = cniProviderCalico
 		p.AddOns.CNI.Options.Calico.Mode = "overlay"
 		p.AddOns.CNI.Options.Calico.LogLevel = "info"
-		// read KET <v1.5.0 plan option
-		if p.Cluster.Networking.Type != "" {
-			p.AddOns.CNI.Options.Calico.Mode = p.Cluster.Networking.Type
-		}
 	}
 	if p.AddOns.CNI.Options.Calico.LogLevel == "" {
 		p.AddOns.CNI.Options.Calico.LogLevel = "info"
@@ -195,19 +190,12 @@ func setDefaults(p *Plan) {
 	if p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas == 0 {
 		p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas = 2
 	}
-	// read field from KET < v1.5.0
-	if p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas != 0 {
-		p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas = p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas
-	}
 	if p.AddOns.HeapsterMonitoring.Options.Heapster.Sink == "" {
 		p.AddOns.HeapsterMonitoring.Options.Heapster.Sink = "influxdb:http://heapster-influxdb.kube-system.svc:8086"
 	}
 	if p.AddOns.HeapsterMonitoring.Options.Heapster.ServiceType == "" {
 		p.AddOns.HeapsterMonitoring.Options.Heapster.ServiceType = "ClusterIP"
 	}
-	if p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName != "" {
-		p.AddOns.HeapsterMonitoring.Options.InfluxDB.PVCName = p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName
-	}
 
 	if p.Cluster.Certificates.CAExpiry == "" {
 		p.Cluster.Certificates.CAExpiry = defaultCAExpiry
@@ -221,18 +209,24 @@ func setDefaults(p *Plan) {
 		p.AddOns.PackageManager.Options.Helm.Namespace = "kube-system"
 	}
 
+	p.Cluster.SSH.setBastionDefaults()
+
 }
 
 var yamlKeyRE = regexp.MustCompile(`[^a-zA-Z]*([a-z_\-\/A-Z.\d]+)[ ]*:`)
 
 // Write the plan to the file system
 func (fp *FilePlanner) Write(p *Plan) error {
+	if len(fp.Overlays) != 0 {
+		return fmt.Errorf("cannot write a plan that is composed of overlays, update %q or its overlays instead", fp.File)
+	}
 	// make a copy of the global comment map
 	oneTimeComments := map[string][]string{}
 	for k, v := range commentMap {
 		oneTimeComments[k] = v
 	}
-	bytez, marshalErr := yaml.Marshal(p)
+	// the secrets that were resolved when the plan was read are not written to the file
+	bytez, marshalErr := yaml.Marshal(WithSecretRefs(p))
 	if marshalErr != nil {
 		return fmt.Errorf("error marshalling plan to yaml: %v", marshalErr)
 	}
@@ -357,6 +351,7 @@ func WritePlanTemplate(planTemplateOpts PlanTemplateOptions, w PlanReadWriter) e
 // template options
 func buildPlanFromTemplateOptions(templateOpts PlanTemplateOptions) Plan {
 	p := Plan{}
+	p.APIVersion = currentPlanAPIVersion
 	p.Cluster.Name = "kubernetes"
 	p.Cluster.Version = kubernetesVersionString
 	p.Cluster.AdminPassword = templateOpts.AdminPassword
@@ -494,6 +489,7 @@ var commentMap = map[string][]string{
 	"cluster.ssh":                                        []string{"SSH configuration for cluster nodes."},
 	"cluster.ssh.user":                                   []string{"This user must be able to sudo without password."},
 	"cluster.ssh.ssh_key":                                []string{"Absolute path to the ssh private key we should use to manage nodes."},
+	"cluster.ssh.bastion":                                []string{"Bastion host through which the nodes are accessed via SSH.", "The user and ssh_key default to the values above."},
 	"cluster.kube_apiserver":                             []string{"Override configuration of Kubernetes components."},
 	"cluster.cloud_provider":                             []string{"Kubernetes cloud provider integration."},
 	"cluster.cloud_provider.provider":                    []string{"Options: 'aws','azure','cloudstack','fake','gce','mesos','openstack',", "'ovirt','photon','rackspace','vsphere'.", "Leave empty for bare metal setups or other unsupported providers."},
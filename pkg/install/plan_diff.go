@@ -0,0 +1,217 @@
+package install
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// PlanDiff is the set of differences between two plans, grouped by their
+// impact on the cluster.
+type PlanDiff struct {
+	// Nodes that are only in the new plan
+	NodesAdded []PlanNodeDiff `json:"nodesAdded"`
+	// Nodes that are only in the old plan
+	NodesRemoved []PlanNodeDiff `json:"nodesRemoved"`
+	// Changes to the add-on configuration
+	AddOns []PlanFieldDiff `json:"addOns"`
+	// Changes to the option overrides of the cluster components
+	ComponentOptions []PlanFieldDiff `json:"componentOptions"`
+	// Changes that require the cluster certificates to be regenerated
+	Certificates []PlanFieldDiff `json:"certificates"`
+	// Any other change
+	Other []PlanFieldDiff `json:"other"`
+}
+
+// PlanNodeDiff is a node that was added to or removed from a node group
+type PlanNodeDiff struct {
+	// The node group of the node. For example: "worker"
+	Group string `json:"group"`
+	Host  string `json:"host"`
+	IP    string `json:"ip"`
+}
+
+// PlanFieldDiff is a plan file field that has a different value in each plan
+type PlanFieldDiff struct {
+	// The path of the field in the plan file. Nodes are identified by their
+	// host. For example: "worker.nodes[worker1].labels.zone"
+	Field string `json:"field"`
+	// The value in the old plan. Empty if the field was not set.
+	From string `json:"from"`
+	// The value in the new plan. Empty if the field was not set.
+	To string `json:"to"`
+}
+
+// Empty returns true if there are no differences between the plans
+func (d PlanDiff) Empty() bool {
+	return len(d.NodesAdded) == 0 && len(d.NodesRemoved) == 0 && len(d.AddOns) == 0 &&
+		len(d.ComponentOptions) == 0 && len(d.Certificates) == 0 && len(d.Other) == 0
+}
+
+// nodeGroupFields are the plan file fields that contain node groups
+var nodeGroupFields = []string{"etcd", "master", "worker", "ingress", "storage"}
+
+// DiffPlans returns the differences between the old and new plans.
+// Sensitive fields are compared using their secret references, as the plans
+// recorded in the runs directory do not contain the secrets.
+func DiffPlans(old, new *Plan) (*PlanDiff, error) {
+	oldFields, err := flattenPlan(redactedPlan(old))
+	if err != nil {
+		return nil, fmt.Errorf("error reading old plan: %v", err)
+	}
+	newFields, err := flattenPlan(redactedPlan(new))
+	if err != nil {
+		return nil, fmt.Errorf("error reading new plan: %v", err)
+	}
+	d := &PlanDiff{
+		NodesAdded:       nodesOnlyIn(new, old),
+		NodesRemoved:     nodesOnlyIn(old, new),
+		AddOns:           []PlanFieldDiff{},
+		ComponentOptions: []PlanFieldDiff{},
+		Certificates:     []PlanFieldDiff{},
+		Other:            []PlanFieldDiff{},
+	}
+	// the fields of added and removed nodes are reported as a whole
+	skip := map[string]bool{}
+	for _, n := range append(d.NodesAdded, d.NodesRemoved...) {
+		skip[nodeFieldPrefix(n.Group, n.Host)] = true
+	}
+
+	fields := map[string]bool{}
+	for f := range oldFields {
+		fields[f] = true
+	}
+	for f := range newFields {
+		fields[f] = true
+	}
+	sorted := make([]string, 0, len(fields))
+	for f := range fields {
+		sorted = append(sorted, f)
+	}
+	sort.Strings(sorted)
+
+	for _, f := range sorted {
+		from, to := oldFields[f], newFields[f]
+		if from == to || skipField(f, skip) {
+			continue
+		}
+		fd := PlanFieldDiff{Field: f, From: from, To: to}
+		switch {
+		case strings.HasPrefix(f, "add_ons."):
+			d.AddOns = append(d.AddOns, fd)
+		case strings.Contains(f, ".option_overrides."):
+			d.ComponentOptions = append(d.ComponentOptions, fd)
+		case affectsCertificates(f):
+			d.Certificates = append(d.Certificates, fd)
+		default:
+			d.Other = append(d.Other, fd)
+		}
+	}
+	return d, nil
+}
+
+func nodeFieldPrefix(group, host string) string {
+	return fmt.Sprintf("%s.nodes[%s].", group, host)
+}
+
+func skipField(field string, skip map[string]bool) bool {
+	i := strings.Index(field, "].")
+	if i == -1 {
+		return false
+	}
+	return skip[field[:i+2]]
+}
+
+// affectsCertificates returns true if the field is used to generate the
+// subject alternative names of the cluster certificates
+func affectsCertificates(field string) bool {
+	if strings.HasPrefix(field, "cluster.certificates.") {
+		return true
+	}
+	switch field {
+	case "master.load_balancer", "cluster.networking.service_cidr_block":
+		return true
+	}
+	return strings.HasSuffix(field, "].ip") || strings.HasSuffix(field, "].internalip")
+}
+
+// nodesOnlyIn returns the nodes of each node group of a that are not in the same group of b
+func nodesOnlyIn(a, b *Plan) []PlanNodeDiff {
+	nodes := []PlanNodeDiff{}
+	aGroups, bGroups := nodeGroups(a), nodeGroups(b)
+	for _, g := range nodeGroupFields {
+		hosts := map[string]bool{}
+		for _, n := range bGroups[g] {
+			hosts[n.Host] = true
+		}
+		for _, n := range aGroups[g] {
+			if !hosts[n.Host] {
+				nodes = append(nodes, PlanNodeDiff{Group: g, Host: n.Host, IP: n.IP})
+			}
+		}
+	}
+	return nodes
+}
+
+func nodeGroups(p *Plan) map[string][]Node {
+	return map[string][]Node{
+		"etcd":    p.Etcd.Nodes,
+		"master":  p.Master.Nodes,
+		"worker":  p.Worker.Nodes,
+		"ingress": p.Ingress.Nodes,
+		"storage": p.Storage.Nodes,
+	}
+}
+
+// flattenPlan returns the value of every field of the plan, keyed by the
+// path of the field in the plan file.
+func flattenPlan(p *Plan) (map[string]string, error) {
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	var m map[interface{}]interface{}
+	if err = yaml.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	fields := map[string]string{}
+	flattenValue(fields, "", m)
+	return fields, nil
+}
+
+func flattenValue(fields map[string]string, path string, value interface{}) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for k, child := range v {
+			key := fmt.Sprint(k)
+			if path != "" {
+				key = path + "." + key
+			}
+			flattenValue(fields, key, child)
+		}
+	case []interface{}:
+		if strings.HasSuffix(path, ".nodes") {
+			// key nodes by their host, so that re-ordering nodes is not a change
+			for _, n := range v {
+				node, ok := n.(map[interface{}]interface{})
+				if !ok {
+					continue
+				}
+				flattenValue(fields, fmt.Sprintf("%s[%v]", path, node["host"]), node)
+			}
+			return
+		}
+		if len(v) != 0 {
+			fields[path] = fmt.Sprint(v)
+		}
+	case nil:
+		return
+	default:
+		s := fmt.Sprint(v)
+		if s != "" {
+			fields[path] = s
+		}
+	}
+}
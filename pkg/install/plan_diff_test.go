@@ -0,0 +1,89 @@
+package install
+
+import (
+	"testing"
+)
+
+func diffTestPlan() *Plan {
+	p := buildPlanFromTemplateOptions(PlanTemplateOptions{
+		EtcdNodes:   1,
+		MasterNodes: 1,
+		WorkerNodes: 2,
+	})
+	p.Etcd.Nodes[0] = Node{Host: "etcd01", IP: "10.0.0.1"}
+	p.Master.Nodes[0] = Node{Host: "master01", IP: "10.0.0.2"}
+	p.Worker.Nodes[0] = Node{Host: "worker01", IP: "10.0.0.3"}
+	p.Worker.Nodes[1] = Node{Host: "worker02", IP: "10.0.0.4"}
+	return &p
+}
+
+func TestDiffPlansNoChanges(t *testing.T) {
+	old := diffTestPlan()
+	new := diffTestPlan()
+	// re-ordering the nodes is not a change
+	new.Worker.Nodes[0], new.Worker.Nodes[1] = new.Worker.Nodes[1], new.Worker.Nodes[0]
+	d, err := DiffPlans(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.Empty() {
+		t.Errorf("expected no differences, but got %+v", d)
+	}
+}
+
+func TestDiffPlansGroupsChanges(t *testing.T) {
+	old := diffTestPlan()
+	new := diffTestPlan()
+	new.Worker.Nodes = append(new.Worker.Nodes[1:], Node{Host: "worker03", IP: "10.0.0.5"})
+	new.Worker.Nodes[0].Labels = map[string]string{"zone": "a"}
+	new.Master.LoadBalancer = "lb.example.com:6443"
+	new.Cluster.Certificates.APIServerCertExtraSANs = "lb"
+	new.Cluster.APIServerOptions.Overrides = map[string]string{"v": "4"}
+	new.AddOns.Dashboard.Disable = !old.AddOns.Dashboard.Disable
+	new.Cluster.Name = "renamed"
+
+	d, err := DiffPlans(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.NodesAdded) != 1 || d.NodesAdded[0] != (PlanNodeDiff{Group: "worker", Host: "worker03", IP: "10.0.0.5"}) {
+		t.Errorf("expected worker03 to be added, but got %+v", d.NodesAdded)
+	}
+	if len(d.NodesRemoved) != 1 || d.NodesRemoved[0].Host != "worker01" {
+		t.Errorf("expected worker01 to be removed, but got %+v", d.NodesRemoved)
+	}
+	tests := []struct {
+		group  string
+		diffs  []PlanFieldDiff
+		fields []string
+	}{
+		{"add-ons", d.AddOns, []string{"add_ons.dashboard.disable"}},
+		{"component options", d.ComponentOptions, []string{"cluster.kube_apiserver.option_overrides.v"}},
+		{"certificates", d.Certificates, []string{"cluster.certificates.apiserver_cert_extra_sans", "master.load_balancer"}},
+		{"other", d.Other, []string{"cluster.name", "worker.nodes[worker02].labels.zone"}},
+	}
+	for _, test := range tests {
+		if len(test.diffs) != len(test.fields) {
+			t.Errorf("expected %s changes %v, but got %+v", test.group, test.fields, test.diffs)
+			continue
+		}
+		for i, f := range test.fields {
+			if test.diffs[i].Field != f {
+				t.Errorf("expected %s change to %q, but got %+v", test.group, f, test.diffs[i])
+			}
+		}
+	}
+}
+
+func TestDiffPlansNodeIPAffectsCertificates(t *testing.T) {
+	old := diffTestPlan()
+	new := diffTestPlan()
+	new.Master.Nodes[0].InternalIP = "192.168.0.2"
+	d, err := DiffPlans(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(d.Certificates) != 1 || d.Certificates[0] != (PlanFieldDiff{Field: "master.nodes[master01].internalip", To: "192.168.0.2"}) {
+		t.Errorf("expected the internal IP change to affect certificates, but got %+v", d.Certificates)
+	}
+}
@@ -0,0 +1,241 @@
+package install
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Plan file API versions. The version is bumped every time a plan file field
+// is deprecated or moved, and a migration from the previous version is added
+// to the planMigrations chain.
+const (
+	// planAPIVersionV1 replaces the fields used by KET v1.4.x and earlier:
+	// the master load balancer FQDN and short name, features.package_manager,
+	// allow_package_installation and the docker registry address and port.
+	planAPIVersionV1 = "v1"
+	// planAPIVersionV2 replaces the fields used by KET versions earlier than v1.5.0:
+	// cluster.networking.type, the heapster add-on replicas and PVC name,
+	// and the docker direct_lvm storage configuration.
+	planAPIVersionV2 = "v2"
+	// currentPlanAPIVersion is the plan API version supported by this release
+	currentPlanAPIVersion = planAPIVersionV2
+)
+
+// PlanFieldChange is a change that was made to a plan file field when migrating
+// the plan to a newer API version.
+type PlanFieldChange struct {
+	// The API version of the migration that made the change
+	Version string
+	// The path of the field in the plan file. For example: "master.load_balancer"
+	Field string
+	// The value of the field before the migration
+	From string
+	// The value of the field after the migration
+	To string
+}
+
+// a planMigration rewrites a plan from one API version to the next
+type planMigration struct {
+	to      string
+	migrate func(p *Plan, r *planChangeRecorder)
+}
+
+// planMigrations is the ordered chain of migrations. A plan is migrated by
+// running every migration, and its API version is bumped by the migrations to the
+// versions that it has not reached yet.
+var planMigrations = []planMigration{
+	{to: planAPIVersionV1, migrate: migrateToV1},
+	{to: planAPIVersionV2, migrate: migrateToV2},
+}
+
+func supportedPlanAPIVersions() []string {
+	versions := []string{}
+	for _, m := range planMigrations {
+		versions = append(versions, m.to)
+	}
+	return versions
+}
+
+// MigratePlan migrates the plan to the current API version, returning the list of
+// fields that were changed. The deprecated fields of every previous API version are
+// migrated, even when the plan is already at a later version, so that they are not
+// silently ignored.
+func MigratePlan(p *Plan) ([]PlanFieldChange, error) {
+	// the index of the migration to the API version of the plan, or -1 if the plan is unversioned
+	reached := -1
+	for i, m := range planMigrations {
+		if m.to == p.APIVersion {
+			reached = i
+		}
+	}
+	if p.APIVersion != "" && reached == -1 {
+		return nil, fmt.Errorf("plan file api_version %q is not supported. Supported versions are: %s", p.APIVersion, strings.Join(supportedPlanAPIVersions(), ", "))
+	}
+	changes := []PlanFieldChange{}
+	for i, m := range planMigrations {
+		r := &planChangeRecorder{version: m.to}
+		m.migrate(p, r)
+		if i > reached {
+			r.record("api_version", p.APIVersion, m.to)
+			p.APIVersion = m.to
+		}
+		changes = append(changes, r.changes...)
+	}
+	return changes, nil
+}
+
+type planChangeRecorder struct {
+	version string
+	changes []PlanFieldChange
+}
+
+// record a change to the field, if the value was actually modified
+func (r *planChangeRecorder) record(field string, from, to interface{}) {
+	f := fmt.Sprint(from)
+	t := fmt.Sprint(to)
+	if f == t {
+		return
+	}
+	r.changes = append(r.changes, PlanFieldChange{Version: r.version, Field: field, From: f, To: t})
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func migrateToV1(p *Plan, r *planChangeRecorder) {
+	// set load_balancer from load_balanced_fqdn:6443
+	// add load_balanced_fqdn to apiserver_cert_extra_sans when a short name was set
+	if p.Master.LoadBalancer == "" {
+		fqdn := stringOrEmpty(p.Master.LoadBalancedFQDN)
+		if stringOrEmpty(p.Master.LoadBalancedShortName) != "" && fqdn != "" {
+			sans := p.Cluster.Certificates.APIServerCertExtraSANs
+			if sans != "" {
+				sans = sans + ","
+			}
+			sans = sans + fqdn
+			r.record("cluster.certificates.apiserver_cert_extra_sans", p.Cluster.Certificates.APIServerCertExtraSANs, sans)
+			p.Cluster.Certificates.APIServerCertExtraSANs = sans
+		}
+		if fqdn != "" {
+			r.record("master.load_balancer", p.Master.LoadBalancer, fqdn+":6443")
+			p.Master.LoadBalancer = fqdn + ":6443"
+		}
+	}
+	if p.Master.LoadBalancedFQDN != nil {
+		r.record("master.load_balanced_fqdn", *p.Master.LoadBalancedFQDN, "")
+		p.Master.LoadBalancedFQDN = nil
+	}
+	if p.Master.LoadBalancedShortName != nil {
+		r.record("master.load_balanced_short_name", *p.Master.LoadBalancedShortName, "")
+		p.Master.LoadBalancedShortName = nil
+	}
+
+	// package_manager moved from features: to add_ons: after KET v1.3.3
+	if p.Features != nil {
+		if p.Features.PackageManager != nil {
+			r.record("add_ons.package_manager.disable", p.AddOns.PackageManager.Disable, !p.Features.PackageManager.Enabled)
+			p.AddOns.PackageManager.Disable = !p.Features.PackageManager.Enabled
+			// KET v1.3.3 did not have a provider field
+			r.record("add_ons.package_manager.provider", p.AddOns.PackageManager.Provider, ket133PackageManagerProvider)
+			p.AddOns.PackageManager.Provider = ket133PackageManagerProvider
+			r.record("features.package_manager.enabled", p.Features.PackageManager.Enabled, "")
+		}
+		p.Features = nil
+	}
+
+	// allow_package_installation renamed to disable_package_installation after KET v1.4.0
+	if p.Cluster.AllowPackageInstallation != nil {
+		r.record("cluster.disable_package_installation", p.Cluster.DisablePackageInstallation, !*p.Cluster.AllowPackageInstallation)
+		p.Cluster.DisablePackageInstallation = !*p.Cluster.AllowPackageInstallation
+		r.record("cluster.allow_package_installation", *p.Cluster.AllowPackageInstallation, "")
+		p.Cluster.AllowPackageInstallation = nil
+	}
+
+	// docker_registry address and port were merged into server
+	if p.DockerRegistry.Server == "" && p.DockerRegistry.Address != "" && p.DockerRegistry.Port != 0 {
+		server := fmt.Sprintf("%s:%d", p.DockerRegistry.Address, p.DockerRegistry.Port)
+		r.record("docker_registry.server", p.DockerRegistry.Server, server)
+		p.DockerRegistry.Server = server
+	}
+	if p.DockerRegistry.Server != "" {
+		r.record("docker_registry.address", p.DockerRegistry.Address, "")
+		p.DockerRegistry.Address = ""
+		if p.DockerRegistry.Port != 0 {
+			r.record("docker_registry.port", p.DockerRegistry.Port, "")
+			p.DockerRegistry.Port = 0
+		}
+	}
+}
+
+func migrateToV2(p *Plan, r *planChangeRecorder) {
+	// cluster.networking.type moved to add_ons.cni.options.calico.mode in KET v1.5.0
+	if p.Cluster.Networking.Type != "" {
+		if p.AddOns.CNI == nil {
+			p.AddOns.CNI = &CNI{}
+			r.record("add_ons.cni.provider", "", cniProviderCalico)
+			p.AddOns.CNI.Provider = cniProviderCalico
+			r.record("add_ons.cni.options.calico.mode", "", p.Cluster.Networking.Type)
+			p.AddOns.CNI.Options.Calico.Mode = p.Cluster.Networking.Type
+		}
+		r.record("cluster.networking.type", p.Cluster.Networking.Type, "")
+		p.Cluster.Networking.Type = ""
+	}
+
+	// heapster options were restructured in KET v1.5.0
+	if h := p.AddOns.HeapsterMonitoring; h != nil {
+		if h.Options.HeapsterReplicas != 0 {
+			r.record("add_ons.heapster.options.heapster.replicas", h.Options.Heapster.Replicas, h.Options.HeapsterReplicas)
+			h.Options.Heapster.Replicas = h.Options.HeapsterReplicas
+			r.record("add_ons.heapster.options.heapster_replicas", h.Options.HeapsterReplicas, "")
+			h.Options.HeapsterReplicas = 0
+		}
+		if h.Options.InfluxDBPVCName != "" {
+			r.record("add_ons.heapster.options.influxdb.pvc_name", h.Options.InfluxDB.PVCName, h.Options.InfluxDBPVCName)
+			h.Options.InfluxDB.PVCName = h.Options.InfluxDBPVCName
+			r.record("add_ons.heapster.options.influxdb_pvc_name", h.Options.InfluxDBPVCName, "")
+			h.Options.InfluxDBPVCName = ""
+		}
+	}
+
+	// docker.storage.direct_lvm was replaced by the devicemapper driver options
+	// and docker.storage.direct_lvm_block_device
+	storage := &p.Docker.Storage
+	if storage.DirectLVM == nil {
+		return
+	}
+	if !storage.DirectLVM.Enabled {
+		r.record("docker.storage.direct_lvm.enabled", storage.DirectLVM.Enabled, "")
+		storage.DirectLVM = nil
+		return
+	}
+	// leave the deprecated field in place if the user has set driver options,
+	// as we cannot safely merge them. Validation will catch any issues.
+	if len(storage.Opts) != 0 {
+		return
+	}
+	opts := map[string]string{
+		"dm.thinpooldev":           "/dev/mapper/docker-thinpool",
+		"dm.use_deferred_removal":  "true",
+		"dm.use_deferred_deletion": fmt.Sprintf("%t", storage.DirectLVM.EnableDeferredDeletion),
+	}
+	r.record("docker.storage.driver", storage.Driver, "devicemapper")
+	storage.Driver = "devicemapper"
+	r.record("docker.storage.opts", storage.Opts, opts)
+	storage.Opts = opts
+	r.record("docker.storage.direct_lvm_block_device.path", storage.DirectLVMBlockDevice.Path, storage.DirectLVM.BlockDevice)
+	storage.DirectLVMBlockDevice.Path = storage.DirectLVM.BlockDevice
+	r.record("docker.storage.direct_lvm_block_device.thinpool_percent", storage.DirectLVMBlockDevice.ThinpoolPercent, "95")
+	storage.DirectLVMBlockDevice.ThinpoolPercent = "95"
+	r.record("docker.storage.direct_lvm_block_device.thinpool_metapercent", storage.DirectLVMBlockDevice.ThinpoolMetaPercent, "1")
+	storage.DirectLVMBlockDevice.ThinpoolMetaPercent = "1"
+	r.record("docker.storage.direct_lvm_block_device.thinpool_autoextend_threshold", storage.DirectLVMBlockDevice.ThinpoolAutoextendThreshold, "80")
+	storage.DirectLVMBlockDevice.ThinpoolAutoextendThreshold = "80"
+	r.record("docker.storage.direct_lvm_block_device.thinpool_autoextend_percent", storage.DirectLVMBlockDevice.ThinpoolAutoextendPercent, "20")
+	storage.DirectLVMBlockDevice.ThinpoolAutoextendPercent = "20"
+	r.record("docker.storage.direct_lvm", "enabled", "")
+	storage.DirectLVM = nil
+}
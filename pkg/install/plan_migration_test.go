@@ -0,0 +1,218 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigratePlanUnversioned(t *testing.T) {
+	p := &Plan{}
+	fqdn := "lb.example.com"
+	shortName := "kube-lb"
+	p.Master.LoadBalancedFQDN = &fqdn
+	p.Master.LoadBalancedShortName = &shortName
+	allow := true
+	p.Cluster.AllowPackageInstallation = &allow
+	p.DockerRegistry.Address = "10.0.0.1"
+	p.DockerRegistry.Port = 8443
+	p.Cluster.Networking.Type = "routed"
+	p.AddOns.HeapsterMonitoring = &HeapsterMonitoring{}
+	p.AddOns.HeapsterMonitoring.Options.HeapsterReplicas = 3
+	p.AddOns.HeapsterMonitoring.Options.InfluxDBPVCName = "influx"
+	p.Docker.Storage.DirectLVM = &DockerStorageDirectLVMDeprecated{
+		Enabled:     true,
+		BlockDevice: "/dev/sdb",
+	}
+
+	changes, err := MigratePlan(p)
+	if err != nil {
+		t.Fatalf("unexpected error migrating plan: %v", err)
+	}
+	if p.APIVersion != currentPlanAPIVersion {
+		t.Errorf("expected api_version to be %q, but got %q", currentPlanAPIVersion, p.APIVersion)
+	}
+	if p.Master.LoadBalancer != "lb.example.com:6443" {
+		t.Errorf("expected master.load_balancer to be set from the fqdn, but got %q", p.Master.LoadBalancer)
+	}
+	if p.Cluster.Certificates.APIServerCertExtraSANs != "lb.example.com" {
+		t.Errorf("expected the fqdn to be added to the extra SANs, but got %q", p.Cluster.Certificates.APIServerCertExtraSANs)
+	}
+	if p.Master.LoadBalancedFQDN != nil || p.Master.LoadBalancedShortName != nil {
+		t.Errorf("expected the deprecated load balancer fields to be removed")
+	}
+	if p.Cluster.DisablePackageInstallation || p.Cluster.AllowPackageInstallation != nil {
+		t.Errorf("expected allow_package_installation to be replaced by disable_package_installation")
+	}
+	if p.DockerRegistry.Server != "10.0.0.1:8443" || p.DockerRegistry.Address != "" || p.DockerRegistry.Port != 0 {
+		t.Errorf("expected docker registry address and port to be replaced by server, but got %+v", p.DockerRegistry)
+	}
+	if p.AddOns.CNI == nil || p.AddOns.CNI.Options.Calico.Mode != "routed" || p.Cluster.Networking.Type != "" {
+		t.Errorf("expected cluster.networking.type to be moved to the calico mode")
+	}
+	heapster := p.AddOns.HeapsterMonitoring.Options
+	if heapster.Heapster.Replicas != 3 || heapster.InfluxDB.PVCName != "influx" || heapster.HeapsterReplicas != 0 || heapster.InfluxDBPVCName != "" {
+		t.Errorf("expected deprecated heapster options to be migrated, but got %+v", heapster)
+	}
+	if p.Docker.Storage.DirectLVM != nil || p.Docker.Storage.Driver != "devicemapper" || p.Docker.Storage.DirectLVMBlockDevice.Path != "/dev/sdb" {
+		t.Errorf("expected direct_lvm to be migrated, but got %+v", p.Docker.Storage)
+	}
+
+	// every change should be reported
+	changed := map[string]bool{}
+	for _, c := range changes {
+		changed[c.Field] = true
+	}
+	expectedFields := []string{
+		"api_version",
+		"master.load_balancer",
+		"master.load_balanced_fqdn",
+		"cluster.allow_package_installation",
+		"docker_registry.server",
+		"cluster.networking.type",
+		"add_ons.heapster.options.heapster.replicas",
+		"docker.storage.direct_lvm_block_device.path",
+	}
+	for _, f := range expectedFields {
+		if !changed[f] {
+			t.Errorf("expected a change to %q to be reported", f)
+		}
+	}
+}
+
+func TestMigratePlanCurrentVersion(t *testing.T) {
+	p := &Plan{APIVersion: currentPlanAPIVersion}
+	changes, err := MigratePlan(p)
+	if err != nil {
+		t.Fatalf("unexpected error migrating plan: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected no changes, but got %v", changes)
+	}
+}
+
+func TestMigratePlanCurrentVersionDeprecatedFields(t *testing.T) {
+	p := &Plan{APIVersion: currentPlanAPIVersion}
+	fqdn := "lb.example.com"
+	p.Master.LoadBalancedFQDN = &fqdn
+	p.Cluster.Networking.Type = "routed"
+	changes, err := MigratePlan(p)
+	if err != nil {
+		t.Fatalf("unexpected error migrating plan: %v", err)
+	}
+	if p.APIVersion != currentPlanAPIVersion {
+		t.Errorf("expected api_version to be %q, but got %q", currentPlanAPIVersion, p.APIVersion)
+	}
+	if p.Master.LoadBalancer != "lb.example.com:6443" || p.Master.LoadBalancedFQDN != nil {
+		t.Errorf("expected load_balanced_fqdn to be migrated, but got %+v", p.Master)
+	}
+	if p.AddOns.CNI == nil || p.AddOns.CNI.Options.Calico.Mode != "routed" {
+		t.Errorf("expected cluster.networking.type to be migrated")
+	}
+	for _, c := range changes {
+		if c.Field == "api_version" {
+			t.Errorf("expected api_version to be unchanged, but got change %+v", c)
+		}
+	}
+	if len(changes) == 0 {
+		t.Errorf("expected the migrated fields to be reported")
+	}
+}
+
+func TestMigratePlanUnsupportedVersion(t *testing.T) {
+	p := &Plan{APIVersion: "v99"}
+	if _, err := MigratePlan(p); err == nil {
+		t.Errorf("expected an error migrating a plan with an unsupported version")
+	}
+}
+
+func TestMigratePlanFromV1(t *testing.T) {
+	p := &Plan{APIVersion: planAPIVersionV1}
+	p.Cluster.Networking.Type = "overlay"
+	changes, err := MigratePlan(p)
+	if err != nil {
+		t.Fatalf("unexpected error migrating plan: %v", err)
+	}
+	for _, c := range changes {
+		if c.Version != planAPIVersionV2 {
+			t.Errorf("expected only the %s migration to run, but got change %+v", planAPIVersionV2, c)
+		}
+	}
+	if p.APIVersion != planAPIVersionV2 {
+		t.Errorf("expected api_version to be %q, but got %q", planAPIVersionV2, p.APIVersion)
+	}
+}
+
+func TestMigratePlanFromV1DeprecatedFields(t *testing.T) {
+	p := &Plan{APIVersion: planAPIVersionV1}
+	allow := false
+	p.Cluster.AllowPackageInstallation = &allow
+	p.Features = &Features{PackageManager: &DeprecatedPackageManager{Enabled: true}}
+	changes, err := MigratePlan(p)
+	if err != nil {
+		t.Fatalf("unexpected error migrating plan: %v", err)
+	}
+	if p.Cluster.AllowPackageInstallation != nil || !p.Cluster.DisablePackageInstallation {
+		t.Errorf("expected allow_package_installation to be migrated, but got %+v", p.Cluster)
+	}
+	if p.Features != nil || p.AddOns.PackageManager.Disable {
+		t.Errorf("expected features.package_manager to be migrated")
+	}
+	if p.APIVersion != planAPIVersionV2 {
+		t.Errorf("expected api_version to be %q, but got %q", planAPIVersionV2, p.APIVersion)
+	}
+	versionChanges := 0
+	for _, c := range changes {
+		if c.Field == "api_version" {
+			versionChanges++
+			if c.From != planAPIVersionV1 || c.To != planAPIVersionV2 {
+				t.Errorf("expected api_version to be bumped from %s to %s, but got change %+v", planAPIVersionV1, planAPIVersionV2, c)
+			}
+		}
+	}
+	if versionChanges != 1 {
+		t.Errorf("expected api_version to be bumped once, but got %d changes", versionChanges)
+	}
+}
+
+func TestFilePlannerMigrate(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-migrate-plan")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	file := filepath.Join(tmp, "kismatic-cluster.yaml")
+	old := `cluster:
+  name: test
+  allow_package_installation: false
+master:
+  load_balanced_fqdn: lb.example.com
+`
+	if err = ioutil.WriteFile(file, []byte(old), 0644); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	fp := &FilePlanner{File: file}
+	changes, err := fp.Migrate()
+	if err != nil {
+		t.Fatalf("unexpected error migrating plan file: %v", err)
+	}
+	if len(changes) == 0 {
+		t.Fatalf("expected changes to be reported")
+	}
+	// the rewritten file should not require any further migrations
+	changes, err = fp.Migrate()
+	if err != nil {
+		t.Fatalf("unexpected error migrating plan file: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("expected migrated plan file to be at the current version, but got changes %v", changes)
+	}
+	p, err := fp.Read()
+	if err != nil {
+		t.Fatalf("error reading migrated plan: %v", err)
+	}
+	if p.Master.LoadBalancer != "lb.example.com:6443" || !p.Cluster.DisablePackageInstallation {
+		t.Errorf("migrated plan file did not contain the migrated fields")
+	}
+}
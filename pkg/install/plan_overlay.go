@@ -0,0 +1,123 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Plan overlays are partial plan files that are merged on top of a base plan file,
+// in the order they are given. The merge is done on the plan file contents,
+// before the plan is migrated and defaults are set:
+//
+// - Mappings, such as the cluster configuration, the option_overrides of a component
+//   or an add-on block, are merged key by key. Values in the overlay win.
+// - A key that is set to null in an overlay is removed from the merged plan.
+//   For example, this removes an option override, or resets an add-on to its defaults.
+// - The node lists of a node group are merged by host. A node of the overlay is merged
+//   into the node of the base plan with the same host, and other nodes are appended.
+// - Any other list, such as additional_files, is replaced by the list of the overlay.
+
+// readPlanFiles returns the contents of the plan file, with the overlays merged on top of it
+func (fp *FilePlanner) readPlanFiles() ([]byte, error) {
+	d, err := ioutil.ReadFile(fp.File)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file: %v", err)
+	}
+	if len(fp.Overlays) == 0 {
+		return d, nil
+	}
+	merged := map[interface{}]interface{}{}
+	if err = yaml.Unmarshal(d, &merged); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal plan: %v", err)
+	}
+	for _, file := range fp.Overlays {
+		d, err = ioutil.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("could not read plan overlay: %v", err)
+		}
+		overlay := map[interface{}]interface{}{}
+		if err = yaml.Unmarshal(d, &overlay); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal plan overlay %q: %v", file, err)
+		}
+		merged = mergePlanOverlay(merged, overlay, "")
+	}
+	d, err = yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling merged plan: %v", err)
+	}
+	return d, nil
+}
+
+func mergePlanOverlay(base, overlay map[interface{}]interface{}, path string) map[interface{}]interface{} {
+	if base == nil {
+		base = map[interface{}]interface{}{}
+	}
+	for k, ov := range overlay {
+		field := fmt.Sprint(k)
+		if path != "" {
+			field = path + "." + field
+		}
+		if ov == nil {
+			delete(base, k)
+			continue
+		}
+		switch o := ov.(type) {
+		case map[interface{}]interface{}:
+			bm, _ := base[k].(map[interface{}]interface{})
+			base[k] = mergePlanOverlay(bm, o, field)
+		case []interface{}:
+			if isNodeListField(field) {
+				bl, _ := base[k].([]interface{})
+				base[k] = mergeNodeLists(bl, o, field)
+				continue
+			}
+			base[k] = o
+		default:
+			base[k] = o
+		}
+	}
+	return base
+}
+
+func isNodeListField(field string) bool {
+	parts := strings.Split(field, ".")
+	if len(parts) != 2 || parts[1] != "nodes" {
+		return false
+	}
+	for _, g := range nodeGroupFields {
+		if parts[0] == g {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeNodeLists merges the overlay nodes into the base nodes with the same host,
+// and appends the nodes that are not in the base list
+func mergeNodeLists(base, overlay []interface{}, field string) []interface{} {
+	merged := make([]interface{}, len(base))
+	copy(merged, base)
+	for _, on := range overlay {
+		o, ok := on.(map[interface{}]interface{})
+		if !ok {
+			merged = append(merged, on)
+			continue
+		}
+		found := false
+		for i, bn := range merged {
+			b, ok := bn.(map[interface{}]interface{})
+			if ok && b["host"] != nil && b["host"] == o["host"] {
+				merged[i] = mergePlanOverlay(b, o, fmt.Sprintf("%s[%v]", field, o["host"]))
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, o)
+		}
+	}
+	return merged
+}
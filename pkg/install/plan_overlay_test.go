@@ -0,0 +1,151 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const overlayTestBasePlan = `cluster:
+  name: base
+  version: v1.10.5
+  kube_apiserver:
+    option_overrides:
+      v: "2"
+      audit-log-maxage: "30"
+add_ons:
+  dashboard:
+    disable: true
+  heapster:
+    options:
+      heapster:
+        replicas: 3
+additional_files:
+- source: /a
+  destination: /a
+  hosts: [all]
+worker:
+  expected_count: 2
+  nodes:
+  - host: worker01
+    ip: 10.0.0.1
+    labels:
+      zone: a
+  - host: worker02
+    ip: 10.0.0.2
+`
+
+const overlayTestProdPlan = `cluster:
+  name: prod
+  kube_apiserver:
+    option_overrides:
+      v: "4"
+      audit-log-maxage: null
+add_ons:
+  dashboard:
+    disable: false
+  heapster: null
+additional_files:
+- source: /b
+  destination: /b
+  hosts: [all]
+worker:
+  expected_count: 3
+  nodes:
+  - host: worker02
+    labels:
+      zone: b
+  - host: worker03
+    ip: 10.0.0.3
+`
+
+func writeOverlayTestFiles(t *testing.T, files ...string) (string, []string) {
+	tmp, err := ioutil.TempDir("", "ket-test-plan-overlay")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	paths := []string{}
+	for i, f := range files {
+		path := filepath.Join(tmp, fmt.Sprintf("plan-%d.yaml", i))
+		if err = ioutil.WriteFile(path, []byte(f), 0644); err != nil {
+			t.Fatalf("error writing plan file: %v", err)
+		}
+		paths = append(paths, path)
+	}
+	return tmp, paths
+}
+
+func TestReadPlanWithOverlays(t *testing.T) {
+	tmp, files := writeOverlayTestFiles(t, overlayTestBasePlan, overlayTestProdPlan)
+	defer os.RemoveAll(tmp)
+	fp := &FilePlanner{File: files[0], Overlays: files[1:]}
+	p, err := fp.Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading plan: %v", err)
+	}
+	if p.Cluster.Name != "prod" || p.Cluster.Version != "v1.10.5" {
+		t.Errorf("expected scalars to be overridden by the overlay, but got %q %q", p.Cluster.Name, p.Cluster.Version)
+	}
+	overrides := p.Cluster.APIServerOptions.Overrides
+	if len(overrides) != 1 || overrides["v"] != "4" {
+		t.Errorf("expected option overrides to be merged, but got %v", overrides)
+	}
+	if p.AddOns.Dashboard.Disable {
+		t.Errorf("expected the dashboard to be enabled by the overlay")
+	}
+	if p.AddOns.HeapsterMonitoring.Options.Heapster.Replicas != 2 {
+		t.Errorf("expected the heapster add-on to be reset to its defaults, but got %+v", p.AddOns.HeapsterMonitoring.Options.Heapster)
+	}
+	if len(p.AdditionalFiles) != 1 || p.AdditionalFiles[0].Source != "/b" {
+		t.Errorf("expected additional files to be replaced, but got %+v", p.AdditionalFiles)
+	}
+	if p.Worker.ExpectedCount != 3 || len(p.Worker.Nodes) != 3 {
+		t.Fatalf("expected 3 worker nodes, but got %+v", p.Worker)
+	}
+	expected := []Node{
+		{Host: "worker01", IP: "10.0.0.1", Labels: map[string]string{"zone": "a"}},
+		{Host: "worker02", IP: "10.0.0.2", Labels: map[string]string{"zone": "b"}},
+		{Host: "worker03", IP: "10.0.0.3"},
+	}
+	for i, n := range expected {
+		got := p.Worker.Nodes[i]
+		if got.Host != n.Host || got.IP != n.IP || got.Labels["zone"] != n.Labels["zone"] {
+			t.Errorf("expected node %d to be %+v, but got %+v", i, n, got)
+		}
+	}
+}
+
+func TestReadPlanWithoutOverlaysIsUnchanged(t *testing.T) {
+	tmp, files := writeOverlayTestFiles(t, overlayTestBasePlan)
+	defer os.RemoveAll(tmp)
+	p, err := (&FilePlanner{File: files[0]}).Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading plan: %v", err)
+	}
+	if p.Cluster.Name != "base" || len(p.Worker.Nodes) != 2 || len(p.Cluster.APIServerOptions.Overrides) != 2 {
+		t.Errorf("unexpected plan: %+v", p)
+	}
+}
+
+func TestWritePlanWithOverlaysFails(t *testing.T) {
+	tmp, files := writeOverlayTestFiles(t, overlayTestBasePlan, overlayTestProdPlan)
+	defer os.RemoveAll(tmp)
+	fp := &FilePlanner{File: files[0], Overlays: files[1:]}
+	if err := fp.Write(&Plan{}); err == nil {
+		t.Errorf("expected an error writing a plan that is composed of overlays")
+	}
+	if _, err := fp.Migrate(); err == nil {
+		t.Errorf("expected an error migrating a plan that is composed of overlays")
+	}
+}
+
+func TestReadPlanMissingOverlay(t *testing.T) {
+	tmp, files := writeOverlayTestFiles(t, overlayTestBasePlan)
+	defer os.RemoveAll(tmp)
+	fp := &FilePlanner{File: files[0], Overlays: []string{filepath.Join(tmp, "missing.yaml")}}
+	if _, err := fp.Read(); err == nil {
+		t.Errorf("expected an error reading a plan with a missing overlay")
+	}
+}
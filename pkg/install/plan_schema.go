@@ -0,0 +1,1540 @@
+// Code generated by gen-kismatic-ref-docs. DO NOT EDIT.
+
+package install
+
+// PlanJSONSchema is the JSON Schema document of the plan file
+const PlanJSONSchema = "{\n" +
+	"  \"$schema\": \"http://json-schema.org/draft-07/schema#\",\n" +
+	"  \"title\": \"Plan\",\n" +
+	"  \"description\": \"The installation plan of a Kubernetes cluster managed by KET\",\n" +
+	"  \"type\": \"object\",\n" +
+	"  \"properties\": {\n" +
+	"    \"add_ons\": {\n" +
+	"      \"description\": \"Add on configuration\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"cni\": {\n" +
+	"          \"description\": \"The Container Networking Interface (CNI) add-on configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"disable\": {\n" +
+	"              \"description\": \"Whether the CNI add-on is disabled. When set to true, CNI will not be installed on the cluster. Furthermore, the smoke test and any validation that depends on a functional pod network will be skipped.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            },\n" +
+	"            \"options\": {\n" +
+	"              \"description\": \"The CNI options that can be configured for each CNI provider.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"properties\": {\n" +
+	"                \"calico\": {\n" +
+	"                  \"description\": \"The options that can be configured for the Calico CNI provider.\",\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"felix_input_mtu\": {\n" +
+	"                      \"description\": \"MTU for the tunnel device used if IPIP is enabled.\",\n" +
+	"                      \"type\": \"integer\",\n" +
+	"                      \"default\": 1440\n" +
+	"                    },\n" +
+	"                    \"ip_autodetection_method\": {\n" +
+	"                      \"description\": \"IPAutodetectionMethod is used to detect the IPv4 address of the host. The value gets set in IP_AUTODETECTION_METHOD variable in the pod.\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"default\": \"first-found\"\n" +
+	"                    },\n" +
+	"                    \"log_level\": {\n" +
+	"                      \"description\": \"The logging level for the CNI plugin\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"default\": \"info\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"warning\",\n" +
+	"                        \"info\",\n" +
+	"                        \"debug\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"mode\": {\n" +
+	"                      \"description\": \"The datapath technique that should be configured in Calico.\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"default\": \"overlay\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"overlay\",\n" +
+	"                        \"routed\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"workload_mtu\": {\n" +
+	"                      \"description\": \"MTU for the workload interface, configures the CNI config.\",\n" +
+	"                      \"type\": \"integer\",\n" +
+	"                      \"default\": 1500\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                },\n" +
+	"                \"portmap\": {\n" +
+	"                  \"description\": \"The options that can be configured for the Portmap CNI provider.\",\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"disable\": {\n" +
+	"                      \"description\": \"Disable the portmap CNI plugin\",\n" +
+	"                      \"type\": \"boolean\",\n" +
+	"                      \"default\": false\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                },\n" +
+	"                \"weave\": {\n" +
+	"                  \"description\": \"The options that can be configured for the Weave CNI provider.\",\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"password\": {\n" +
+	"                      \"description\": \"The password to use for network traffic encryption. Can be a secret reference, such as env:WEAVE_PASSWORD or file:/path/to/secret.\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false\n" +
+	"            },\n" +
+	"            \"provider\": {\n" +
+	"              \"description\": \"The CNI provider that should be installed on the cluster.\",\n" +
+	"              \"type\": \"string\",\n" +
+	"              \"default\": \"calico\",\n" +
+	"              \"enum\": [\n" +
+	"                \"\",\n" +
+	"                \"calico\",\n" +
+	"                \"weave\",\n" +
+	"                \"contiv\",\n" +
+	"                \"custom\"\n" +
+	"              ]\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"dashboard\": {\n" +
+	"          \"description\": \"The Dashboard add-on configuration.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"disable\": {\n" +
+	"              \"description\": \"Whether the dashboard add-on should be disabled. When set to true, the Kubernetes Dashboard will not be installed on the cluster.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            },\n" +
+	"            \"options\": {\n" +
+	"              \"description\": \"The options that can be configured for the Dashboard add-on\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"properties\": {\n" +
+	"                \"node_port\": {\n" +
+	"                  \"description\": \"When using NodePort set the port to use. When left empty Kubernetes will allocate a random port.\",\n" +
+	"                  \"type\": \"string\"\n" +
+	"                },\n" +
+	"                \"service_type\": {\n" +
+	"                  \"description\": \"Kubernetes service type of the Dashboard service.\",\n" +
+	"                  \"type\": \"string\",\n" +
+	"                  \"default\": \"ClusterIP\",\n" +
+	"                  \"enum\": [\n" +
+	"                    \"\",\n" +
+	"                    \"ClusterIP\",\n" +
+	"                    \"NodePort\",\n" +
+	"                    \"LoadBalancer\",\n" +
+	"                    \"ExternalName\"\n" +
+	"                  ]\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"dns\": {\n" +
+	"          \"description\": \"The DNS add-on configuration.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"disable\": {\n" +
+	"              \"description\": \"Whether the DNS add-on should be disabled. When set to true, no DNS solution will be deployed on the cluster.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            },\n" +
+	"            \"options\": {\n" +
+	"              \"description\": \"The options that can be configured for the cluster DNS add-on\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"properties\": {\n" +
+	"                \"replicas\": {\n" +
+	"                  \"description\": \"Number of cluster DNS replicas that should be scheduled on the cluster.\",\n" +
+	"                  \"type\": \"integer\",\n" +
+	"                  \"default\": 2\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false\n" +
+	"            },\n" +
+	"            \"provider\": {\n" +
+	"              \"description\": \"This property indicates the in-cluster DNS provider.\",\n" +
+	"              \"type\": \"string\",\n" +
+	"              \"default\": \"kubedns\",\n" +
+	"              \"enum\": [\n" +
+	"                \"\",\n" +
+	"                \"kubedns\",\n" +
+	"                \"coredns\"\n" +
+	"              ]\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false,\n" +
+	"          \"required\": [\n" +
+	"            \"provider\"\n" +
+	"          ]\n" +
+	"        },\n" +
+	"        \"heapster\": {\n" +
+	"          \"description\": \"The Heapster Monitoring add-on configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"disable\": {\n" +
+	"              \"description\": \"Whether the Heapster add-on should be disabled. When set to true, Heapster and InfluxDB will not be deployed on the cluster.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            },\n" +
+	"            \"options\": {\n" +
+	"              \"description\": \"The options that can be configured for the Heapster add-on\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"properties\": {\n" +
+	"                \"heapster\": {\n" +
+	"                  \"description\": \"The Heapster configuration options.\",\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"replicas\": {\n" +
+	"                      \"description\": \"Number of Heapster replicas that should be scheduled on the cluster.\",\n" +
+	"                      \"type\": \"integer\",\n" +
+	"                      \"default\": 2\n" +
+	"                    },\n" +
+	"                    \"service_type\": {\n" +
+	"                      \"description\": \"Kubernetes service type of the Heapster service.\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"default\": \"ClusterIP\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"ClusterIP\",\n" +
+	"                        \"NodePort\",\n" +
+	"                        \"LoadBalancer\",\n" +
+	"                        \"ExternalName\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"sink\": {\n" +
+	"                      \"description\": \"URL of the backend store that will be used as the Heapster sink.\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"default\": \"influxdb:http://heapster-influxdb.kube-system.svc:8086\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                },\n" +
+	"                \"heapster_replicas\": {\n" +
+	"                  \"description\": \"Number of Heapster replicas that should be scheduled on the cluster.\",\n" +
+	"                  \"type\": \"integer\",\n" +
+	"                  \"deprecated\": true\n" +
+	"                },\n" +
+	"                \"influxdb\": {\n" +
+	"                  \"description\": \"The InfluxDB configuration options.\",\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"pvc_name\": {\n" +
+	"                      \"description\": \"Name of the Persistent Volume Claim that will be used by InfluxDB. This PVC must be created after the installation. If not set, InfluxDB will be configured with ephemeral storage.\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                },\n" +
+	"                \"influxdb_pvc_name\": {\n" +
+	"                  \"description\": \"Name of the Persistent Volume Claim that will be used by InfluxDB. When set, this PVC must be created after the installation. If not set, InfluxDB will be configured with ephemeral storage.\",\n" +
+	"                  \"type\": \"string\",\n" +
+	"                  \"deprecated\": true\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"metrics_server\": {\n" +
+	"          \"description\": \"Metrics Server add-on configuration. A cluster-wide aggregator of resource usage data. Required for Horizontal Pod Autoscaler to function properly.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"disable\": {\n" +
+	"              \"description\": \"Whether the metrics-server add-on should be disabled. When set to true, metrics-server will not be deployed on the cluster.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"package_manager\": {\n" +
+	"          \"description\": \"The PackageManager add-on configuration.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"disable\": {\n" +
+	"              \"description\": \"Whether the package manager add-on should be disabled. When set to true, the package manager will not be installed on the cluster.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            },\n" +
+	"            \"options\": {\n" +
+	"              \"description\": \"The PackageManager options.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"properties\": {\n" +
+	"                \"helm\": {\n" +
+	"                  \"description\": \"Helm PackageManager options\",\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"namespace\": {\n" +
+	"                      \"description\": \"Namespace to deploy tiller\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"default\": \"kube-system\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false\n" +
+	"            },\n" +
+	"            \"provider\": {\n" +
+	"              \"description\": \"This property indicates the package manager provider.\",\n" +
+	"              \"type\": \"string\",\n" +
+	"              \"enum\": [\n" +
+	"                \"\",\n" +
+	"                \"helm\"\n" +
+	"              ]\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false,\n" +
+	"          \"required\": [\n" +
+	"            \"provider\"\n" +
+	"          ]\n" +
+	"        },\n" +
+	"        \"rescheduler\": {\n" +
+	"          \"description\": \"The Rescheduler add-on configuration. Because the Rescheduler does not have leader election and therefore can only run as a single instance in a cluster, it will be deployed as a static pod on the first master. More information about the Rescheduler can be found here: https://kubernetes.io/docs/tasks/administer-cluster/guaranteed-scheduling-critical-addon-pods/\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"disable\": {\n" +
+	"              \"description\": \"Whether the pod rescheduler add-on should be disabled. When set to true, the rescheduler will not be installed on the cluster.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false\n" +
+	"    },\n" +
+	"    \"additional_files\": {\n" +
+	"      \"description\": \"A set of files or directories to copy from the local machine to any of the nodes in the cluster.\",\n" +
+	"      \"type\": \"array\",\n" +
+	"      \"items\": {\n" +
+	"        \"type\": \"object\",\n" +
+	"        \"properties\": {\n" +
+	"          \"destination\": {\n" +
+	"            \"description\": \"Path to the file or directory on remote machine, where file will be copied. Must be an absolute path.\",\n" +
+	"            \"type\": \"string\"\n" +
+	"          },\n" +
+	"          \"hosts\": {\n" +
+	"            \"description\": \"Hostname or role where additional files or directories will be copied.\",\n" +
+	"            \"type\": \"array\",\n" +
+	"            \"items\": {\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"skip_validation\": {\n" +
+	"            \"description\": \"Set to true if validation will be run before the file exists on the local machine. Useful for files generated at install time, ie. assets in generated/ directory.\",\n" +
+	"            \"type\": \"boolean\",\n" +
+	"            \"default\": false\n" +
+	"          },\n" +
+	"          \"source\": {\n" +
+	"            \"description\": \"Path to the file or directory on local machine. Must be an absolute path.\",\n" +
+	"            \"type\": \"string\"\n" +
+	"          }\n" +
+	"        },\n" +
+	"        \"additionalProperties\": false,\n" +
+	"        \"required\": [\n" +
+	"          \"hosts\",\n" +
+	"          \"source\",\n" +
+	"          \"destination\"\n" +
+	"        ]\n" +
+	"      }\n" +
+	"    },\n" +
+	"    \"api_version\": {\n" +
+	"      \"description\": \"Version of the plan file schema. Plan files using an older version are migrated in memory when they are read, and can be rewritten using the current version with \\\"kismatic plan migrate\\\".\",\n" +
+	"      \"type\": \"string\",\n" +
+	"      \"default\": \"v2\",\n" +
+	"      \"enum\": [\n" +
+	"        \"\",\n" +
+	"        \"v1\",\n" +
+	"        \"v2\"\n" +
+	"      ]\n" +
+	"    },\n" +
+	"    \"cluster\": {\n" +
+	"      \"description\": \"Kubernetes cluster configuration\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"admin_password\": {\n" +
+	"          \"description\": \"The password for the admin user. If provided, ABAC will be enabled in the cluster. Can be a secret reference, such as env:ADMIN_PASSWORD or file:/path/to/secret. This field will be removed completely in a future release.\",\n" +
+	"          \"type\": \"string\",\n" +
+	"          \"deprecated\": true\n" +
+	"        },\n" +
+	"        \"allow_package_installation\": {\n" +
+	"          \"description\": \"Whether KET should install the packages on the cluster nodes. Use DisablePackageInstallation instead.\",\n" +
+	"          \"type\": [\n" +
+	"            \"boolean\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"default\": false,\n" +
+	"          \"deprecated\": true\n" +
+	"        },\n" +
+	"        \"certificates\": {\n" +
+	"          \"description\": \"The Certificates configuration for the cluster.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"apiserver_cert_extra_sans\": {\n" +
+	"              \"description\": \"Comma-separated list of Subject Alternative Names (SANs) to use for the API Server serving certificate. Can be both IP addresses and DNS names.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ca_expiry\": {\n" +
+	"              \"description\": \"The length of time that the generated Certificate Authority should be valid for. For example: \\\"17520h\\\" for 2 years.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"expiry\": {\n" +
+	"              \"description\": \"The length of time that the generated certificates should be valid for. For example: \\\"17520h\\\" for 2 years.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false,\n" +
+	"          \"required\": [\n" +
+	"            \"expiry\",\n" +
+	"            \"ca_expiry\"\n" +
+	"          ]\n" +
+	"        },\n" +
+	"        \"cloud_provider\": {\n" +
+	"          \"description\": \"The CloudProvider configuration for the cluster.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"config\": {\n" +
+	"              \"description\": \"Path to the cloud provider config file. This will be copied to all the machines in the cluster. Can be a secret reference, such as env:CLOUD_CONFIG_PATH or file:/path/to/cloud.conf.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"provider\": {\n" +
+	"              \"description\": \"The cloud provider that should be set in the Kubernetes components\",\n" +
+	"              \"type\": \"string\",\n" +
+	"              \"enum\": [\n" +
+	"                \"\",\n" +
+	"                \"aws\",\n" +
+	"                \"azure\",\n" +
+	"                \"cloudstack\",\n" +
+	"                \"fake\",\n" +
+	"                \"gce\",\n" +
+	"                \"mesos\",\n" +
+	"                \"openstack\",\n" +
+	"                \"ovirt\",\n" +
+	"                \"photon\",\n" +
+	"                \"rackspace\",\n" +
+	"                \"vsphere\"\n" +
+	"              ]\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"disable_package_installation\": {\n" +
+	"          \"description\": \"Whether KET should install the packages on the cluster nodes. When true, KET will not install the required packages. Instead, it will verify that the packages have been installed by the operator.\",\n" +
+	"          \"type\": \"boolean\",\n" +
+	"          \"default\": false\n" +
+	"        },\n" +
+	"        \"disconnected_installation\": {\n" +
+	"          \"description\": \"Whether the cluster nodes are disconnected from the internet. When set to `true`, internal package repositories and a container image registry are required for installation.\",\n" +
+	"          \"type\": \"boolean\",\n" +
+	"          \"default\": false\n" +
+	"        },\n" +
+	"        \"kube_apiserver\": {\n" +
+	"          \"description\": \"Kubernetes API Server configuration.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"option_overrides\": {\n" +
+	"              \"description\": \"Listing of option overrides that are to be applied to the Kubernetes API server configuration. This is an advanced feature that can prevent the API server from starting up if invalid configuration is provided.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"additionalProperties\": {\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"kube_controller_manager\": {\n" +
+	"          \"description\": \"Kubernetes Controller Manager configuration.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"option_overrides\": {\n" +
+	"              \"description\": \"Listing of option overrides that are to be applied to the Kubernetes Controller Manager configuration. This is an advanced feature that can prevent the Controller Manager from starting up if invalid configuration is provided.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"additionalProperties\": {\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"kube_proxy\": {\n" +
+	"          \"description\": \"Kubernetes Proxy configuration.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"option_overrides\": {\n" +
+	"              \"description\": \"Listing of option overrides that are to be applied to the Kubernetes Proxy configuration. This is an advanced feature that can prevent the Proxy from starting up if invalid configuration is provided.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"additionalProperties\": {\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"kube_scheduler\": {\n" +
+	"          \"description\": \"Kubernetes Scheduler configuration.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"option_overrides\": {\n" +
+	"              \"description\": \"Listing of option overrides that are to be applied to the Kubernetes Scheduler configuration. This is an advanced feature that can prevent the Scheduler from starting up if invalid configuration is provided.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"additionalProperties\": {\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"kubelet\": {\n" +
+	"          \"description\": \"Kubelet configuration applied to all nodes.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"option_overrides\": {\n" +
+	"              \"description\": \"Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"additionalProperties\": {\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"name\": {\n" +
+	"          \"description\": \"Name of the cluster to be used when generating assets that require a cluster name, such as kubeconfig files and certificates.\",\n" +
+	"          \"type\": \"string\"\n" +
+	"        },\n" +
+	"        \"networking\": {\n" +
+	"          \"description\": \"The Networking configuration for the cluster.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"http_proxy\": {\n" +
+	"              \"description\": \"The URL of the proxy that should be used for HTTP connections.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"https_proxy\": {\n" +
+	"              \"description\": \"The URL of the proxy that should be used for HTTPS connections.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"no_proxy\": {\n" +
+	"              \"description\": \"Comma-separated list of host names and/or IPs for which connections should not go through a proxy. All nodes' 'host' and 'IPs' are always set.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"pod_cidr_block\": {\n" +
+	"              \"description\": \"The pod network's CIDR block. For example: `172.16.0.0/16`\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"service_cidr_block\": {\n" +
+	"              \"description\": \"The Kubernetes service network's CIDR block. For example: `172.20.0.0/16`\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"type\": {\n" +
+	"              \"description\": \"The datapath technique that should be configured in Calico.\",\n" +
+	"              \"type\": \"string\",\n" +
+	"              \"default\": \"overlay\",\n" +
+	"              \"enum\": [\n" +
+	"                \"\",\n" +
+	"                \"overlay\",\n" +
+	"                \"routed\"\n" +
+	"              ],\n" +
+	"              \"deprecated\": true\n" +
+	"            },\n" +
+	"            \"update_hosts_files\": {\n" +
+	"              \"description\": \"Whether the /etc/hosts file should be updated on the cluster nodes. When set to true, KET will update the hosts file on all nodes to include entries for all other nodes in the cluster.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false,\n" +
+	"          \"required\": [\n" +
+	"            \"pod_cidr_block\",\n" +
+	"            \"service_cidr_block\"\n" +
+	"          ]\n" +
+	"        },\n" +
+	"        \"ssh\": {\n" +
+	"          \"description\": \"The SSH configuration for the cluster nodes.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"bastion\": {\n" +
+	"              \"description\": \"The bastion host through which the cluster nodes are accessed via SSH. When set, all the SSH connections to the cluster nodes, including the ones made during the installation, are proxied through the bastion host.\",\n" +
+	"              \"type\": [\n" +
+	"                \"object\",\n" +
+	"                \"null\"\n" +
+	"              ],\n" +
+	"              \"properties\": {\n" +
+	"                \"host\": {\n" +
+	"                  \"description\": \"The hostname or IP address of the bastion host.\",\n" +
+	"                  \"type\": \"string\"\n" +
+	"                },\n" +
+	"                \"ssh_key\": {\n" +
+	"                  \"description\": \"The absolute path of the SSH key that should be used for accessing the bastion host via SSH. Defaults to the cluster's SSH key.\",\n" +
+	"                  \"type\": \"string\"\n" +
+	"                },\n" +
+	"                \"ssh_port\": {\n" +
+	"                  \"description\": \"The port number on which the bastion host is listening for SSH connections.\",\n" +
+	"                  \"type\": \"integer\",\n" +
+	"                  \"default\": 22\n" +
+	"                },\n" +
+	"                \"user\": {\n" +
+	"                  \"description\": \"The user for accessing the bastion host via SSH. Defaults to the cluster's SSH user.\",\n" +
+	"                  \"type\": \"string\"\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false,\n" +
+	"              \"required\": [\n" +
+	"                \"host\"\n" +
+	"              ]\n" +
+	"            },\n" +
+	"            \"known_hosts\": {\n" +
+	"              \"description\": \"The absolute path of a known_hosts file with the host keys of the cluster nodes. The keys are added to the known_hosts file in the generated assets directory, so that they are trusted instead of the keys presented on first contact.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ssh_key\": {\n" +
+	"              \"description\": \"The absolute path of the SSH key that should be used for accessing the cluster nodes via SSH. The key may be protected by a passphrase, which is read from the KISMATIC_SSH_KEY_PASSPHRASE environment variable or prompted for once. Can be left empty to use the keys of the ssh-agent running at SSH_AUTH_SOCK.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ssh_port\": {\n" +
+	"              \"description\": \"The port number on which cluster nodes are listening for SSH connections.\",\n" +
+	"              \"type\": \"integer\"\n" +
+	"            },\n" +
+	"            \"user\": {\n" +
+	"              \"description\": \"The user for accessing the cluster nodes via SSH. This user requires sudo elevation privileges on the cluster nodes.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false,\n" +
+	"          \"required\": [\n" +
+	"            \"user\",\n" +
+	"            \"ssh_port\"\n" +
+	"          ]\n" +
+	"        },\n" +
+	"        \"version\": {\n" +
+	"          \"description\": \"The Kubernetes version to install. If left blank will be set to the latest tested version. Only a single Minor version is supported with.\",\n" +
+	"          \"type\": \"string\",\n" +
+	"          \"default\": \"v1.10.11\"\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false,\n" +
+	"      \"required\": [\n" +
+	"        \"name\"\n" +
+	"      ]\n" +
+	"    },\n" +
+	"    \"docker\": {\n" +
+	"      \"description\": \"Configuration for the docker engine installed by KET\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"disable\": {\n" +
+	"          \"description\": \"Set to true to disable the installation of docker container runtime on the nodes. The installer will validate that docker is installed and running prior to proceeding. Use this option if a different version of docker from the included one is required.\",\n" +
+	"          \"type\": \"boolean\",\n" +
+	"          \"default\": false\n" +
+	"        },\n" +
+	"        \"logs\": {\n" +
+	"          \"description\": \"Log configuration for the docker engine.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"driver\": {\n" +
+	"              \"description\": \"Docker logging driver, more details https://docs.docker.com/engine/admin/logging/overview/.\",\n" +
+	"              \"type\": \"string\",\n" +
+	"              \"default\": \"json-file\"\n" +
+	"            },\n" +
+	"            \"opts\": {\n" +
+	"              \"description\": \"Driver specific options.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"additionalProperties\": {\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        },\n" +
+	"        \"storage\": {\n" +
+	"          \"description\": \"Storage configuration for the docker engine.\",\n" +
+	"          \"type\": \"object\",\n" +
+	"          \"properties\": {\n" +
+	"            \"direct_lvm\": {\n" +
+	"              \"description\": \"DirectLVM is the configuration required for setting up device mapper in direct-lvm mode.\",\n" +
+	"              \"type\": [\n" +
+	"                \"object\",\n" +
+	"                \"null\"\n" +
+	"              ],\n" +
+	"              \"properties\": {\n" +
+	"                \"block_device\": {\n" +
+	"                  \"description\": \"The path to the block storage device that will be used by the devicemapper storage driver.\",\n" +
+	"                  \"type\": \"string\"\n" +
+	"                },\n" +
+	"                \"enable_deferred_deletion\": {\n" +
+	"                  \"description\": \"Whether deferred deletion should be enabled when using devicemapper in direct_lvm mode.\",\n" +
+	"                  \"type\": \"boolean\",\n" +
+	"                  \"default\": false\n" +
+	"                },\n" +
+	"                \"enabled\": {\n" +
+	"                  \"description\": \"Whether the direct_lvm mode of the devicemapper storage driver should be enabled. When set to true, a dedicated block storage device must be available on each cluster node.\",\n" +
+	"                  \"type\": \"boolean\",\n" +
+	"                  \"default\": false\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false,\n" +
+	"              \"deprecated\": true\n" +
+	"            },\n" +
+	"            \"direct_lvm_block_device\": {\n" +
+	"              \"description\": \"DirectLVMBlockDevice is the configuration required for setting up Device Mapper storage driver in direct-lvm mode. Refer to https://docs.docker.com/v17.03/engine/userguide/storagedriver/device-mapper-driver/#manage-devicemapper docs.\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"properties\": {\n" +
+	"                \"path\": {\n" +
+	"                  \"description\": \"The path to the block device.\",\n" +
+	"                  \"type\": \"string\"\n" +
+	"                },\n" +
+	"                \"thinpool_autoextend_percent\": {\n" +
+	"                  \"description\": \"The percentage to increase the thin pool by when an autoextend is triggered.\",\n" +
+	"                  \"type\": \"string\",\n" +
+	"                  \"default\": \"20\"\n" +
+	"                },\n" +
+	"                \"thinpool_autoextend_threshold\": {\n" +
+	"                  \"description\": \"The threshold for when lvm should automatically extend the thin pool as a percentage of the total storage space.\",\n" +
+	"                  \"type\": \"string\",\n" +
+	"                  \"default\": \"80\"\n" +
+	"                },\n" +
+	"                \"thinpool_metapercent\": {\n" +
+	"                  \"description\": \"The percentage of space to for metadata storage from the passed in block device.\",\n" +
+	"                  \"type\": \"string\",\n" +
+	"                  \"default\": \"1\"\n" +
+	"                },\n" +
+	"                \"thinpool_percent\": {\n" +
+	"                  \"description\": \"The percentage of space to use for storage from the passed in block device.\",\n" +
+	"                  \"type\": \"string\",\n" +
+	"                  \"default\": \"95\"\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"additionalProperties\": false\n" +
+	"            },\n" +
+	"            \"driver\": {\n" +
+	"              \"description\": \"Docker storage driver, more details https://docs.docker.com/engine/userguide/storagedriver/. Leave empty to have docker automatically select the driver.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"opts\": {\n" +
+	"              \"description\": \"Driver specific options\",\n" +
+	"              \"type\": \"object\",\n" +
+	"              \"additionalProperties\": {\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false\n" +
+	"    },\n" +
+	"    \"docker_registry\": {\n" +
+	"      \"description\": \"Docker registry configuration\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"CA\": {\n" +
+	"          \"description\": \"The absolute path of the Certificate Authority that should be installed on all cluster nodes that have a docker daemon. This is required to establish trust between the daemons and the private registry when the registry is using a self-signed certificate.\",\n" +
+	"          \"type\": \"string\"\n" +
+	"        },\n" +
+	"        \"address\": {\n" +
+	"          \"description\": \"The hostname or IP address of a private container image registry. When performing a disconnected installation, this registry will be used to fetch all the required container images.\",\n" +
+	"          \"type\": \"string\",\n" +
+	"          \"deprecated\": true\n" +
+	"        },\n" +
+	"        \"password\": {\n" +
+	"          \"description\": \"The password that should be used when connecting to a registry that has authentication enabled. Otherwise leave blank for unauthenticated access. Can be a secret reference, such as env:REGISTRY_PASSWORD or file:/path/to/secret.\",\n" +
+	"          \"type\": \"string\"\n" +
+	"        },\n" +
+	"        \"port\": {\n" +
+	"          \"description\": \"The port on which the private container image registry is listening on.\",\n" +
+	"          \"type\": \"integer\",\n" +
+	"          \"deprecated\": true\n" +
+	"        },\n" +
+	"        \"server\": {\n" +
+	"          \"description\": \"The hostname or IP address and port of a private container image registry. Do not include http or https. When performing a disconnected installation, this registry will be used to fetch all the required container images.\",\n" +
+	"          \"type\": \"string\"\n" +
+	"        },\n" +
+	"        \"username\": {\n" +
+	"          \"description\": \"The username that should be used when connecting to a registry that has authentication enabled. Otherwise leave blank for unauthenticated access.\",\n" +
+	"          \"type\": \"string\"\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false\n" +
+	"    },\n" +
+	"    \"etcd\": {\n" +
+	"      \"description\": \"Etcd nodes of the cluster\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"expected_count\": {\n" +
+	"          \"description\": \"Number of nodes.\",\n" +
+	"          \"type\": \"integer\"\n" +
+	"        },\n" +
+	"        \"nodes\": {\n" +
+	"          \"description\": \"List of nodes.\",\n" +
+	"          \"type\": \"array\",\n" +
+	"          \"items\": {\n" +
+	"            \"type\": \"object\",\n" +
+	"            \"properties\": {\n" +
+	"              \"host\": {\n" +
+	"                \"description\": \"The hostname of the node. The hostname is verified in the validation phase of the installation.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"internalip\": {\n" +
+	"                \"description\": \"The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"ip\": {\n" +
+	"                \"description\": \"The IP address of the node. This is the IP address that will be used to connect to the node over SSH.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"kubelet\": {\n" +
+	"                \"description\": \"Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"properties\": {\n" +
+	"                  \"option_overrides\": {\n" +
+	"                    \"description\": \"Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.\",\n" +
+	"                    \"type\": \"object\",\n" +
+	"                    \"additionalProperties\": {\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"labels\": {\n" +
+	"                \"description\": \"Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"additionalProperties\": {\n" +
+	"                  \"type\": \"string\"\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"ssh\": {\n" +
+	"                \"description\": \"SSH configuration of this node. Fields that are not set are inherited from the node group's and the cluster's SSH configuration. If a node is repeated for multiple roles, the resulting SSH configuration cannot be different.\",\n" +
+	"                \"type\": [\n" +
+	"                  \"object\",\n" +
+	"                  \"null\"\n" +
+	"                ],\n" +
+	"                \"properties\": {\n" +
+	"                  \"ssh_key\": {\n" +
+	"                    \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  },\n" +
+	"                  \"ssh_port\": {\n" +
+	"                    \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"                    \"type\": \"integer\"\n" +
+	"                  },\n" +
+	"                  \"user\": {\n" +
+	"                    \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"taints\": {\n" +
+	"                \"description\": \"Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.\",\n" +
+	"                \"type\": \"array\",\n" +
+	"                \"items\": {\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"effect\": {\n" +
+	"                      \"description\": \"Effect for the taint\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"NoSchedule\",\n" +
+	"                        \"PreferNoSchedule\",\n" +
+	"                        \"NoExecute\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"key\": {\n" +
+	"                      \"description\": \"Key for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    },\n" +
+	"                    \"value\": {\n" +
+	"                      \"description\": \"Value for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                }\n" +
+	"              }\n" +
+	"            },\n" +
+	"            \"additionalProperties\": false,\n" +
+	"            \"required\": [\n" +
+	"              \"host\",\n" +
+	"              \"ip\"\n" +
+	"            ]\n" +
+	"          }\n" +
+	"        },\n" +
+	"        \"ssh\": {\n" +
+	"          \"description\": \"SSH configuration of the nodes in this group. Fields that are not set are inherited from the cluster's SSH configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"ssh_key\": {\n" +
+	"              \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ssh_port\": {\n" +
+	"              \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"              \"type\": \"integer\"\n" +
+	"            },\n" +
+	"            \"user\": {\n" +
+	"              \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false,\n" +
+	"      \"required\": [\n" +
+	"        \"expected_count\",\n" +
+	"        \"nodes\"\n" +
+	"      ]\n" +
+	"    },\n" +
+	"    \"features\": {\n" +
+	"      \"description\": \"Feature configuration\",\n" +
+	"      \"type\": [\n" +
+	"        \"object\",\n" +
+	"        \"null\"\n" +
+	"      ],\n" +
+	"      \"properties\": {\n" +
+	"        \"package_manager\": {\n" +
+	"          \"description\": \"The PackageManager feature configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"enabled\": {\n" +
+	"              \"description\": \"Whether the package manager add-on should be enabled.\",\n" +
+	"              \"type\": \"boolean\",\n" +
+	"              \"default\": false,\n" +
+	"              \"deprecated\": true\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false,\n" +
+	"          \"deprecated\": true\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false,\n" +
+	"      \"deprecated\": true\n" +
+	"    },\n" +
+	"    \"ingress\": {\n" +
+	"      \"description\": \"Ingress nodes of the cluster\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"expected_count\": {\n" +
+	"          \"description\": \"Number of nodes.\",\n" +
+	"          \"type\": \"integer\"\n" +
+	"        },\n" +
+	"        \"nodes\": {\n" +
+	"          \"description\": \"List of nodes.\",\n" +
+	"          \"type\": \"array\",\n" +
+	"          \"items\": {\n" +
+	"            \"type\": \"object\",\n" +
+	"            \"properties\": {\n" +
+	"              \"host\": {\n" +
+	"                \"description\": \"The hostname of the node. The hostname is verified in the validation phase of the installation.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"internalip\": {\n" +
+	"                \"description\": \"The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"ip\": {\n" +
+	"                \"description\": \"The IP address of the node. This is the IP address that will be used to connect to the node over SSH.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"kubelet\": {\n" +
+	"                \"description\": \"Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"properties\": {\n" +
+	"                  \"option_overrides\": {\n" +
+	"                    \"description\": \"Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.\",\n" +
+	"                    \"type\": \"object\",\n" +
+	"                    \"additionalProperties\": {\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"labels\": {\n" +
+	"                \"description\": \"Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"additionalProperties\": {\n" +
+	"                  \"type\": \"string\"\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"ssh\": {\n" +
+	"                \"description\": \"SSH configuration of this node. Fields that are not set are inherited from the node group's and the cluster's SSH configuration. If a node is repeated for multiple roles, the resulting SSH configuration cannot be different.\",\n" +
+	"                \"type\": [\n" +
+	"                  \"object\",\n" +
+	"                  \"null\"\n" +
+	"                ],\n" +
+	"                \"properties\": {\n" +
+	"                  \"ssh_key\": {\n" +
+	"                    \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  },\n" +
+	"                  \"ssh_port\": {\n" +
+	"                    \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"                    \"type\": \"integer\"\n" +
+	"                  },\n" +
+	"                  \"user\": {\n" +
+	"                    \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"taints\": {\n" +
+	"                \"description\": \"Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.\",\n" +
+	"                \"type\": \"array\",\n" +
+	"                \"items\": {\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"effect\": {\n" +
+	"                      \"description\": \"Effect for the taint\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"NoSchedule\",\n" +
+	"                        \"PreferNoSchedule\",\n" +
+	"                        \"NoExecute\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"key\": {\n" +
+	"                      \"description\": \"Key for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    },\n" +
+	"                    \"value\": {\n" +
+	"                      \"description\": \"Value for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                }\n" +
+	"              }\n" +
+	"            },\n" +
+	"            \"additionalProperties\": false,\n" +
+	"            \"required\": [\n" +
+	"              \"host\",\n" +
+	"              \"ip\"\n" +
+	"            ]\n" +
+	"          }\n" +
+	"        },\n" +
+	"        \"ssh\": {\n" +
+	"          \"description\": \"SSH configuration of the nodes in this group. Fields that are not set are inherited from the cluster's SSH configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"ssh_key\": {\n" +
+	"              \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ssh_port\": {\n" +
+	"              \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"              \"type\": \"integer\"\n" +
+	"            },\n" +
+	"            \"user\": {\n" +
+	"              \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false,\n" +
+	"      \"required\": [\n" +
+	"        \"expected_count\",\n" +
+	"        \"nodes\"\n" +
+	"      ]\n" +
+	"    },\n" +
+	"    \"master\": {\n" +
+	"      \"description\": \"Master nodes of the cluster\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"expected_count\": {\n" +
+	"          \"description\": \"Number of master nodes that are part of the cluster.\",\n" +
+	"          \"type\": \"integer\"\n" +
+	"        },\n" +
+	"        \"load_balanced_fqdn\": {\n" +
+	"          \"description\": \"The FQDN of the load balancer that is fronting multiple master nodes. In the case where there is only one master node, this can be set to the IP address of the master node.\",\n" +
+	"          \"type\": [\n" +
+	"            \"string\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"deprecated\": true\n" +
+	"        },\n" +
+	"        \"load_balanced_short_name\": {\n" +
+	"          \"description\": \"The short name of the load balancer that is fronting multiple master nodes. In the case where there is only one master node, this can be set to the IP address of the master nodes.\",\n" +
+	"          \"type\": [\n" +
+	"            \"string\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"deprecated\": true\n" +
+	"        },\n" +
+	"        \"load_balancer\": {\n" +
+	"          \"description\": \"The IP or DNS and Port of the load balancer that is fronting multiple master nodes. In the case where there no load balancer this can be set to the IP address of the master node with port '6443'.\",\n" +
+	"          \"type\": \"string\"\n" +
+	"        },\n" +
+	"        \"nodes\": {\n" +
+	"          \"description\": \"List of master nodes that are part of the cluster.\",\n" +
+	"          \"type\": \"array\",\n" +
+	"          \"items\": {\n" +
+	"            \"type\": \"object\",\n" +
+	"            \"properties\": {\n" +
+	"              \"host\": {\n" +
+	"                \"description\": \"The hostname of the node. The hostname is verified in the validation phase of the installation.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"internalip\": {\n" +
+	"                \"description\": \"The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"ip\": {\n" +
+	"                \"description\": \"The IP address of the node. This is the IP address that will be used to connect to the node over SSH.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"kubelet\": {\n" +
+	"                \"description\": \"Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"properties\": {\n" +
+	"                  \"option_overrides\": {\n" +
+	"                    \"description\": \"Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.\",\n" +
+	"                    \"type\": \"object\",\n" +
+	"                    \"additionalProperties\": {\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"labels\": {\n" +
+	"                \"description\": \"Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"additionalProperties\": {\n" +
+	"                  \"type\": \"string\"\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"ssh\": {\n" +
+	"                \"description\": \"SSH configuration of this node. Fields that are not set are inherited from the node group's and the cluster's SSH configuration. If a node is repeated for multiple roles, the resulting SSH configuration cannot be different.\",\n" +
+	"                \"type\": [\n" +
+	"                  \"object\",\n" +
+	"                  \"null\"\n" +
+	"                ],\n" +
+	"                \"properties\": {\n" +
+	"                  \"ssh_key\": {\n" +
+	"                    \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  },\n" +
+	"                  \"ssh_port\": {\n" +
+	"                    \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"                    \"type\": \"integer\"\n" +
+	"                  },\n" +
+	"                  \"user\": {\n" +
+	"                    \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"taints\": {\n" +
+	"                \"description\": \"Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.\",\n" +
+	"                \"type\": \"array\",\n" +
+	"                \"items\": {\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"effect\": {\n" +
+	"                      \"description\": \"Effect for the taint\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"NoSchedule\",\n" +
+	"                        \"PreferNoSchedule\",\n" +
+	"                        \"NoExecute\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"key\": {\n" +
+	"                      \"description\": \"Key for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    },\n" +
+	"                    \"value\": {\n" +
+	"                      \"description\": \"Value for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                }\n" +
+	"              }\n" +
+	"            },\n" +
+	"            \"additionalProperties\": false,\n" +
+	"            \"required\": [\n" +
+	"              \"host\",\n" +
+	"              \"ip\"\n" +
+	"            ]\n" +
+	"          }\n" +
+	"        },\n" +
+	"        \"ssh\": {\n" +
+	"          \"description\": \"SSH configuration of the master nodes. Fields that are not set are inherited from the cluster's SSH configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"ssh_key\": {\n" +
+	"              \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ssh_port\": {\n" +
+	"              \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"              \"type\": \"integer\"\n" +
+	"            },\n" +
+	"            \"user\": {\n" +
+	"              \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false,\n" +
+	"      \"required\": [\n" +
+	"        \"load_balancer\",\n" +
+	"        \"expected_count\",\n" +
+	"        \"nodes\"\n" +
+	"      ]\n" +
+	"    },\n" +
+	"    \"nfs\": {\n" +
+	"      \"description\": \"NFS volumes of the cluster.\",\n" +
+	"      \"type\": [\n" +
+	"        \"object\",\n" +
+	"        \"null\"\n" +
+	"      ],\n" +
+	"      \"properties\": {\n" +
+	"        \"nfs_volume\": {\n" +
+	"          \"description\": \"List of NFS volumes that should be attached to the cluster during the installation.\",\n" +
+	"          \"type\": \"array\",\n" +
+	"          \"items\": {\n" +
+	"            \"type\": \"object\",\n" +
+	"            \"properties\": {\n" +
+	"              \"mount_path\": {\n" +
+	"                \"description\": \"The path where the NFS volume should be mounted.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"nfs_host\": {\n" +
+	"                \"description\": \"The hostname or IP of the NFS volume.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              }\n" +
+	"            },\n" +
+	"            \"additionalProperties\": false,\n" +
+	"            \"required\": [\n" +
+	"              \"nfs_host\",\n" +
+	"              \"mount_path\"\n" +
+	"            ]\n" +
+	"          }\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false\n" +
+	"    },\n" +
+	"    \"storage\": {\n" +
+	"      \"description\": \"Storage nodes of the cluster.\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"expected_count\": {\n" +
+	"          \"description\": \"Number of nodes.\",\n" +
+	"          \"type\": \"integer\"\n" +
+	"        },\n" +
+	"        \"nodes\": {\n" +
+	"          \"description\": \"List of nodes.\",\n" +
+	"          \"type\": \"array\",\n" +
+	"          \"items\": {\n" +
+	"            \"type\": \"object\",\n" +
+	"            \"properties\": {\n" +
+	"              \"host\": {\n" +
+	"                \"description\": \"The hostname of the node. The hostname is verified in the validation phase of the installation.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"internalip\": {\n" +
+	"                \"description\": \"The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"ip\": {\n" +
+	"                \"description\": \"The IP address of the node. This is the IP address that will be used to connect to the node over SSH.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"kubelet\": {\n" +
+	"                \"description\": \"Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"properties\": {\n" +
+	"                  \"option_overrides\": {\n" +
+	"                    \"description\": \"Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.\",\n" +
+	"                    \"type\": \"object\",\n" +
+	"                    \"additionalProperties\": {\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"labels\": {\n" +
+	"                \"description\": \"Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"additionalProperties\": {\n" +
+	"                  \"type\": \"string\"\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"ssh\": {\n" +
+	"                \"description\": \"SSH configuration of this node. Fields that are not set are inherited from the node group's and the cluster's SSH configuration. If a node is repeated for multiple roles, the resulting SSH configuration cannot be different.\",\n" +
+	"                \"type\": [\n" +
+	"                  \"object\",\n" +
+	"                  \"null\"\n" +
+	"                ],\n" +
+	"                \"properties\": {\n" +
+	"                  \"ssh_key\": {\n" +
+	"                    \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  },\n" +
+	"                  \"ssh_port\": {\n" +
+	"                    \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"                    \"type\": \"integer\"\n" +
+	"                  },\n" +
+	"                  \"user\": {\n" +
+	"                    \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"taints\": {\n" +
+	"                \"description\": \"Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.\",\n" +
+	"                \"type\": \"array\",\n" +
+	"                \"items\": {\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"effect\": {\n" +
+	"                      \"description\": \"Effect for the taint\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"NoSchedule\",\n" +
+	"                        \"PreferNoSchedule\",\n" +
+	"                        \"NoExecute\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"key\": {\n" +
+	"                      \"description\": \"Key for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    },\n" +
+	"                    \"value\": {\n" +
+	"                      \"description\": \"Value for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                }\n" +
+	"              }\n" +
+	"            },\n" +
+	"            \"additionalProperties\": false,\n" +
+	"            \"required\": [\n" +
+	"              \"host\",\n" +
+	"              \"ip\"\n" +
+	"            ]\n" +
+	"          }\n" +
+	"        },\n" +
+	"        \"ssh\": {\n" +
+	"          \"description\": \"SSH configuration of the nodes in this group. Fields that are not set are inherited from the cluster's SSH configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"ssh_key\": {\n" +
+	"              \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ssh_port\": {\n" +
+	"              \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"              \"type\": \"integer\"\n" +
+	"            },\n" +
+	"            \"user\": {\n" +
+	"              \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false,\n" +
+	"      \"required\": [\n" +
+	"        \"expected_count\",\n" +
+	"        \"nodes\"\n" +
+	"      ]\n" +
+	"    },\n" +
+	"    \"worker\": {\n" +
+	"      \"description\": \"Worker nodes of the cluster\",\n" +
+	"      \"type\": \"object\",\n" +
+	"      \"properties\": {\n" +
+	"        \"expected_count\": {\n" +
+	"          \"description\": \"Number of nodes.\",\n" +
+	"          \"type\": \"integer\"\n" +
+	"        },\n" +
+	"        \"nodes\": {\n" +
+	"          \"description\": \"List of nodes.\",\n" +
+	"          \"type\": \"array\",\n" +
+	"          \"items\": {\n" +
+	"            \"type\": \"object\",\n" +
+	"            \"properties\": {\n" +
+	"              \"host\": {\n" +
+	"                \"description\": \"The hostname of the node. The hostname is verified in the validation phase of the installation.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"internalip\": {\n" +
+	"                \"description\": \"The internal (or private) IP address of the node. If set, this IP will be used when configuring cluster components.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"ip\": {\n" +
+	"                \"description\": \"The IP address of the node. This is the IP address that will be used to connect to the node over SSH.\",\n" +
+	"                \"type\": \"string\"\n" +
+	"              },\n" +
+	"              \"kubelet\": {\n" +
+	"                \"description\": \"Kubelet configuration applied to this node. If a node is repeated for multiple roles, the overrides cannot be different.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"properties\": {\n" +
+	"                  \"option_overrides\": {\n" +
+	"                    \"description\": \"Listing of option overrides that are to be applied to the Kubelet configurations. This is an advanced feature that can prevent the Kubelet from starting up if invalid configuration is provided.\",\n" +
+	"                    \"type\": \"object\",\n" +
+	"                    \"additionalProperties\": {\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"labels\": {\n" +
+	"                \"description\": \"Labels to add when installing the node in the cluster. If a node is defined under multiple roles, the labels for that node will be merged. If a label is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence. It is recommended to use reverse-DNS notation to avoid collision with other labels.\",\n" +
+	"                \"type\": \"object\",\n" +
+	"                \"additionalProperties\": {\n" +
+	"                  \"type\": \"string\"\n" +
+	"                }\n" +
+	"              },\n" +
+	"              \"ssh\": {\n" +
+	"                \"description\": \"SSH configuration of this node. Fields that are not set are inherited from the node group's and the cluster's SSH configuration. If a node is repeated for multiple roles, the resulting SSH configuration cannot be different.\",\n" +
+	"                \"type\": [\n" +
+	"                  \"object\",\n" +
+	"                  \"null\"\n" +
+	"                ],\n" +
+	"                \"properties\": {\n" +
+	"                  \"ssh_key\": {\n" +
+	"                    \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  },\n" +
+	"                  \"ssh_port\": {\n" +
+	"                    \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"                    \"type\": \"integer\"\n" +
+	"                  },\n" +
+	"                  \"user\": {\n" +
+	"                    \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"                    \"type\": \"string\"\n" +
+	"                  }\n" +
+	"                },\n" +
+	"                \"additionalProperties\": false\n" +
+	"              },\n" +
+	"              \"taints\": {\n" +
+	"                \"description\": \"Taints to add when installing the node in the cluster. If a node is defined under multiple roles, the taints for that node will be merged. If a taint is repeated for the same node, only one will be used in this order: etcd,master,worker,ingress,storage roles where 'storage' has the highest precedence.\",\n" +
+	"                \"type\": \"array\",\n" +
+	"                \"items\": {\n" +
+	"                  \"type\": \"object\",\n" +
+	"                  \"properties\": {\n" +
+	"                    \"effect\": {\n" +
+	"                      \"description\": \"Effect for the taint\",\n" +
+	"                      \"type\": \"string\",\n" +
+	"                      \"enum\": [\n" +
+	"                        \"\",\n" +
+	"                        \"NoSchedule\",\n" +
+	"                        \"PreferNoSchedule\",\n" +
+	"                        \"NoExecute\"\n" +
+	"                      ]\n" +
+	"                    },\n" +
+	"                    \"key\": {\n" +
+	"                      \"description\": \"Key for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    },\n" +
+	"                    \"value\": {\n" +
+	"                      \"description\": \"Value for the taint\",\n" +
+	"                      \"type\": \"string\"\n" +
+	"                    }\n" +
+	"                  },\n" +
+	"                  \"additionalProperties\": false\n" +
+	"                }\n" +
+	"              }\n" +
+	"            },\n" +
+	"            \"additionalProperties\": false,\n" +
+	"            \"required\": [\n" +
+	"              \"host\",\n" +
+	"              \"ip\"\n" +
+	"            ]\n" +
+	"          }\n" +
+	"        },\n" +
+	"        \"ssh\": {\n" +
+	"          \"description\": \"SSH configuration of the nodes in this group. Fields that are not set are inherited from the cluster's SSH configuration.\",\n" +
+	"          \"type\": [\n" +
+	"            \"object\",\n" +
+	"            \"null\"\n" +
+	"          ],\n" +
+	"          \"properties\": {\n" +
+	"            \"ssh_key\": {\n" +
+	"              \"description\": \"The absolute path of the SSH key that should be used for accessing the nodes via SSH.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            },\n" +
+	"            \"ssh_port\": {\n" +
+	"              \"description\": \"The port number on which the nodes are listening for SSH connections.\",\n" +
+	"              \"type\": \"integer\"\n" +
+	"            },\n" +
+	"            \"user\": {\n" +
+	"              \"description\": \"The user for accessing the nodes via SSH. This user requires sudo elevation privileges on the nodes.\",\n" +
+	"              \"type\": \"string\"\n" +
+	"            }\n" +
+	"          },\n" +
+	"          \"additionalProperties\": false\n" +
+	"        }\n" +
+	"      },\n" +
+	"      \"additionalProperties\": false,\n" +
+	"      \"required\": [\n" +
+	"        \"expected_count\",\n" +
+	"        \"nodes\"\n" +
+	"      ]\n" +
+	"    }\n" +
+	"  },\n" +
+	"  \"additionalProperties\": false,\n" +
+	"  \"required\": [\n" +
+	"    \"cluster\",\n" +
+	"    \"etcd\",\n" +
+	"    \"master\",\n" +
+	"    \"worker\"\n" +
+	"  ]\n" +
+	"}"
@@ -0,0 +1,71 @@
+package install
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+type testSchema struct {
+	Properties map[string]*testSchema `json:"properties"`
+	Items      *testSchema            `json:"items"`
+}
+
+// verifies that every key of the plan file is described by the JSON schema
+func checkSchemaKeys(schema *testSchema, value interface{}, path string) []error {
+	errs := []error{}
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		// maps without properties (e.g. option overrides) accept any key
+		if schema.Properties == nil {
+			return errs
+		}
+		for k, child := range v {
+			key := fmt.Sprint(k)
+			s, ok := schema.Properties[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s%s is not in the JSON schema", path, key))
+				continue
+			}
+			errs = append(errs, checkSchemaKeys(s, child, path+key+".")...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			if schema.Items == nil {
+				errs = append(errs, fmt.Errorf("%s is not an array in the JSON schema", path))
+				break
+			}
+			errs = append(errs, checkSchemaKeys(schema.Items, child, path)...)
+		}
+	}
+	return errs
+}
+
+func TestPlanJSONSchemaDescribesPlanFile(t *testing.T) {
+	schema := &testSchema{}
+	if err := json.Unmarshal([]byte(PlanJSONSchema), schema); err != nil {
+		t.Fatalf("plan JSON schema is not valid JSON: %v", err)
+	}
+	p := buildPlanFromTemplateOptions(PlanTemplateOptions{
+		EtcdNodes:       1,
+		MasterNodes:     1,
+		WorkerNodes:     1,
+		IngressNodes:    1,
+		StorageNodes:    1,
+		AdditionalFiles: 1,
+	})
+	p.NFS = &NFS{Volumes: []NFSVolume{{Host: "nfs", Path: "/"}}}
+	b, err := yaml.Marshal(p)
+	if err != nil {
+		t.Fatalf("error marshalling plan: %v", err)
+	}
+	var planFile map[interface{}]interface{}
+	if err := yaml.Unmarshal(b, &planFile); err != nil {
+		t.Fatalf("error unmarshalling plan: %v", err)
+	}
+	for _, err := range checkSchemaKeys(schema, planFile, "") {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,170 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Sensitive plan fields can be set to a reference to a secret that is kept outside
+// of the plan file. References are resolved in memory when the plan is read:
+//
+//   - "env:NAME" is replaced by the value of the NAME environment variable
+//   - "file:/path/to/secret" is replaced by the contents of the file, without the
+//     trailing newline. For the cloud provider config, which is itself a path to a file,
+//     the reference is replaced by the path of the file.
+//
+// Plan files that are written back, such as by add-node, keep the references instead
+// of the secret values. Plan files that are recorded in the runs directory also contain
+// the references, and sensitive fields that were set in plain text are redacted.
+// The cluster catalogs recorded in the runs directory are redacted by the ansible runner.
+const (
+	secretRefEnvPrefix  = "env:"
+	secretRefFilePrefix = "file:"
+	// redactedSecretValue replaces the value of a sensitive field that was set in plain text
+	redactedSecretValue = "<redacted>"
+)
+
+// secretField is a plan field that can be set with a secret reference
+type secretField struct {
+	// the path of the field in the plan file
+	name string
+	// returns the field of the plan, or nil if the field is not set
+	field func(p *Plan) *string
+	// the field is a path, so a file reference resolves to the path of the file
+	isPath bool
+}
+
+var secretFields = []secretField{
+	{
+		name:  "cluster.admin_password",
+		field: func(p *Plan) *string { return &p.Cluster.AdminPassword },
+	},
+	{
+		name:   "cluster.cloud_provider.config",
+		field:  func(p *Plan) *string { return &p.Cluster.CloudProvider.Config },
+		isPath: true,
+	},
+	{
+		name:  "docker_registry.password",
+		field: func(p *Plan) *string { return &p.DockerRegistry.Password },
+	},
+	{
+		name: "add_ons.cni.options.weave.password",
+		field: func(p *Plan) *string {
+			if p.AddOns.CNI == nil {
+				return nil
+			}
+			return &p.AddOns.CNI.Options.Weave.Password
+		},
+	},
+}
+
+// secretRef is the secret reference of a sensitive field, and the value it was resolved to
+type secretRef struct {
+	ref   string
+	value string
+}
+
+func isSecretRef(value string) bool {
+	return strings.HasPrefix(value, secretRefEnvPrefix) || strings.HasPrefix(value, secretRefFilePrefix)
+}
+
+// resolveSecretRefs replaces the secret references of the plan with the secret values.
+// The references are kept in the plan, so that they can be recorded instead of the secrets.
+func resolveSecretRefs(p *Plan) error {
+	for _, sf := range secretFields {
+		v := sf.field(p)
+		if v == nil || !isSecretRef(*v) {
+			continue
+		}
+		secret, err := resolveSecretRef(*v, sf.isPath)
+		if err != nil {
+			return fmt.Errorf("error resolving secret reference of %s: %v", sf.name, err)
+		}
+		if p.secretRefs == nil {
+			p.secretRefs = map[string]secretRef{}
+		}
+		p.secretRefs[sf.name] = secretRef{ref: *v, value: secret}
+		*v = secret
+	}
+	return nil
+}
+
+// keepSecretRefs records the secret references of the plan as if they were resolved to
+// themselves, so that the plan compares equal to plans where the same references were resolved.
+func keepSecretRefs(p *Plan) {
+	for _, sf := range secretFields {
+		v := sf.field(p)
+		if v == nil || !isSecretRef(*v) {
+			continue
+		}
+		if p.secretRefs == nil {
+			p.secretRefs = map[string]secretRef{}
+		}
+		p.secretRefs[sf.name] = secretRef{ref: *v, value: *v}
+	}
+}
+
+func resolveSecretRef(ref string, isPath bool) (string, error) {
+	if strings.HasPrefix(ref, secretRefEnvPrefix) {
+		name := strings.TrimPrefix(ref, secretRefEnvPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %q is not set", name)
+		}
+		return v, nil
+	}
+	path := strings.TrimPrefix(ref, secretRefFilePrefix)
+	if isPath {
+		if _, err := os.Stat(path); err != nil {
+			return "", err
+		}
+		return path, nil
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// WithSecretRefs returns a copy of the plan where the sensitive fields that were set with a
+// secret reference are set back to the reference. A field that was changed after the
+// reference was resolved keeps its new value.
+func WithSecretRefs(p *Plan) *Plan {
+	r := *p
+	if p.AddOns.CNI != nil {
+		cni := *p.AddOns.CNI
+		r.AddOns.CNI = &cni
+	}
+	for _, sf := range secretFields {
+		v := sf.field(&r)
+		if v == nil {
+			continue
+		}
+		if sr, ok := p.secretRefs[sf.name]; ok && *v == sr.value {
+			*v = sr.ref
+		}
+	}
+	return &r
+}
+
+// redactedPlan returns a copy of the plan that does not contain secrets. Sensitive fields
+// that were set with a secret reference are set back to the reference, and the
+// ones that were set in plain text are redacted.
+func redactedPlan(p *Plan) *Plan {
+	r := WithSecretRefs(p)
+	for _, sf := range secretFields {
+		v := sf.field(r)
+		if v == nil || *v == "" {
+			continue
+		}
+		if sr, ok := p.secretRefs[sf.name]; ok && *v == sr.ref {
+			continue
+		}
+		*v = redactedSecretValue
+	}
+	return r
+}
@@ -0,0 +1,171 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadPlanResolvesSecretRefs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-secret-refs")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	passwordFile := filepath.Join(tmp, "weave-password")
+	if err = ioutil.WriteFile(passwordFile, []byte("weavesecret\n"), 0600); err != nil {
+		t.Fatalf("error writing secret file: %v", err)
+	}
+	cloudConfig := filepath.Join(tmp, "cloud.conf")
+	if err = ioutil.WriteFile(cloudConfig, []byte("[Global]\n"), 0600); err != nil {
+		t.Fatalf("error writing secret file: %v", err)
+	}
+	os.Setenv("KET_TEST_REGISTRY_PASSWORD", "registrysecret")
+	defer os.Unsetenv("KET_TEST_REGISTRY_PASSWORD")
+
+	plan := `cluster:
+  admin_password: plainsecret
+  cloud_provider:
+    provider: aws
+    config: file:` + cloudConfig + `
+docker_registry:
+  server: registry:443
+  username: admin
+  password: env:KET_TEST_REGISTRY_PASSWORD
+add_ons:
+  cni:
+    provider: weave
+    options:
+      weave:
+        password: file:` + passwordFile + `
+`
+	file := filepath.Join(tmp, "kismatic-cluster.yaml")
+	if err = ioutil.WriteFile(file, []byte(plan), 0644); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	p, err := (&FilePlanner{File: file}).Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading plan: %v", err)
+	}
+	if p.DockerRegistry.Password != "registrysecret" {
+		t.Errorf("expected the registry password to be read from the environment, but got %q", p.DockerRegistry.Password)
+	}
+	if p.AddOns.CNI.Options.Weave.Password != "weavesecret" {
+		t.Errorf("expected the weave password to be read from the file, but got %q", p.AddOns.CNI.Options.Weave.Password)
+	}
+	if p.Cluster.CloudProvider.Config != cloudConfig {
+		t.Errorf("expected the cloud config to be the referenced file, but got %q", p.Cluster.CloudProvider.Config)
+	}
+
+	// the recorded plan should not contain any secret
+	recorded := filepath.Join(tmp, "recorded.yaml")
+	if err = (&FilePlanner{File: recorded}).Write(redactedPlan(p)); err != nil {
+		t.Fatalf("error writing redacted plan: %v", err)
+	}
+	r, err := (&FilePlanner{File: recorded}).Read()
+	if err != nil {
+		t.Fatalf("error reading redacted plan: %v", err)
+	}
+	if r.DockerRegistry.Password != "registrysecret" || r.secretRefs["docker_registry.password"].ref != "env:KET_TEST_REGISTRY_PASSWORD" {
+		t.Errorf("expected the recorded plan to contain the registry password reference")
+	}
+	b, err := ioutil.ReadFile(recorded)
+	if err != nil {
+		t.Fatalf("error reading redacted plan: %v", err)
+	}
+	for _, secret := range []string{"plainsecret", "registrysecret", "weavesecret"} {
+		if strings.Contains(string(b), secret) {
+			t.Errorf("expected the recorded plan not to contain %q", secret)
+		}
+	}
+	if p.Cluster.AdminPassword != "plainsecret" || p.AddOns.CNI.Options.Weave.Password != "weavesecret" {
+		t.Errorf("expected the plan not to be modified by redaction")
+	}
+}
+
+func TestReadPlanUnsetSecretRef(t *testing.T) {
+	p := &Plan{}
+	p.DockerRegistry.Password = "env:KET_TEST_UNSET_VARIABLE"
+	os.Unsetenv("KET_TEST_UNSET_VARIABLE")
+	if err := resolveSecretRefs(p); err == nil {
+		t.Errorf("expected an error resolving a reference to an unset environment variable")
+	}
+	p.DockerRegistry.Password = "file:/does/not/exist"
+	if err := resolveSecretRefs(p); err == nil {
+		t.Errorf("expected an error resolving a reference to a missing file")
+	}
+}
+
+func TestDiffPlansComparesSecretRefs(t *testing.T) {
+	old := diffTestPlan()
+	old.DockerRegistry.Password = redactedSecretValue
+	new := diffTestPlan()
+	new.DockerRegistry.Password = "secret"
+	d, err := DiffPlans(old, new)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.Empty() {
+		t.Errorf("expected a redacted password to be equal to a plain text password, but got %+v", d)
+	}
+}
+
+func TestDiffPlansWithRecordedSecretRefs(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-secret-refs")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	os.Setenv("KET_TEST_REGISTRY_PASSWORD", "registrysecret")
+	defer os.Unsetenv("KET_TEST_REGISTRY_PASSWORD")
+	if err = ioutil.WriteFile(filepath.Join(tmp, "weave-password"), []byte("weavesecret"), 0600); err != nil {
+		t.Fatalf("error writing secret file: %v", err)
+	}
+	plan := diffTestPlan()
+	plan.DockerRegistry.Password = "env:KET_TEST_REGISTRY_PASSWORD"
+	plan.AddOns.CNI = &CNI{Provider: "weave"}
+	plan.AddOns.CNI.Options.Weave.Password = "file:" + filepath.Join(tmp, "weave-password")
+	file := filepath.Join(tmp, "kismatic-cluster.yaml")
+	if err = (&FilePlanner{File: file}).Write(plan); err != nil {
+		t.Fatalf("error writing plan file: %v", err)
+	}
+	p, err := (&FilePlanner{File: file}).Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading plan: %v", err)
+	}
+	recorded := filepath.Join(tmp, "recorded.yaml")
+	if err = (&FilePlanner{File: recorded}).Write(redactedPlan(p)); err != nil {
+		t.Fatalf("error writing redacted plan: %v", err)
+	}
+
+	// the secrets behind the references of the recorded plan are gone
+	os.Unsetenv("KET_TEST_REGISTRY_PASSWORD")
+	os.Remove(filepath.Join(tmp, "weave-password"))
+	if _, err = (&FilePlanner{File: recorded}).Read(); err == nil {
+		t.Errorf("expected an error resolving the secret references of the recorded plan")
+	}
+	r, err := (&FilePlanner{File: recorded, KeepSecretRefs: true}).Read()
+	if err != nil {
+		t.Fatalf("unexpected error reading the recorded plan without resolving its secret references: %v", err)
+	}
+	if r.DockerRegistry.Password != "env:KET_TEST_REGISTRY_PASSWORD" {
+		t.Errorf("expected the registry password reference to be kept, but got %q", r.DockerRegistry.Password)
+	}
+	d, err := DiffPlans(r, p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.Empty() {
+		t.Errorf("expected the recorded plan to be equal to the plan with the same secret references, but got %+v", d)
+	}
+	p = WithSecretRefs(p)
+	p.DockerRegistry.Password = "env:KET_TEST_OTHER_PASSWORD"
+	if d, err = DiffPlans(r, p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.Empty() {
+		t.Errorf("expected a changed secret reference to be a difference")
+	}
+}
@@ -0,0 +1,137 @@
+package install
+
+import (
+	"fmt"
+
+	"github.com/apprenda/kismatic/pkg/ssh"
+)
+
+// withOverride returns the SSH configuration with the fields that are set in the override
+func (s SSHConfig) withOverride(o *NodeSSHConfig) SSHConfig {
+	if o == nil {
+		return s
+	}
+	if o.User != "" {
+		s.User = o.User
+	}
+	if o.Key != "" {
+		s.Key = o.Key
+	}
+	if o.Port != 0 {
+		s.Port = o.Port
+	}
+	return s
+}
+
+// SSHBastion returns the bastion that SSH connections are proxied through,
+// or nil if the nodes are accessed directly
+func (s SSHConfig) SSHBastion() *ssh.Bastion {
+	if s.Bastion == nil {
+		return nil
+	}
+	return &ssh.Bastion{
+		Host: s.Bastion.Host,
+		Port: s.Bastion.Port,
+		User: s.Bastion.User,
+		Key:  s.Bastion.Key,
+	}
+}
+
+// setBastionDefaults sets the bastion's unset fields to the cluster's SSH configuration
+func (s *SSHConfig) setBastionDefaults() {
+	if s.Bastion == nil {
+		return
+	}
+	if s.Bastion.User == "" {
+		s.Bastion.User = s.User
+	}
+	if s.Bastion.Key == "" {
+		s.Bastion.Key = s.Key
+	}
+	if s.Bastion.Port == 0 {
+		s.Bastion.Port = 22
+	}
+}
+
+// ImportKnownHosts adds the host keys of the plan's known_hosts file to the
+// known_hosts file that is used for verifying the host keys of the nodes, replacing
+// the keys that were recorded for the same hosts
+func (p *Plan) ImportKnownHosts() error {
+	if p.Cluster.SSH.KnownHosts == "" {
+		return nil
+	}
+	if _, err := ssh.ImportKnownHosts(p.Cluster.SSH.KnownHosts); err != nil {
+		return fmt.Errorf("error importing host keys: %v", err)
+	}
+	return nil
+}
+
+type nodeGroupSSH struct {
+	name  string
+	ssh   *NodeSSHConfig
+	nodes []Node
+}
+
+func (p *Plan) nodeGroupsSSH() []nodeGroupSSH {
+	return []nodeGroupSSH{
+		{name: "etcd", ssh: p.Etcd.SSH, nodes: p.Etcd.Nodes},
+		{name: "master", ssh: p.Master.SSH, nodes: p.Master.Nodes},
+		{name: "worker", ssh: p.Worker.SSH, nodes: p.Worker.Nodes},
+		{name: "ingress", ssh: p.Ingress.SSH, nodes: p.Ingress.Nodes},
+		{name: "storage", ssh: p.Storage.SSH, nodes: p.Storage.Nodes},
+	}
+}
+
+// nodeSSHConfig returns the SSH configuration of a node that belongs to a node group
+// with the given SSH configuration
+func (p *Plan) nodeSSHConfig(group *NodeSSHConfig, n Node) SSHConfig {
+	return p.Cluster.SSH.withOverride(group).withOverride(n.SSH)
+}
+
+// SSHConfigForNode returns the SSH configuration that is used for accessing the node.
+// The cluster's SSH configuration is overridden by the SSH configuration of the
+// first node group that contains the node, and then by the node's SSH configuration.
+func (p *Plan) SSHConfigForNode(n Node) SSHConfig {
+	for _, g := range p.nodeGroupsSSH() {
+		for _, gn := range g.nodes {
+			if gn.Equal(n) {
+				return p.nodeSSHConfig(g.ssh, gn)
+			}
+		}
+	}
+	return p.Cluster.SSH.withOverride(n.SSH)
+}
+
+// validateNodeSSHConfigs verifies that the SSH configuration of the nodes is valid,
+// and that nodes with multiple roles have the same SSH configuration in all node groups
+func (p *Plan) validateNodeSSHConfigs() (bool, []error) {
+	v := newValidator()
+	type resolved struct {
+		group string
+		ssh   SSHConfig
+	}
+	seen := map[string]resolved{}
+	validated := map[SSHConfig]bool{p.Cluster.SSH: true}
+	for _, g := range p.nodeGroupsSSH() {
+		for _, n := range g.nodes {
+			s := p.nodeSSHConfig(g.ssh, n)
+			if r, ok := seen[n.HashCode()]; ok {
+				if r.ssh != s {
+					v.addError(fmt.Errorf("Node %q has a different SSH configuration in the %s and %s node groups", n.Host, r.group, g.name))
+				}
+				continue
+			}
+			seen[n.HashCode()] = resolved{group: g.name, ssh: s}
+			if validated[s] {
+				continue
+			}
+			validated[s] = true
+			if ok, errs := s.validate(); !ok {
+				for _, err := range errs {
+					v.addError(fmt.Errorf("Node %q SSH configuration: %v", n.Host, err))
+				}
+			}
+		}
+	}
+	return v.valid()
+}
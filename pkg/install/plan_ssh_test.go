@@ -0,0 +1,123 @@
+package install
+
+import (
+	"testing"
+)
+
+func sshTestPlan() *Plan {
+	p := &Plan{}
+	p.Cluster.SSH = SSHConfig{User: "root", Key: "/bin/sh", Port: 22}
+	p.Etcd.SSH = &NodeSSHConfig{User: "etcdadmin", Port: 2222}
+	p.Etcd.Nodes = []Node{{Host: "etcd01", IP: "10.0.0.1"}}
+	p.Master.Nodes = []Node{{Host: "master01", IP: "10.0.0.2", SSH: &NodeSSHConfig{Key: "/bin/bash"}}}
+	p.Worker.Nodes = []Node{{Host: "worker01", IP: "10.0.0.3"}}
+	return p
+}
+
+func TestSSHConfigForNode(t *testing.T) {
+	p := sshTestPlan()
+	tests := []struct {
+		node     Node
+		expected SSHConfig
+	}{
+		{p.Etcd.Nodes[0], SSHConfig{User: "etcdadmin", Key: "/bin/sh", Port: 2222}},
+		{p.Master.Nodes[0], SSHConfig{User: "root", Key: "/bin/bash", Port: 22}},
+		{p.Worker.Nodes[0], p.Cluster.SSH},
+	}
+	for _, test := range tests {
+		if got := p.SSHConfigForNode(test.node); got != test.expected {
+			t.Errorf("node %s: expected SSH config %+v, but got %+v", test.node.Host, test.expected, got)
+		}
+	}
+	con, err := p.GetSSHConnection("etcd")
+	if err != nil {
+		t.Fatalf("unexpected error getting SSH connection: %v", err)
+	}
+	if *con.SSHConfig != tests[0].expected {
+		t.Errorf("expected SSH connection to use the etcd SSH config, but got %+v", *con.SSHConfig)
+	}
+}
+
+func TestBuildInventoryUsesNodeSSHConfig(t *testing.T) {
+	p := sshTestPlan()
+	inv := buildInventoryFromPlan(p)
+	for _, r := range inv.Roles {
+		for _, n := range r.Nodes {
+			expected := p.Cluster.SSH
+			switch n.Host {
+			case "etcd01":
+				expected = SSHConfig{User: "etcdadmin", Key: "/bin/sh", Port: 2222}
+			case "master01":
+				expected = SSHConfig{User: "root", Key: "/bin/bash", Port: 22}
+			}
+			if n.SSHUser != expected.User || n.SSHPrivateKey != expected.Key || n.SSHPort != expected.Port {
+				t.Errorf("node %s: expected SSH config %+v in the inventory, but got %+v", n.Host, expected, n)
+			}
+		}
+	}
+}
+
+func TestValidateNodeSSHConfigs(t *testing.T) {
+	p := sshTestPlan()
+	if ok, errs := p.validateNodeSSHConfigs(); !ok {
+		t.Errorf("expected node SSH configs to be valid, but got %v", errs)
+	}
+
+	// a node that is both etcd and worker must have the same SSH config in both groups
+	p.Worker.Nodes = append(p.Worker.Nodes, p.Etcd.Nodes[0])
+	if ok, _ := p.validateNodeSSHConfigs(); ok {
+		t.Errorf("expected an error when a node has different SSH configs in its node groups")
+	}
+	p.Worker.Nodes[1].SSH = &NodeSSHConfig{User: "etcdadmin", Port: 2222}
+	if ok, errs := p.validateNodeSSHConfigs(); !ok {
+		t.Errorf("expected node SSH configs to be valid, but got %v", errs)
+	}
+
+	p.Master.Nodes[0].SSH.Key = "relative.key"
+	if ok, _ := p.validateNodeSSHConfigs(); ok {
+		t.Errorf("expected an error when a node SSH key is not an absolute path")
+	}
+}
+
+func TestSSHBastion(t *testing.T) {
+	p := sshTestPlan()
+	p.Cluster.SSH.Bastion = &SSHBastion{Host: "bastion.example.com"}
+	p.Cluster.SSH.setBastionDefaults()
+	expected := SSHBastion{Host: "bastion.example.com", User: "root", Key: "/bin/sh", Port: 22}
+	if *p.Cluster.SSH.Bastion != expected {
+		t.Errorf("expected the bastion to default to the cluster SSH config, but got %+v", *p.Cluster.SSH.Bastion)
+	}
+
+	// nodes with an SSH override are still accessed through the bastion
+	s := p.SSHConfigForNode(p.Etcd.Nodes[0])
+	b := s.SSHBastion()
+	if b == nil || b.Host != expected.Host || b.User != expected.User || b.Key != expected.Key || b.Port != expected.Port {
+		t.Errorf("expected the etcd node to be accessed through the bastion, but got %+v", b)
+	}
+	inv := buildInventoryFromPlan(p)
+	for _, r := range inv.Roles {
+		for _, n := range r.Nodes {
+			if n.SSHBastion == nil || n.SSHBastion.Host != expected.Host || n.SSHBastion.SSHUser != expected.User {
+				t.Errorf("node %s: expected the inventory to proxy through the bastion, but got %+v", n.Host, n.SSHBastion)
+			}
+		}
+	}
+	if ok, errs := p.validateNodeSSHConfigs(); !ok {
+		t.Errorf("expected node SSH configs to be valid, but got %v", errs)
+	}
+
+	p.Cluster.SSH.Bastion.Host = ""
+	if ok, _ := p.Cluster.SSH.validate(); ok {
+		t.Errorf("expected an error when the bastion host is not set")
+	}
+	p.Cluster.SSH.Bastion.Host = "bastion.example.com"
+	p.Cluster.SSH.Bastion.Key = "relative.key"
+	if ok, _ := p.Cluster.SSH.validate(); ok {
+		t.Errorf("expected an error when the bastion key is not an absolute path")
+	}
+	p.Cluster.SSH.Bastion.Key = "/bin/sh"
+	p.Cluster.SSH.Bastion.Port = 0
+	if ok, _ := p.Cluster.SSH.validate(); ok {
+		t.Errorf("expected an error when the bastion port is 0")
+	}
+}
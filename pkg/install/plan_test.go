@@ -48,7 +48,7 @@ func TestWritePlanTemplate(t *testing.T) {
 			t.Fatalf("error creating temp dir: %v", err)
 		}
 		file := filepath.Join(tmp, "kismatic-cluster.yaml")
-		fp := &FilePlanner{file}
+		fp := &FilePlanner{File: file}
 		if err = WritePlanTemplate(test.template, fp); err != nil {
 			t.Fatalf("error writing plan template: %v", err)
 		}
@@ -81,7 +81,9 @@ func TestReadWithDeprecated(t *testing.T) {
 	lb := "lb"
 	p.Master.LoadBalancedFQDN = &lb
 	p.Master.LoadBalancedShortName = &lb
-	readDeprecatedFields(p)
+	if _, err := MigratePlan(p); err != nil {
+		t.Fatalf("unexpected error migrating plan: %v", err)
+	}
 
 	if p.Cluster.Certificates.APIServerCertExtraSANs != "lb" {
 		t.Errorf("Expected master.load_balanced_short_name to be added to apiserver_cert_extra_sans")
@@ -59,6 +59,12 @@ func taintEffects() []string {
 
 // Plan is the installation plan that the user intends to execute
 type Plan struct {
+	// Version of the plan file schema.
+	// Plan files using an older version are migrated in memory when they are read,
+	// and can be rewritten using the current version with "kismatic plan migrate".
+	// +default=v2
+	// +options=v1,v2
+	APIVersion string `yaml:"api_version"`
 	// Kubernetes cluster configuration
 	// +required
 	Cluster Cluster
@@ -88,6 +94,9 @@ type Plan struct {
 	Storage OptionalNodeGroup
 	// NFS volumes of the cluster.
 	NFS *NFS `yaml:"nfs,omitempty"`
+
+	// the secret references of sensitive fields, keyed by field name
+	secretRefs map[string]secretRef
 }
 
 // Cluster describes a Kubernetes cluster
@@ -103,6 +112,7 @@ type Cluster struct {
 	Version string
 	// The password for the admin user.
 	// If provided, ABAC will be enabled in the cluster.
+	// Can be a secret reference, such as env:ADMIN_PASSWORD or file:/path/to/secret.
 	// This field will be removed completely in a future release.
 	// +deprecated
 	AdminPassword string `yaml:"admin_password,omitempty"`
@@ -223,12 +233,48 @@ type SSHConfig struct {
 	// +required
 	User string
 	// The absolute path of the SSH key that should be used for accessing the
-	// cluster nodes via SSH.
-	// +required
+	// cluster nodes via SSH. The key may be protected by a passphrase, which is read from
+	// the KISMATIC_SSH_KEY_PASSPHRASE environment variable or prompted for once.
+	// Can be left empty to use the keys of the ssh-agent running at SSH_AUTH_SOCK.
 	Key string `yaml:"ssh_key"`
 	// The port number on which cluster nodes are listening for SSH connections.
 	// +required
 	Port int `yaml:"ssh_port"`
+	// The absolute path of a known_hosts file with the host keys of the cluster nodes.
+	// The keys are added to the known_hosts file in the generated assets directory,
+	// so that they are trusted instead of the keys presented on first contact.
+	KnownHosts string `yaml:"known_hosts,omitempty"`
+	// The bastion host through which the cluster nodes are accessed via SSH.
+	// When set, all the SSH connections to the cluster nodes, including the
+	// ones made during the installation, are proxied through the bastion host.
+	Bastion *SSHBastion `yaml:"bastion,omitempty"`
+}
+
+// SSHBastion is a jump host that is used for accessing the cluster nodes via SSH
+type SSHBastion struct {
+	// The hostname or IP address of the bastion host.
+	// +required
+	Host string
+	// The user for accessing the bastion host via SSH.
+	// Defaults to the cluster's SSH user.
+	User string
+	// The absolute path of the SSH key that should be used for accessing the
+	// bastion host via SSH. Defaults to the cluster's SSH key.
+	Key string `yaml:"ssh_key"`
+	// The port number on which the bastion host is listening for SSH connections.
+	// +default=22
+	Port int `yaml:"ssh_port"`
+}
+
+// NodeSSHConfig overrides the cluster's SSH configuration for a node group or a node
+type NodeSSHConfig struct {
+	// The user for accessing the nodes via SSH.
+	// This user requires sudo elevation privileges on the nodes.
+	User string
+	// The absolute path of the SSH key that should be used for accessing the nodes via SSH.
+	Key string `yaml:"ssh_key"`
+	// The port number on which the nodes are listening for SSH connections.
+	Port int `yaml:"ssh_port"`
 }
 
 // CloudProvider controls the Kubernetes cloud providers feature
@@ -236,7 +282,8 @@ type CloudProvider struct {
 	// The cloud provider that should be set in the Kubernetes components
 	// +options=aws,azure,cloudstack,fake,gce,mesos,openstack,ovirt,photon,rackspace,vsphere
 	Provider string
-	// Path to the cloud provider config file. This will be copied to all the machines in the cluster
+	// Path to the cloud provider config file. This will be copied to all the machines in the cluster.
+	// Can be a secret reference, such as env:CLOUD_CONFIG_PATH or file:/path/to/cloud.conf.
 	Config string
 }
 
@@ -351,6 +398,7 @@ type DockerRegistry struct {
 	Username string
 	// The password that should be used when connecting to a registry that has authentication enabled.
 	// Otherwise leave blank for unauthenticated access.
+	// Can be a secret reference, such as env:REGISTRY_PASSWORD or file:/path/to/secret.
 	Password string
 }
 
@@ -442,6 +490,7 @@ type CalicoOptions struct {
 // The WeaveOptions that can be configured for the Weave CNI provider.
 type WeaveOptions struct {
 	// The password to use for network traffic encryption.
+	// Can be a secret reference, such as env:WEAVE_PASSWORD or file:/path/to/secret.
 	Password string
 }
 
@@ -601,6 +650,9 @@ type MasterNodeGroup struct {
 	// In the case where there is only one master node, this can be set to the IP address of the master nodes.
 	// +deprecated
 	LoadBalancedShortName *string `yaml:"load_balanced_short_name,omitempty"`
+	// SSH configuration of the master nodes.
+	// Fields that are not set are inherited from the cluster's SSH configuration.
+	SSH *NodeSSHConfig `yaml:"ssh,omitempty"`
 	// List of master nodes that are part of the cluster.
 	// +required
 	Nodes []Node
@@ -611,6 +663,9 @@ type NodeGroup struct {
 	// Number of nodes.
 	// +required
 	ExpectedCount int `yaml:"expected_count"`
+	// SSH configuration of the nodes in this group.
+	// Fields that are not set are inherited from the cluster's SSH configuration.
+	SSH *NodeSSHConfig `yaml:"ssh,omitempty"`
 	// List of nodes.
 	// +required
 	Nodes []Node
@@ -646,6 +701,10 @@ type Node struct {
 	// Kubelet configuration applied to this node.
 	// If a node is repeated for multiple roles, the overrides cannot be different.
 	KubeletOptions KubeletOptions `yaml:"kubelet,omitempty"`
+	// SSH configuration of this node.
+	// Fields that are not set are inherited from the node group's and the cluster's SSH configuration.
+	// If a node is repeated for multiple roles, the resulting SSH configuration cannot be different.
+	SSH *NodeSSHConfig `yaml:"ssh,omitempty"`
 }
 
 // Taint for nodes
@@ -852,7 +911,8 @@ func (p *Plan) GetSSHConnection(host string) (*SSHConnection, error) {
 		return nil, notFoundErr
 	}
 
-	return &SSHConnection{&p.Cluster.SSH, foundNode}, nil
+	sshConfig := p.SSHConfigForNode(*foundNode)
+	return &SSHConnection{&sshConfig, foundNode}, nil
 }
 
 // GetSSHClient is a convience method that calls GetSSHConnection and returns an SSH client with the result
@@ -861,7 +921,10 @@ func (p *Plan) GetSSHClient(host string) (ssh.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	client, err := ssh.NewClient(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.User, con.SSHConfig.Key)
+	if err = p.ImportKnownHosts(); err != nil {
+		return nil, err
+	}
+	client, err := ssh.NewClient(con.Node.IP, con.SSHConfig.Port, con.SSHConfig.User, con.SSHConfig.Key, con.SSHConfig.SSHBastion())
 	if err != nil {
 		return nil, fmt.Errorf("error creating SSH client for host %s: %v", host, err)
 	}
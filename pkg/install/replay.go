@@ -0,0 +1,74 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+// preflightRuns are the runs whose output is explained as pre-flight checks
+var preflightRuns = map[string]bool{
+	"preflight":          true,
+	"add-node-preflight": true,
+	"upgrade-preflight":  true,
+	"copy-inspector":     true,
+}
+
+// ReplayRun renders the output of a past run again, in the "simple", "raw" or "json" output
+// format. The "simple" and "json" output are rendered from the event stream that was recorded
+// in the run directory, and the "raw" output is the ansible log of the run.
+func ReplayRun(out io.Writer, runsDirectory string, id string, outputFormat string, verbose bool) error {
+	run, err := ReadRun(runsDirectory, id)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Join(runsDirectory, filepath.FromSlash(run.ID))
+	var explainer explain.AnsibleEventExplainer
+	switch outputFormat {
+	case "raw":
+		return replayAnsibleLog(out, run.ID, dir)
+	case "simple":
+		explainer = explain.DefaultExplainer(verbose, out)
+		if preflightRuns[run.Name] {
+			explainer = explain.PreflightExplainer(verbose, out)
+		}
+	case "json":
+		explainer = explain.JSONExplainer(run.ID, out)
+	default:
+		return fmt.Errorf("Output format %q is not supported", outputFormat)
+	}
+
+	events, err := os.Open(filepath.Join(dir, ansible.EventsFilename))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("the events of the run %q were not recorded. Use the \"raw\" output format to print its ansible log", run.ID)
+		}
+		return fmt.Errorf("error reading the events of the run %q: %v", run.ID, err)
+	}
+	runner := &ansible.ReplayRunner{Events: events}
+	eventStream, err := runner.StartPlaybook(context.Background(), run.Playbook, ansible.Inventory{}, ansible.ClusterCatalog{})
+	if err != nil {
+		events.Close()
+		return err
+	}
+	streamExplainer := &explain.AnsibleEventStreamExplainer{EventExplainer: explainer}
+	streamExplainer.Explain(eventStream)
+	return runner.WaitPlaybook(context.Background())
+}
+
+func replayAnsibleLog(out io.Writer, id string, dir string) error {
+	f, err := os.Open(filepath.Join(dir, "ansible.log"))
+	if err != nil {
+		return fmt.Errorf("error reading the ansible log of the run %q: %v", id, err)
+	}
+	defer f.Close()
+	if _, err = io.Copy(out, f); err != nil {
+		return fmt.Errorf("error reading the ansible log of the run %q: %v", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package install
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+// a recorded event stream, in which etcd fails to start on etcd01
+const failedEtcdEvents = `{"eventType":"PLAYBOOK_START", "eventData": {"name":"kubernetes.yaml", "count": 1}}
+{"eventType":"PLAY_START", "eventData": {"name":"Install etcd"}}
+{"eventType":"TASK_START", "eventData": {"name":"start etcd"}}
+{"eventType":"RUNNER_OK", "eventData": {"host":"etcd02"}}
+{"eventType":"RUNNER_FAILED", "eventData": {"host":"etcd01", "result": {"msg":"etcd did not start"}}}
+{"eventType":"TASK_START", "eventData": {"name":"verify etcd"}}
+{"eventType":"PLAYBOOK_END", "eventData": {"name":"kubernetes.yaml"}}
+`
+
+func TestExecuteReplayedRun(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-replay")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	runner := &ansible.ReplayRunner{Events: strings.NewReader(failedEtcdEvents), Err: errors.New("exit status 2")}
+	ae := &ansibleExecutor{
+		options:             ExecutorOptions{RunsDirectory: tmp},
+		stdout:              ioutil.Discard,
+		consoleOutputFormat: ansible.JSONLinesFormat,
+		runnerExplainerFactory: func(e explain.AnsibleEventExplainer, _ io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+			return runner, &explain.AnsibleEventStreamExplainer{EventExplainer: e}, nil
+		},
+	}
+	err = ae.execute(task{
+		name:      "apply",
+		playbook:  "kubernetes.yaml",
+		explainer: ae.defaultExplainer(),
+		limit:     []string{"etcd01", "etcd02"},
+	})
+	if err == nil {
+		t.Fatalf("expected an error when the playbook fails")
+	}
+	if len(runner.Playbooks) != 1 || runner.Playbooks[0] != "kubernetes.yaml" {
+		t.Errorf("unexpected playbooks %v", runner.Playbooks)
+	}
+	runs, err := ListRuns(tmp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(runs))
+	}
+	if runs[0].Outcome != runOutcomeFailure {
+		t.Errorf("expected outcome %q, got %q", runOutcomeFailure, runs[0].Outcome)
+	}
+	expected := []RunFailure{{Play: "Install etcd", Task: "start etcd", Host: "etcd01", Message: "etcd did not start"}}
+	if len(runs[0].Failures) != 1 || runs[0].Failures[0] != expected[0] {
+		t.Errorf("expected failures %v, got %v", expected, runs[0].Failures)
+	}
+}
+
+func TestReplayRun(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-replay")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	id := "apply/2018-06-01-10-00-00"
+	run := filepath.Join(tmp, filepath.FromSlash(id))
+	if err = os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(run, "ansible.log"), []byte("TASK [start etcd]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	if err = ReplayRun(out, tmp, id, "json", false); err == nil || !strings.Contains(err.Error(), "were not recorded") {
+		t.Errorf("expected an error when the run did not record its events, got: %v", err)
+	}
+	if err = ReplayRun(out, tmp, id, "raw", false); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if out.String() != "TASK [start etcd]\n" {
+		t.Errorf("expected the ansible log, got %q", out.String())
+	}
+
+	if err = ioutil.WriteFile(filepath.Join(run, ansible.EventsFilename), []byte(failedEtcdEvents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err = ReplayRun(out, tmp, id, "json", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 7 {
+		t.Fatalf("expected 7 JSON events, got %d:\n%s", len(lines), out.String())
+	}
+	if !strings.Contains(lines[4], `"type":"host_failed"`) || !strings.Contains(lines[4], `"run_id":"apply/2018-06-01-10-00-00"`) || !strings.Contains(lines[4], `"host":"etcd01"`) {
+		t.Errorf("unexpected JSON event %s", lines[4])
+	}
+
+	out.Reset()
+	if err = ReplayRun(out, tmp, id, "simple", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "etcd did not start") {
+		t.Errorf("expected the simple output to explain the failure, got:\n%s", out.String())
+	}
+
+	if err = ReplayRun(out, tmp, id, "yaml", false); err == nil {
+		t.Errorf("expected an error with an unsupported output format")
+	}
+
+	// the events are replayed with the time they were recorded
+	recorded := `{"eventType":"PLAY_START","eventData":{"name":"Install etcd"},"time":"2018-06-01T10:00:05Z"}` + "\n"
+	if err = ioutil.WriteFile(filepath.Join(run, ansible.EventsFilename), []byte(recorded), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if err = ReplayRun(out, tmp, id, "json", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), `"timestamp":"2018-06-01T10:00:05Z"`) {
+		t.Errorf("expected the JSON event to have the recorded time, got %s", out.String())
+	}
+}
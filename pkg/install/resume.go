@@ -0,0 +1,329 @@
+package install
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/util"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// resumePlaybookSuffix is appended to the name of the playbook that resumes a run
+const resumePlaybookSuffix = "-resume"
+
+// playCheckpoint records the plays of a run that completed without failures.
+// Once a host fails, the following plays run without it, so they are not
+// considered complete either.
+type playCheckpoint struct {
+	file    string
+	current string
+	started bool
+	failed  bool
+}
+
+// newPlayCheckpoint returns a checkpoint that records the completed plays in the
+// run directory, starting with the plays that were completed by a previous run
+func newPlayCheckpoint(runDirectory string, completed []string) (*playCheckpoint, error) {
+	c := &playCheckpoint{file: filepath.Join(runDirectory, runPlaysFilename)}
+	var b bytes.Buffer
+	for _, play := range completed {
+		fmt.Fprintln(&b, play)
+	}
+	if err := ioutil.WriteFile(c.file, []byte(b.String()), 0644); err != nil {
+		return nil, fmt.Errorf("error recording completed plays to %s: %v", c.file, err)
+	}
+	return c, nil
+}
+
+// watch records the plays of the event stream, and forwards the events
+func (c *playCheckpoint) watch(in <-chan ansible.Event) <-chan ansible.Event {
+	out := make(chan ansible.Event)
+	go func() {
+		defer close(out)
+		for e := range in {
+			if err := c.record(e); err != nil {
+				// stop recording, the run can no longer be resumed reliably
+				c.failed = true
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (c *playCheckpoint) record(e ansible.Event) error {
+	switch event := e.(type) {
+	case *ansible.PlayStartEvent:
+		err := c.complete()
+		c.current, c.started = event.Name, true
+		return err
+	case *ansible.PlaybookEndEvent:
+		err := c.complete()
+		c.started = false
+		return err
+	case *ansible.RunnerFailedEvent:
+		if !event.IgnoreErrors {
+			c.failed = true
+		}
+	case *ansible.RunnerUnreachableEvent:
+		c.failed = true
+	}
+	return nil
+}
+
+// complete records the current play, unless a host failed
+func (c *playCheckpoint) complete() error {
+	if !c.started || c.failed {
+		return nil
+	}
+	f, err := os.OpenFile(c.file, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, c.current)
+	return err
+}
+
+func readCompletedPlays(runDirectory string) ([]string, error) {
+	f, err := os.Open(filepath.Join(runDirectory, runPlaysFilename))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var plays []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		plays = append(plays, s.Text())
+	}
+	return plays, s.Err()
+}
+
+// runChecksum returns the checksum of the plan, without its secrets, and of the nodes
+// targeted by a run. A run can only be resumed with the same plan and nodes.
+func runChecksum(p *Plan, limit []string) (string, error) {
+	b, err := yaml.Marshal(redactedPlan(p))
+	if err != nil {
+		return "", fmt.Errorf("error marshalling plan to yaml: %v", err)
+	}
+	h := sha256.New()
+	h.Write(b)
+	fmt.Fprintf(h, "limit: %s\n", strings.Join(limit, ","))
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func writeRunChecksum(runDirectory string, p *Plan, limit []string) error {
+	sum, err := runChecksum(p, limit)
+	if err != nil {
+		return err
+	}
+	file := filepath.Join(runDirectory, runChecksumFilename)
+	if err = ioutil.WriteFile(file, []byte(sum+"\n"), 0644); err != nil {
+		return fmt.Errorf("error recording run checksum to %s: %v", file, err)
+	}
+	return nil
+}
+
+// playbookInclude is an entry of a playbook that includes the plays of another playbook
+type playbookInclude struct {
+	entry yaml.MapSlice
+	file  string
+	plays []string
+}
+
+// readPlaybookIncludes reads the entries of a playbook that only includes other
+// playbooks, and the names of the plays of the included playbooks
+func readPlaybookIncludes(playbook string) ([]playbookInclude, error) {
+	b, err := ioutil.ReadFile(playbook)
+	if err != nil {
+		return nil, fmt.Errorf("error reading playbook: %v", err)
+	}
+	var entries []yaml.MapSlice
+	if err = yaml.Unmarshal(b, &entries); err != nil {
+		return nil, fmt.Errorf("error parsing playbook %q: %v", playbook, err)
+	}
+	includes := make([]playbookInclude, 0, len(entries))
+	for _, entry := range entries {
+		inc := playbookInclude{entry: entry}
+		for _, item := range entry {
+			if item.Key == "include" {
+				inc.file, _ = item.Value.(string)
+			}
+		}
+		if inc.file == "" {
+			return nil, fmt.Errorf("playbook %q cannot be resumed, as it contains plays that are not included from other playbooks", playbook)
+		}
+		b, err := ioutil.ReadFile(filepath.Join(filepath.Dir(playbook), inc.file))
+		if err != nil {
+			return nil, fmt.Errorf("error reading playbook: %v", err)
+		}
+		var plays []struct {
+			Name string `yaml:"name"`
+		}
+		if err = yaml.Unmarshal(b, &plays); err != nil {
+			return nil, fmt.Errorf("error parsing playbook %q: %v", inc.file, err)
+		}
+		for _, play := range plays {
+			inc.plays = append(inc.plays, play.Name)
+		}
+		includes = append(includes, inc)
+	}
+	return includes, nil
+}
+
+// resumePlaybook writes a playbook that includes the playbooks starting with the one
+// that contains the first incomplete play. Plays are matched by their position, as
+// their names can be templated. Returns the name of the written playbook, the plays
+// that it skips, and false if all the plays were completed.
+// The playbook is written next to the playbook it resumes, so that the included playbooks
+// are found, with a name that is unique to the run. It must be removed once the run is done.
+func resumePlaybook(playbook string, completed []string) (string, []string, bool, error) {
+	includes, err := readPlaybookIncludes(playbook)
+	if err != nil {
+		return "", nil, false, err
+	}
+	skipped := 0
+	for i, inc := range includes {
+		if skipped+len(inc.plays) <= len(completed) {
+			skipped += len(inc.plays)
+			continue
+		}
+		var entries []yaml.MapSlice
+		for _, inc := range includes[i:] {
+			entries = append(entries, inc.entry)
+		}
+		b, err := yaml.Marshal(entries)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("error marshalling playbook to yaml: %v", err)
+		}
+		header := fmt.Sprintf("---\n# Resumes %s from %s\n", filepath.Base(playbook), inc.file)
+		f, err := createResumePlaybook(playbook)
+		if err != nil {
+			return "", nil, false, fmt.Errorf("error writing playbook: %v", err)
+		}
+		_, err = f.Write(append([]byte(header), b...))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+			return "", nil, false, fmt.Errorf("error writing playbook: %v", err)
+		}
+		return filepath.Base(f.Name()), completed[:skipped], true, nil
+	}
+	return "", completed, false, nil
+}
+
+// createResumePlaybook creates the file of a playbook that resumes the playbook, in the
+// directory of the playbook. Concurrent runs get different files.
+func createResumePlaybook(playbook string) (*os.File, error) {
+	ext := filepath.Ext(playbook)
+	prefix := strings.TrimSuffix(playbook, ext) + resumePlaybookSuffix
+	for i := 0; ; i++ {
+		name := fmt.Sprintf("%s-%d-%d%s", prefix, os.Getpid(), i, ext)
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+}
+
+// resumedPlaybook returns the name of the playbook resumed by the playbook, or the name of
+// the playbook if it does not resume another one
+func resumedPlaybook(playbook string) string {
+	ext := filepath.Ext(playbook)
+	i := strings.LastIndex(playbook, resumePlaybookSuffix+"-")
+	if i <= 0 {
+		return playbook
+	}
+	return playbook[:i] + ext
+}
+
+// ResumeInstall resumes the last installation run from the first play that it did not
+// complete, as long as the plan and the nodes are the same as in that run. When no nodes
+// are given, a run that was limited to some of the nodes is resumed on the same nodes.
+func (ae *ansibleExecutor) ResumeInstall(p *Plan, restartServices bool, nodes ...string) error {
+	run, err := lastRun(ae.options.RunsDirectory, "apply")
+	if err != nil {
+		return fmt.Errorf("error finding the installation run to resume: %v", err)
+	}
+	if outcome, err := readRunOutcome(run); err == nil && outcome == runOutcomeSuccess {
+		return fmt.Errorf("the last installation run %q completed successfully, and there is nothing to resume", run)
+	}
+	recorded, err := ioutil.ReadFile(filepath.Join(run, runChecksumFilename))
+	if err != nil {
+		return fmt.Errorf("the installation run %q cannot be resumed, as it did not record the plan it used: %v", run, err)
+	}
+	// a run that was limited to some of the nodes, such as a retry of the hosts that failed,
+	// is resumed on the same nodes when none are given
+	limits := [][]string{nodes}
+	if len(nodes) == 0 {
+		if summary, err := readRunSummary(ae.options.RunsDirectory, ae.runID(run)); err == nil && len(summary.Nodes) != 0 {
+			limits = append(limits, summary.Nodes)
+		}
+	}
+	var limit []string
+	planChanged := true
+	for _, l := range limits {
+		sum, err := runChecksum(p, l)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(recorded)) == sum {
+			limit = l
+			planChanged = false
+			break
+		}
+	}
+	if planChanged {
+		return fmt.Errorf("the plan file or the nodes have changed since the installation run %q, and it cannot be resumed. Run \"kismatic apply\" without --resume", run)
+	}
+	completed, err := readCompletedPlays(run)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading the plays completed by %q: %v", run, err)
+	}
+
+	cc, err := ae.buildClusterCatalog(p)
+	if err != nil {
+		return err
+	}
+	if restartServices {
+		cc.EnableRestart()
+	}
+	playbook, skipped, incomplete, err := resumePlaybook(filepath.Join(ae.ansibleDir, "playbooks", "kubernetes.yaml"), completed)
+	if err != nil {
+		return err
+	}
+	if incomplete {
+		defer os.Remove(filepath.Join(ae.ansibleDir, "playbooks", playbook))
+	}
+	util.PrintHeader(ae.stdout, "Resuming Cluster Installation", '=')
+	if !incomplete {
+		util.PrettyPrintOk(ae.stdout, "All the plays were completed by the installation run %q", run)
+		return nil
+	}
+	util.PrettyPrintOk(ae.stdout, "Skipping %d plays completed by the installation run %q", len(skipped), run)
+	if len(nodes) == 0 && len(limit) != 0 {
+		util.PrettyPrintOk(ae.stdout, "Resuming on the nodes of the installation run %q: %s", run, strings.Join(limit, ", "))
+	}
+	t := task{
+		name:           "apply",
+		playbook:       playbook,
+		plan:           *p,
+		inventory:      buildInventoryFromPlan(p),
+		clusterCatalog: *cc,
+		explainer:      ae.defaultExplainer(),
+		limit:          limit,
+		completedPlays: skipped,
+		printTiming:    true,
+	}
+	return ae.execute(t)
+}
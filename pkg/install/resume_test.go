@@ -0,0 +1,334 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+func play(name string) *ansible.PlayStartEvent {
+	e := &ansible.PlayStartEvent{}
+	e.Name = name
+	return e
+}
+
+func TestPlayCheckpoint(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-resume")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	c, err := newPlayCheckpoint(tmp, []string{"previous"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ignored := &ansible.RunnerFailedEvent{}
+	ignored.IgnoreErrors = true
+	events := make(chan ansible.Event)
+	go func() {
+		for _, e := range []ansible.Event{
+			play("first"), &ansible.RunnerOKEvent{}, ignored,
+			play("second"),
+			play("third"), &ansible.RunnerFailedEvent{},
+			play("fourth"), &ansible.RunnerOKEvent{},
+			&ansible.PlaybookEndEvent{},
+		} {
+			events <- e
+		}
+		close(events)
+	}()
+	n := 0
+	for range c.watch(events) {
+		n++
+	}
+	if n != 9 {
+		t.Errorf("expected all the events to be forwarded, but got %d", n)
+	}
+	plays, err := readCompletedPlays(tmp)
+	if err != nil {
+		t.Fatalf("unexpected error reading completed plays: %v", err)
+	}
+	expected := []string{"previous", "first", "second"}
+	if !reflect.DeepEqual(plays, expected) {
+		t.Errorf("expected completed plays %v, but got %v", expected, plays)
+	}
+}
+
+func TestResumePlaybook(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-resume")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	files := map[string]string{
+		"main.yaml": `---
+  - include: _one.yaml
+  - include: _two.yaml
+    when: two|bool == true
+  - include: _three.yaml
+`,
+		"_one.yaml":   "---\n  - hosts: all\n    name: one\n",
+		"_two.yaml":   "---\n  - hosts: all\n    name: two-a\n  - hosts: all\n    name: \"{{ play_name | default('two-b') }}\"\n",
+		"_three.yaml": "---\n  - hosts: all\n    name: three\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(tmp, name), []byte(content), 0644); err != nil {
+			t.Fatalf("error writing playbook: %v", err)
+		}
+	}
+
+	tests := []struct {
+		completed    []string
+		skipped      []string
+		incomplete   bool
+		firstInclude string
+	}{
+		{nil, nil, true, "_one.yaml"},
+		{[]string{"one"}, []string{"one"}, true, "_two.yaml"},
+		// the included playbook is run again when only some of its plays were completed
+		{[]string{"one", "two-a"}, []string{"one"}, true, "_two.yaml"},
+		{[]string{"one", "two-a", "custom"}, []string{"one", "two-a", "custom"}, true, "_three.yaml"},
+		{[]string{"one", "two-a", "custom", "three"}, []string{"one", "two-a", "custom", "three"}, false, ""},
+	}
+	names := map[string]bool{}
+	for i, test := range tests {
+		name, skipped, incomplete, err := resumePlaybook(filepath.Join(tmp, "main.yaml"), test.completed)
+		if err != nil {
+			t.Fatalf("%d: unexpected error: %v", i, err)
+		}
+		if incomplete != test.incomplete || len(skipped) != len(test.skipped) {
+			t.Errorf("%d: expected %v and skipped plays %v, but got %v and %v", i, test.incomplete, test.skipped, incomplete, skipped)
+		}
+		if !incomplete {
+			continue
+		}
+		b, err := ioutil.ReadFile(filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("%d: error reading resume playbook: %v", i, err)
+		}
+		if names[name] {
+			t.Errorf("%d: expected a resume playbook that is unique to the run, but got %s again", i, name)
+		}
+		names[name] = true
+		includes, err := readPlaybookIncludes(filepath.Join(tmp, name))
+		if err != nil {
+			t.Fatalf("%d: error parsing resume playbook: %v", i, err)
+		}
+		if includes[0].file != test.firstInclude {
+			t.Errorf("%d: expected the playbook to start with %s, but got:\n%s", i, test.firstInclude, b)
+		}
+		if test.firstInclude == "_two.yaml" && !strings.Contains(string(b), "when: two|bool == true") {
+			t.Errorf("%d: expected the conditions of the includes to be kept, but got:\n%s", i, b)
+		}
+	}
+}
+
+func TestResumedPlaybook(t *testing.T) {
+	tests := map[string]string{
+		"kubernetes.yaml":               "kubernetes.yaml",
+		"kubernetes-resume-1234-0.yaml": "kubernetes.yaml",
+		"kubernetes-node.yaml":          "kubernetes-node.yaml",
+	}
+	for playbook, expected := range tests {
+		if got := resumedPlaybook(playbook); got != expected {
+			t.Errorf("expected %q to resume %q, but got %q", playbook, expected, got)
+		}
+	}
+}
+
+func TestReadPlaybookIncludesKubernetesPlaybook(t *testing.T) {
+	includes, err := readPlaybookIncludes("../../ansible/kubernetes.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error reading the installation playbook: %v", err)
+	}
+	if len(includes) == 0 || includes[0].file != "_all.yaml" || len(includes[0].plays) != 2 {
+		t.Errorf("unexpected includes %+v", includes)
+	}
+}
+
+func TestResumeInstall(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-resume")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	ae := &ansibleExecutor{options: ExecutorOptions{RunsDirectory: tmp}}
+	p := &Plan{}
+	p.Cluster.Name = "test"
+
+	if err = ae.ResumeInstall(p, false); err == nil {
+		t.Errorf("expected an error when there is no run to resume")
+	}
+
+	run := filepath.Join(tmp, "apply", "2018-06-01-10-00-00")
+	if err = os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	if err = writeRunChecksum(run, p, []string{"worker01"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	changed := &Plan{}
+	changed.Cluster.Name = "changed"
+	if err = ae.ResumeInstall(changed, false, "worker01"); err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("expected an error when the plan has changed, but got %v", err)
+	}
+	if err = ae.ResumeInstall(p, false); err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("expected an error when the nodes have changed, but got %v", err)
+	}
+
+	if err = writeRunOutcome(run, runOutcomeSuccess); err != nil {
+		t.Fatal(err)
+	}
+	if err = ae.ResumeInstall(p, false, "worker01"); err == nil || !strings.Contains(err.Error(), "nothing to resume") {
+		t.Errorf("expected an error when the run was successful, but got %v", err)
+	}
+}
+
+func TestResumeInstallRemovesResumePlaybook(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-resume")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	playbooks := filepath.Join(tmp, "ansible", "playbooks")
+	if err = os.MkdirAll(playbooks, 0777); err != nil {
+		t.Fatalf("error creating playbooks directory: %v", err)
+	}
+	files := map[string]string{
+		"kubernetes.yaml": "---\n  - include: _one.yaml\n  - include: _two.yaml\n",
+		"_one.yaml":       "---\n  - hosts: all\n    name: one\n",
+		"_two.yaml":       "---\n  - hosts: all\n    name: two\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(playbooks, name), []byte(content), 0644); err != nil {
+			t.Fatalf("error writing playbook: %v", err)
+		}
+	}
+	ae := &ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: filepath.Join(tmp, "runs")},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		ansibleDir:             filepath.Join(tmp, "ansible"),
+		runnerExplainerFactory: fakeRunnerExplainer(nil),
+		certsDir:               mustGetTempDir(t),
+	}
+	p := &Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{InternalIP: "10.10.2.20"}},
+		},
+		Cluster: Cluster{
+			Version: "v1.10.11",
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+	run := filepath.Join(tmp, "runs", "apply", "2018-06-01-10-00-00")
+	if err = os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	if err = writeRunChecksum(run, p, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(run, runPlaysFilename), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ae.ResumeInstall(p, false); err != nil {
+		t.Fatalf("unexpected error resuming the installation: %v", err)
+	}
+	left, err := ioutil.ReadDir(playbooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != len(files) {
+		t.Errorf("expected the resume playbook to be removed once the run is done, but got %d files", len(left))
+	}
+}
+
+func TestResumeInstallAfterRetry(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-resume")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	playbooks := filepath.Join(tmp, "ansible", "playbooks")
+	if err = os.MkdirAll(playbooks, 0777); err != nil {
+		t.Fatalf("error creating playbooks directory: %v", err)
+	}
+	files := map[string]string{
+		"kubernetes.yaml": "---\n  - include: _one.yaml\n  - include: _two.yaml\n",
+		"_one.yaml":       "---\n  - hosts: all\n    name: one\n",
+		"_two.yaml":       "---\n  - hosts: all\n    name: two\n",
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(playbooks, name), []byte(content), 0644); err != nil {
+			t.Fatalf("error writing playbook: %v", err)
+		}
+	}
+	ae := &ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: filepath.Join(tmp, "runs")},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		ansibleDir:             filepath.Join(tmp, "ansible"),
+		runnerExplainerFactory: fakeRunnerExplainer(nil),
+		certsDir:               mustGetTempDir(t),
+	}
+	p := &Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{Host: "master01", InternalIP: "10.10.2.20"}},
+		},
+		Worker: NodeGroup{
+			Nodes: []Node{{Host: "worker01", InternalIP: "10.10.2.21"}},
+		},
+		Cluster: Cluster{
+			Version: "v1.10.11",
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+	// the retry of the hosts that failed is limited to them
+	retry := filepath.Join(tmp, "runs", "apply", "2018-06-01-10-00-00")
+	if err = os.MkdirAll(retry, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	r := &runRecorder{summary: RunSummary{
+		Name:     "apply",
+		Playbook: "kubernetes.yaml",
+		Nodes:    []string{"worker01"},
+		Failures: []RunFailure{{Host: "worker01"}},
+	}}
+	if err = r.write(retry, runOutcomeFailure); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = writeRunChecksum(retry, p, []string{"worker01"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(retry, runPlaysFilename), []byte("one\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ae.ResumeInstall(p, false, "master01"); err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("expected an error when the nodes have changed, but got %v", err)
+	}
+	if err = ae.ResumeInstall(p, false); err != nil {
+		t.Fatalf("unexpected error resuming the retry: %v", err)
+	}
+	resumed, err := lastRun(ae.options.RunsDirectory, "apply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := readRunSummary(ae.options.RunsDirectory, ae.runID(resumed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.Nodes) != 1 || summary.Nodes[0] != "worker01" {
+		t.Errorf("expected the retry to be resumed on the hosts it was limited to, but got %v", summary.Nodes)
+	}
+}
@@ -0,0 +1,77 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/apprenda/kismatic/pkg/util"
+)
+
+// RetryInstall runs the playbook of the last installation run again, limited to the
+// hosts that failed or were unreachable during that run, as long as the plan is the
+// same as in that run. A resumed run is retried with the whole installation playbook.
+func (ae *ansibleExecutor) RetryInstall(p *Plan, restartServices bool) error {
+	run, err := lastRun(ae.options.RunsDirectory, "apply")
+	if err != nil {
+		return fmt.Errorf("error finding the installation run to retry: %v", err)
+	}
+	summary, err := readRunSummary(ae.options.RunsDirectory, ae.runID(run))
+	if err != nil {
+		return err
+	}
+	if summary.Outcome == runOutcomeSuccess {
+		return fmt.Errorf("the last installation run %q completed successfully, and there is nothing to retry", run)
+	}
+	hosts := summary.FailedHosts()
+	if summary.Playbook == "" || len(hosts) == 0 {
+		return fmt.Errorf("the installation run %q did not record the hosts that failed, and cannot be retried. Run \"kismatic apply\" without --retry-failed", run)
+	}
+	for _, host := range hosts {
+		if !p.HostExists(host) {
+			return fmt.Errorf("host %q failed during the installation run %q, but it is no longer in the plan file", host, run)
+		}
+	}
+	recorded, err := ioutil.ReadFile(filepath.Join(run, runChecksumFilename))
+	if err != nil {
+		return fmt.Errorf("the installation run %q cannot be retried, as it did not record the plan it used: %v", run, err)
+	}
+	// the run was limited to its nodes, unless it targeted all the nodes of the plan
+	planChanged := true
+	for _, limit := range [][]string{nil, summary.Nodes} {
+		sum, err := runChecksum(p, limit)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(string(recorded)) == sum {
+			planChanged = false
+			break
+		}
+	}
+	if planChanged {
+		return fmt.Errorf("the plan file has changed since the installation run %q, and it cannot be retried. Run \"kismatic apply\" without --retry-failed", run)
+	}
+	playbook := resumedPlaybook(filepath.Base(summary.Playbook))
+
+	cc, err := ae.buildClusterCatalog(p)
+	if err != nil {
+		return err
+	}
+	if restartServices {
+		cc.EnableRestart()
+	}
+	util.PrintHeader(ae.stdout, "Retrying Cluster Installation", '=')
+	util.PrettyPrintOk(ae.stdout, "Running %s again on the hosts that failed during the installation run %q: %s", playbook, run, strings.Join(hosts, ", "))
+	t := task{
+		name:           "apply",
+		playbook:       playbook,
+		plan:           *p,
+		inventory:      buildInventoryFromPlan(p),
+		clusterCatalog: *cc,
+		explainer:      ae.defaultExplainer(),
+		limit:          hosts,
+		printTiming:    true,
+	}
+	return ae.execute(t)
+}
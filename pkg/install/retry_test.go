@@ -0,0 +1,133 @@
+package install
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+func TestRetryInstall(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-retry")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	ae := &ansibleExecutor{options: ExecutorOptions{RunsDirectory: tmp}}
+	p := &Plan{Worker: NodeGroup{Nodes: []Node{{Host: "worker01"}}}}
+
+	if err = ae.RetryInstall(p, false); err == nil {
+		t.Errorf("expected an error when there is no run to retry")
+	}
+
+	run := filepath.Join(tmp, "apply", "2018-06-01-10-00-00")
+	if err = os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	if err = writeRunOutcome(run, runOutcomeFailure); err != nil {
+		t.Fatal(err)
+	}
+	if err = ae.RetryInstall(p, false); err == nil || !strings.Contains(err.Error(), "did not record the hosts") {
+		t.Errorf("expected an error when the run did not record the failed hosts, but got %v", err)
+	}
+
+	r := &runRecorder{summary: RunSummary{
+		Name:     "apply",
+		Playbook: "kubernetes.yaml",
+		Failures: []RunFailure{{Host: "worker02"}, {Host: "worker02", Unreachable: true}},
+	}}
+	if err = r.write(run, runOutcomeFailure); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = ae.RetryInstall(p, false); err == nil || !strings.Contains(err.Error(), `"worker02"`) {
+		t.Errorf("expected an error when a failed host is not in the plan, but got %v", err)
+	}
+
+	if err = r.write(run, runOutcomeSuccess); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = ae.RetryInstall(p, false); err == nil || !strings.Contains(err.Error(), "nothing to retry") {
+		t.Errorf("expected an error when the run was successful, but got %v", err)
+	}
+}
+
+func TestRetryInstallChecksPlanAndPlaybook(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-retry")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	ae := &ansibleExecutor{
+		options:                ExecutorOptions{RunsDirectory: filepath.Join(tmp, "runs")},
+		stdout:                 ioutil.Discard,
+		consoleOutputFormat:    ansible.RawFormat,
+		ansibleDir:             filepath.Join(tmp, "ansible"),
+		runnerExplainerFactory: fakeRunnerExplainer(nil),
+		certsDir:               mustGetTempDir(t),
+	}
+	p := &Plan{
+		Master: MasterNodeGroup{
+			Nodes: []Node{{Host: "master01", InternalIP: "10.10.2.20"}},
+		},
+		Cluster: Cluster{
+			Version: "v1.10.11",
+			Networking: NetworkConfig{
+				ServiceCIDRBlock: "10.0.0.0/16",
+			},
+		},
+	}
+	run := filepath.Join(tmp, "runs", "apply", "2018-06-01-10-00-00")
+	if err = os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	r := &runRecorder{summary: RunSummary{
+		Name:     "apply",
+		Playbook: "kubernetes-resume-1234-0.yaml",
+		Nodes:    []string{"master01"},
+		Failures: []RunFailure{{Host: "master01"}},
+	}}
+	if err = r.write(run, runOutcomeFailure); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = ae.RetryInstall(p, false); err == nil || !strings.Contains(err.Error(), "did not record the plan") {
+		t.Errorf("expected an error when the run did not record its plan, but got %v", err)
+	}
+
+	changed := *p
+	changed.Cluster.Version = "v1.10.10"
+	if err = writeRunChecksum(run, &changed, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = ae.RetryInstall(p, false); err == nil || !strings.Contains(err.Error(), "plan file has changed") {
+		t.Errorf("expected an error when the plan has changed, but got %v", err)
+	}
+
+	if err = writeRunChecksum(run, p, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = ae.RetryInstall(p, false); err != nil {
+		t.Fatalf("unexpected error retrying the installation: %v", err)
+	}
+	retry, err := lastRun(ae.options.RunsDirectory, "apply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := readRunSummary(ae.options.RunsDirectory, ae.runID(retry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Playbook != "kubernetes.yaml" {
+		t.Errorf("expected the resumed run to be retried with kubernetes.yaml, but got %q", summary.Playbook)
+	}
+}
+
+func TestRunSummaryFailedHosts(t *testing.T) {
+	s := RunSummary{Failures: []RunFailure{{Host: "worker02"}, {Host: "worker01", Unreachable: true}, {Host: "worker02"}}}
+	hosts := s.FailedHosts()
+	if len(hosts) != 2 || hosts[0] != "worker02" || hosts[1] != "worker01" {
+		t.Errorf("unexpected failed hosts %v", hosts)
+	}
+}
@@ -0,0 +1,255 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// RunSummary is the summary of a run of the executor
+type RunSummary struct {
+	// ID of the run, which is the path of its directory in the runs directory
+	ID string `yaml:"-" json:"id"`
+	// Name of the task that was run, such as "apply" or "upgrade-nodes"
+	Name string `yaml:"name" json:"name"`
+	// Playbook that was run
+	Playbook string `yaml:"playbook,omitempty" json:"playbook,omitempty"`
+	// Start time of the run
+	Start time.Time `yaml:"start" json:"start"`
+	// End time of the run, zero if the run did not end or did not record it
+	End time.Time `yaml:"end,omitempty" json:"end,omitempty"`
+	// Outcome of the run, "success", "failure", "aborted" or "unknown"
+	Outcome string `yaml:"outcome" json:"outcome"`
+	// Nodes targeted by the run
+	Nodes []string `yaml:"nodes,omitempty" json:"nodes,omitempty"`
+	// Failures of the hosts during the run
+	Failures []RunFailure `yaml:"failures,omitempty" json:"failures,omitempty"`
+}
+
+// RunFailure is a task that failed on a host, or a host that was unreachable
+type RunFailure struct {
+	Play        string `yaml:"play" json:"play"`
+	Task        string `yaml:"task" json:"task"`
+	Host        string `yaml:"host" json:"host"`
+	Message     string `yaml:"message,omitempty" json:"message,omitempty"`
+	Unreachable bool   `yaml:"unreachable,omitempty" json:"unreachable,omitempty"`
+}
+
+// Duration of the run, zero if the end of the run is not known
+func (s RunSummary) Duration() time.Duration {
+	if s.End.IsZero() {
+		return 0
+	}
+	return s.End.Sub(s.Start)
+}
+
+// FailedHosts returns the hosts that failed or were unreachable during the run
+func (s RunSummary) FailedHosts() []string {
+	var hosts []string
+	seen := map[string]bool{}
+	for _, f := range s.Failures {
+		if !seen[f.Host] {
+			seen[f.Host] = true
+			hosts = append(hosts, f.Host)
+		}
+	}
+	return hosts
+}
+
+// runRecorder builds the summary of a run from its event stream
+type runRecorder struct {
+	mu      sync.Mutex
+	summary RunSummary
+	play    string
+	task    string
+}
+
+func newRunRecorder(t task) *runRecorder {
+	nodes := t.limit
+	if len(nodes) == 0 {
+		seen := map[string]bool{}
+		for _, role := range t.inventory.Roles {
+			for _, n := range role.Nodes {
+				if !seen[n.Host] {
+					seen[n.Host] = true
+					nodes = append(nodes, n.Host)
+				}
+			}
+		}
+	}
+	return &runRecorder{
+		summary: RunSummary{
+			Name:     t.name,
+			Playbook: t.playbook,
+			Start:    time.Now(),
+			Nodes:    nodes,
+		},
+	}
+}
+
+// watch records the failures of the event stream, and forwards the events
+func (r *runRecorder) watch(in <-chan ansible.Event) <-chan ansible.Event {
+	out := make(chan ansible.Event)
+	go func() {
+		defer close(out)
+		for e := range in {
+			r.record(e)
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (r *runRecorder) record(e ansible.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch event := e.(type) {
+	case *ansible.PlayStartEvent:
+		r.play, r.task = event.Name, ""
+	case *ansible.TaskStartEvent:
+		r.task = event.Name
+	case *ansible.HandlerTaskStartEvent:
+		r.task = event.Name
+	case *ansible.RunnerFailedEvent:
+		if !event.IgnoreErrors {
+			r.summary.Failures = append(r.summary.Failures, RunFailure{Play: r.play, Task: r.task, Host: event.Host, Message: event.Result.Message})
+		}
+	case *ansible.RunnerUnreachableEvent:
+		r.summary.Failures = append(r.summary.Failures, RunFailure{Play: r.play, Task: r.task, Host: event.Host, Message: event.Result.Message, Unreachable: true})
+	}
+}
+
+// write records the summary of the run, with the given outcome
+func (r *runRecorder) write(runDirectory string, outcome string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.End = time.Now()
+	r.summary.Outcome = outcome
+	b, err := yaml.Marshal(r.summary)
+	if err != nil {
+		return fmt.Errorf("error marshalling run summary to yaml: %v", err)
+	}
+	file := filepath.Join(runDirectory, runSummaryFilename)
+	if err = ioutil.WriteFile(file, b, 0644); err != nil {
+		return fmt.Errorf("error recording run summary to %s: %v", file, err)
+	}
+	return nil
+}
+
+// readRunSummary reads the summary of the run. Runs that did not write a summary, because
+// they were interrupted or were recorded by an older version, are summarized from the
+// name of their directory and their outcome.
+func readRunSummary(runsDirectory string, id string) (*RunSummary, error) {
+	dir := filepath.Join(runsDirectory, filepath.FromSlash(id))
+	s := &RunSummary{}
+	b, err := ioutil.ReadFile(filepath.Join(dir, runSummaryFilename))
+	if err == nil {
+		if err = yaml.Unmarshal(b, s); err != nil {
+			return nil, fmt.Errorf("error reading summary of run %q: %v", id, err)
+		}
+		s.ID = id
+		return s, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading summary of run %q: %v", id, err)
+	}
+	s.ID = id
+	s.Name = filepath.Base(filepath.Dir(dir))
+	if s.Start, err = time.ParseInLocation(runTimestampFormat, filepath.Base(dir), time.Local); err != nil {
+		return nil, fmt.Errorf("%q is not a run directory", id)
+	}
+	if s.Outcome, err = readRunOutcome(dir); err != nil {
+		s.Outcome = "unknown"
+	}
+	return s, nil
+}
+
+// ListRuns returns the summaries of the runs in the runs directory, most recent first
+func ListRuns(runsDirectory string) ([]RunSummary, error) {
+	names, err := ioutil.ReadDir(runsDirectory)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading runs directory: %v", err)
+	}
+	var runs []RunSummary
+	for _, name := range names {
+		if !name.IsDir() {
+			continue
+		}
+		dirs, err := ioutil.ReadDir(filepath.Join(runsDirectory, name.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading runs directory: %v", err)
+		}
+		for _, d := range dirs {
+			if !d.IsDir() {
+				continue
+			}
+			s, err := readRunSummary(runsDirectory, name.Name()+"/"+d.Name())
+			if err != nil {
+				// not a run directory
+				continue
+			}
+			runs = append(runs, *s)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].Start.After(runs[j].Start)
+	})
+	return runs, nil
+}
+
+// ReadRun returns the summary of the run with the given ID
+func ReadRun(runsDirectory string, id string) (*RunSummary, error) {
+	parts := strings.Split(strings.Trim(id, "/"), "/")
+	if len(parts) != 2 || parts[0] == ".." || parts[1] == ".." {
+		return nil, fmt.Errorf("invalid run ID %q: expected <name>/<timestamp>, such as \"apply/2018-06-01-10-00-00\"", id)
+	}
+	id = parts[0] + "/" + parts[1]
+	if _, err := os.Stat(filepath.Join(runsDirectory, parts[0], parts[1])); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("run %q was not found in %q", id, runsDirectory)
+		}
+		return nil, fmt.Errorf("error reading run %q: %v", id, err)
+	}
+	return readRunSummary(runsDirectory, id)
+}
+
+// PruneRuns removes all but the most recent runs of each task, and returns the IDs of the
+// removed runs. The run that holds the last applied plan file is always kept.
+func PruneRuns(runsDirectory string, keep int) ([]string, error) {
+	if keep < 1 {
+		return nil, fmt.Errorf("the number of runs to keep must be greater or equal to 1, got: %d", keep)
+	}
+	runs, err := ListRuns(runsDirectory)
+	if err != nil {
+		return nil, err
+	}
+	var lastApplied string
+	if planFile, err := LastAppliedPlanFile(runsDirectory); err == nil {
+		lastApplied = filepath.Dir(planFile)
+	}
+	kept := map[string]int{}
+	var removed []string
+	for _, run := range runs {
+		dir := filepath.Join(runsDirectory, filepath.FromSlash(run.ID))
+		if kept[run.Name] < keep || dir == lastApplied {
+			kept[run.Name]++
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, fmt.Errorf("error removing run %q: %v", run.ID, err)
+		}
+		removed = append(removed, run.ID)
+	}
+	return removed, nil
+}
@@ -0,0 +1,111 @@
+package install
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// DefaultRunsDirectory is where information about the executor runs is kept
+	// when no other directory is configured.
+	DefaultRunsDirectory = "./runs"
+	// runTimestampFormat is the format of the run directory names
+	runTimestampFormat = "2006-01-02-15-04-05"
+	// runPlanFilename is the name of the copy of the plan used by a run
+	runPlanFilename = "kismatic-cluster.yaml"
+	// runOutcomeFilename is the name of the file that records the outcome of a run
+	runOutcomeFilename = "outcome"
+	// runChecksumFilename is the name of the file that records the checksum of the
+	// plan, without its secrets, and of the nodes targeted by a run
+	runChecksumFilename = "checksum"
+	// runPlaysFilename is the name of the file that records the plays completed by a run
+	runPlaysFilename = "completed-plays"
+	// runSummaryFilename is the name of the file that summarizes a run, written at its end
+	runSummaryFilename = "summary.yaml"
+	// runTimingFilename is the name of the file that records the duration of the plays,
+	// tasks and hosts of a run
+	runTimingFilename = "timing.yaml"
+
+	runOutcomeSuccess = "success"
+	runOutcomeFailure = "failure"
+	// runOutcomeAborted is the outcome of the runs that were interrupted or timed out
+	runOutcomeAborted = "aborted"
+)
+
+// appliedPlanRuns are the runs that apply the plan to the cluster, as opposed
+// to runs that only inspect it, such as the preflight checks.
+var appliedPlanRuns = []string{"apply", "add-node", "upgrade-cluster-services"}
+
+func writeRunOutcome(runDirectory string, outcome string) error {
+	file := filepath.Join(runDirectory, runOutcomeFilename)
+	if err := ioutil.WriteFile(file, []byte(outcome+"\n"), 0644); err != nil {
+		return fmt.Errorf("error recording run outcome to %s: %v", file, err)
+	}
+	return nil
+}
+
+func readRunOutcome(runDirectory string) (string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(runDirectory, runOutcomeFilename))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// LastAppliedPlanFile returns the path of the plan file that was used by the most
+// recent successful run that applied the plan to the cluster.
+func LastAppliedPlanFile(runsDirectory string) (string, error) {
+	// run directories are named after their start time, which sorts lexicographically
+	var runs []string
+	for _, name := range appliedPlanRuns {
+		dirs, err := ioutil.ReadDir(filepath.Join(runsDirectory, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", fmt.Errorf("error reading runs directory: %v", err)
+		}
+		for _, d := range dirs {
+			if d.IsDir() {
+				runs = append(runs, filepath.Join(runsDirectory, name, d.Name()))
+			}
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return filepath.Base(runs[i]) > filepath.Base(runs[j])
+	})
+	for _, run := range runs {
+		outcome, err := readRunOutcome(run)
+		if err != nil || outcome != runOutcomeSuccess {
+			continue
+		}
+		planFile := filepath.Join(run, runPlanFilename)
+		if _, err := os.Stat(planFile); err != nil {
+			continue
+		}
+		return planFile, nil
+	}
+	return "", fmt.Errorf("no successful run that applied the plan was found in %q", runsDirectory)
+}
+
+// lastRun returns the directory of the most recent run with the given name
+func lastRun(runsDirectory string, name string) (string, error) {
+	dirs, err := ioutil.ReadDir(filepath.Join(runsDirectory, name))
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("error reading runs directory: %v", err)
+	}
+	var last string
+	for _, d := range dirs {
+		if d.IsDir() && d.Name() > last {
+			last = d.Name()
+		}
+	}
+	if last == "" {
+		return "", fmt.Errorf("no %s run was found in %q", name, runsDirectory)
+	}
+	return filepath.Join(runsDirectory, name, last), nil
+}
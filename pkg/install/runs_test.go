@@ -0,0 +1,207 @@
+package install
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	"github.com/apprenda/kismatic/pkg/install/explain"
+)
+
+func TestLastAppliedPlanFile(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-runs")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	runs := []struct {
+		name    string
+		start   string
+		outcome string
+	}{
+		{"apply", "2018-06-01-10-00-00", runOutcomeSuccess},
+		{"add-node", "2018-06-02-10-00-00", runOutcomeSuccess},
+		{"apply", "2018-06-03-10-00-00", runOutcomeFailure},
+		{"preflight", "2018-06-04-10-00-00", runOutcomeSuccess},
+		// runs that were interrupted do not have an outcome
+		{"apply", "2018-06-05-10-00-00", ""},
+	}
+	for _, r := range runs {
+		dir := filepath.Join(tmp, r.name, r.start)
+		if err = os.MkdirAll(dir, 0777); err != nil {
+			t.Fatalf("error creating run directory: %v", err)
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, runPlanFilename), []byte{}, 0644); err != nil {
+			t.Fatalf("error writing plan file: %v", err)
+		}
+		if r.outcome != "" {
+			if err = writeRunOutcome(dir, r.outcome); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	file, err := LastAppliedPlanFile(tmp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := filepath.Join(tmp, "add-node", "2018-06-02-10-00-00", runPlanFilename)
+	if file != expected {
+		t.Errorf("expected %q, but got %q", expected, file)
+	}
+}
+
+func TestLastAppliedPlanFileNoRuns(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-runs")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	if _, err := LastAppliedPlanFile(tmp); err == nil {
+		t.Errorf("expected an error when there are no runs")
+	}
+}
+
+func TestJSONOutputUsesRunID(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	runsDir := mustGetTempDir(t)
+	defer os.RemoveAll(runsDir)
+	e, err := NewExecutor(stdout, stderr, ExecutorOptions{GeneratedAssetsDirectory: "generated", RunsDirectory: runsDir, OutputFormat: "json"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ae := e.(*ansibleExecutor)
+	if ae.stdout != stderr {
+		t.Errorf("expected the messages of the executor to be written to stderr")
+	}
+	var used explain.AnsibleEventExplainer
+	ae.runnerExplainerFactory = func(e explain.AnsibleEventExplainer, _ io.Writer) (ansible.Runner, *explain.AnsibleEventStreamExplainer, error) {
+		used = e
+		return nil, nil, nil
+	}
+	if _, _, err = ae.ansibleRunnerWithExplainer(ae.defaultExplainer(), ioutil.Discard, filepath.Join(runsDir, "apply", "2018-06-01-10-00-00")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	used.ExplainEvent(&ansible.PlaybookEndEvent{})
+	if !strings.Contains(stdout.String(), `"run_id":"apply/2018-06-01-10-00-00"`) {
+		t.Errorf("expected a JSON event with the run ID on stdout, but got %q", stdout.String())
+	}
+}
+
+func TestRunRecorder(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-runs")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	run := filepath.Join(tmp, "apply", "2018-06-01-10-00-00")
+	if err = os.MkdirAll(run, 0777); err != nil {
+		t.Fatalf("error creating run directory: %v", err)
+	}
+	r := newRunRecorder(task{
+		name:     "apply",
+		playbook: "kubernetes.yaml",
+		inventory: ansible.Inventory{Roles: []ansible.Role{
+			{Name: "etcd", Nodes: []ansible.Node{{Host: "node01"}}},
+			{Name: "master", Nodes: []ansible.Node{{Host: "node01"}, {Host: "node02"}}},
+		}},
+	})
+	task := &ansible.TaskStartEvent{}
+	task.Name = "start etcd"
+	failed := &ansible.RunnerFailedEvent{}
+	failed.Host = "node01"
+	failed.Result.Message = "etcd did not start"
+	ignored := &ansible.RunnerFailedEvent{}
+	ignored.Host = "node02"
+	ignored.IgnoreErrors = true
+	unreachable := &ansible.RunnerUnreachableEvent{}
+	unreachable.Host = "node02"
+	for _, e := range []ansible.Event{play("Install etcd"), task, failed, ignored, unreachable} {
+		r.record(e)
+	}
+	if err = r.write(run, runOutcomeFailure); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s, err := ReadRun(tmp, "apply/2018-06-01-10-00-00")
+	if err != nil {
+		t.Fatalf("unexpected error reading run: %v", err)
+	}
+	if s.ID != "apply/2018-06-01-10-00-00" || s.Name != "apply" || s.Outcome != runOutcomeFailure || s.Duration() < 0 {
+		t.Errorf("unexpected run summary %+v", s)
+	}
+	if !reflect.DeepEqual(s.Nodes, []string{"node01", "node02"}) {
+		t.Errorf("expected the nodes of the inventory to be targeted, but got %v", s.Nodes)
+	}
+	expected := []RunFailure{
+		{Play: "Install etcd", Task: "start etcd", Host: "node01", Message: "etcd did not start"},
+		{Play: "Install etcd", Task: "start etcd", Host: "node02", Unreachable: true},
+	}
+	if !reflect.DeepEqual(s.Failures, expected) {
+		t.Errorf("expected failures %+v, but got %+v", expected, s.Failures)
+	}
+	if _, err = ReadRun(tmp, "../apply"); err == nil {
+		t.Errorf("expected an error with an invalid run ID")
+	}
+}
+
+func TestPruneRuns(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-runs")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	runs := []struct {
+		id      string
+		outcome string
+	}{
+		{"apply/2018-06-01-10-00-00", runOutcomeSuccess},
+		{"apply/2018-06-02-10-00-00", runOutcomeFailure},
+		{"apply/2018-06-03-10-00-00", runOutcomeFailure},
+		{"preflight/2018-06-01-09-00-00", runOutcomeSuccess},
+		{"preflight/2018-06-02-09-00-00", ""},
+	}
+	for _, r := range runs {
+		dir := filepath.Join(tmp, r.id)
+		if err = os.MkdirAll(dir, 0777); err != nil {
+			t.Fatalf("error creating run directory: %v", err)
+		}
+		if r.outcome != "" {
+			if err = writeRunOutcome(dir, r.outcome); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err = ioutil.WriteFile(filepath.Join(dir, runPlanFilename), []byte("{}"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list, err := ListRuns(tmp)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list) != 5 || list[0].ID != "apply/2018-06-03-10-00-00" || list[2].Outcome != "unknown" {
+		t.Errorf("unexpected runs %+v", list)
+	}
+
+	removed, err := PruneRuns(tmp, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// the last successful apply run holds the last applied plan file
+	expected := []string{"apply/2018-06-02-10-00-00", "preflight/2018-06-01-09-00-00"}
+	if !reflect.DeepEqual(removed, expected) {
+		t.Errorf("expected runs %v to be removed, but got %v", expected, removed)
+	}
+	if _, err = LastAppliedPlanFile(tmp); err != nil {
+		t.Errorf("expected the last applied plan file to be kept: %v", err)
+	}
+	if _, err = PruneRuns(tmp, 0); err == nil {
+		t.Errorf("expected an error when keeping no runs")
+	}
+}
@@ -0,0 +1,236 @@
+package install
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// slowestTimings is the number of tasks and hosts printed at the end of a run
+const slowestTimings = 5
+
+// RunTiming is the wall-clock duration of the plays, tasks and hosts of a run
+type RunTiming struct {
+	// Duration of the run
+	Duration time.Duration `yaml:"duration"`
+	// Plays of the run, in the order they were run
+	Plays []PlayTiming `yaml:"plays"`
+	// Hosts of the run, slowest first. The duration of a host is the time
+	// it took to complete its tasks.
+	Hosts []HostTiming `yaml:"hosts"`
+}
+
+// PlayTiming is the wall-clock duration of a play, and of its tasks
+type PlayTiming struct {
+	Name     string        `yaml:"name"`
+	Duration time.Duration `yaml:"duration"`
+	Tasks    []TaskTiming  `yaml:"tasks"`
+}
+
+// TaskTiming is the wall-clock duration of a task, and the time it took on each host
+type TaskTiming struct {
+	Name     string        `yaml:"name"`
+	Duration time.Duration `yaml:"duration"`
+	Hosts    []HostTiming  `yaml:"hosts,omitempty"`
+}
+
+// HostTiming is the time it took a host to complete one or more tasks
+type HostTiming struct {
+	Host     string        `yaml:"host"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// runTimer measures the durations of the plays, tasks and hosts of the event stream
+type runTimer struct {
+	mu        sync.Mutex
+	now       func() time.Time
+	start     time.Time
+	plays     []PlayTiming
+	playStart time.Time
+	taskStart time.Time
+}
+
+func newRunTimer() *runTimer {
+	return &runTimer{now: time.Now, start: time.Now()}
+}
+
+// watch measures the durations of the event stream, and forwards the events
+func (r *runTimer) watch(in <-chan ansible.Event) <-chan ansible.Event {
+	out := make(chan ansible.Event)
+	go func() {
+		defer close(out)
+		for e := range in {
+			r.record(e)
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (r *runTimer) record(e ansible.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	switch event := e.(type) {
+	case *ansible.PlayStartEvent:
+		r.endPlay(now)
+		r.plays = append(r.plays, PlayTiming{Name: event.Name})
+		r.playStart = now
+	case *ansible.TaskStartEvent:
+		r.startTask(event.Name, now)
+	case *ansible.HandlerTaskStartEvent:
+		r.startTask(event.Name, now)
+	case *ansible.PlaybookEndEvent:
+		r.endPlay(now)
+	case *ansible.RunnerOKEvent:
+		r.hostDone(event.Host, now)
+	case *ansible.RunnerFailedEvent:
+		r.hostDone(event.Host, now)
+	case *ansible.RunnerSkippedEvent:
+		r.hostDone(event.Host, now)
+	case *ansible.RunnerUnreachableEvent:
+		r.hostDone(event.Host, now)
+	}
+}
+
+// currentPlay returns the play that is running, or nil if no play is running
+func (r *runTimer) currentPlay() *PlayTiming {
+	if r.playStart.IsZero() {
+		return nil
+	}
+	return &r.plays[len(r.plays)-1]
+}
+
+// currentTask returns the task that is running, or nil if no task is running
+func (r *runTimer) currentTask() *TaskTiming {
+	p := r.currentPlay()
+	if p == nil || r.taskStart.IsZero() {
+		return nil
+	}
+	return &p.Tasks[len(p.Tasks)-1]
+}
+
+func (r *runTimer) startTask(name string, now time.Time) {
+	r.endTask(now)
+	p := r.currentPlay()
+	if p == nil {
+		return
+	}
+	p.Tasks = append(p.Tasks, TaskTiming{Name: name})
+	r.taskStart = now
+}
+
+func (r *runTimer) endTask(now time.Time) {
+	if t := r.currentTask(); t != nil {
+		t.Duration = now.Sub(r.taskStart)
+	}
+	r.taskStart = time.Time{}
+}
+
+func (r *runTimer) endPlay(now time.Time) {
+	r.endTask(now)
+	if p := r.currentPlay(); p != nil {
+		p.Duration = now.Sub(r.playStart)
+	}
+	r.playStart = time.Time{}
+}
+
+// hostDone records the time it took the host to complete the current task.
+// Tasks that loop over items report a result for the host once all the items are done.
+func (r *runTimer) hostDone(host string, now time.Time) {
+	t := r.currentTask()
+	if t == nil {
+		return
+	}
+	t.Hosts = append(t.Hosts, HostTiming{Host: host, Duration: now.Sub(r.taskStart)})
+}
+
+// timing returns the durations measured so far. The play and task that are still
+// running, if any, end now.
+func (r *runTimer) timing() RunTiming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.endPlay(r.now())
+	rt := RunTiming{Duration: r.now().Sub(r.start), Plays: r.plays}
+	hosts := map[string]time.Duration{}
+	for _, p := range r.plays {
+		for _, t := range p.Tasks {
+			for _, h := range t.Hosts {
+				hosts[h.Host] += h.Duration
+			}
+		}
+	}
+	for host, d := range hosts {
+		rt.Hosts = append(rt.Hosts, HostTiming{Host: host, Duration: d})
+	}
+	sort.Slice(rt.Hosts, func(i, j int) bool {
+		if rt.Hosts[i].Duration == rt.Hosts[j].Duration {
+			return rt.Hosts[i].Host < rt.Hosts[j].Host
+		}
+		return rt.Hosts[i].Duration > rt.Hosts[j].Duration
+	})
+	return rt
+}
+
+// write records the timing of the run in the run directory, and prints the slowest
+// tasks and hosts when out is not nil
+func (r *runTimer) write(out io.Writer, runDirectory string) error {
+	rt := r.timing()
+	b, err := yaml.Marshal(rt)
+	if err != nil {
+		return fmt.Errorf("error marshalling run timing to yaml: %v", err)
+	}
+	file := filepath.Join(runDirectory, runTimingFilename)
+	if err = ioutil.WriteFile(file, b, 0644); err != nil {
+		return fmt.Errorf("error recording run timing to %s: %v", file, err)
+	}
+	if out != nil {
+		printTiming(out, rt, file)
+	}
+	return nil
+}
+
+// printTiming prints the slowest tasks and hosts of the run
+func printTiming(out io.Writer, rt RunTiming, file string) {
+	type namedTask struct {
+		play string
+		TaskTiming
+	}
+	var tasks []namedTask
+	for _, p := range rt.Plays {
+		for _, t := range p.Tasks {
+			tasks = append(tasks, namedTask{p.Name, t})
+		}
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].Duration > tasks[j].Duration
+	})
+	fmt.Fprintf(out, "\nSlowest tasks (the run took %s):\n", roundDuration(rt.Duration))
+	for i := 0; i < len(tasks) && i < slowestTimings; i++ {
+		fmt.Fprintf(out, "  %-8s %s: %s\n", roundDuration(tasks[i].Duration), tasks[i].play, tasks[i].Name)
+	}
+	if len(rt.Hosts) > 0 {
+		fmt.Fprintln(out, "Slowest hosts:")
+		for i := 0; i < len(rt.Hosts) && i < slowestTimings; i++ {
+			fmt.Fprintf(out, "  %-8s %s\n", roundDuration(rt.Hosts[i].Duration), rt.Hosts[i].Host)
+		}
+	}
+	fmt.Fprintf(out, "The duration of every play, task and host was recorded in %q\n", file)
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	if d < time.Second {
+		return d / time.Millisecond * time.Millisecond
+	}
+	return d / time.Second * time.Second
+}
@@ -0,0 +1,104 @@
+package install
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/ansible"
+)
+
+func TestRunTimer(t *testing.T) {
+	start := time.Date(2018, 6, 1, 10, 0, 0, 0, time.UTC)
+	clock := start
+	r := newRunTimer()
+	r.start = start
+	r.now = func() time.Time { return clock }
+	task := func(name string) *ansible.TaskStartEvent {
+		e := &ansible.TaskStartEvent{}
+		e.Name = name
+		return e
+	}
+	ok := func(host string) *ansible.RunnerOKEvent {
+		e := &ansible.RunnerOKEvent{}
+		e.Host = host
+		return e
+	}
+	events := []struct {
+		after time.Duration
+		event ansible.Event
+	}{
+		{0, play("etcd")},
+		{time.Second, task("install etcd")},
+		{10 * time.Second, ok("etcd01")},
+		{20 * time.Second, ok("etcd02")},
+		{0, task("start etcd")},
+		{5 * time.Second, ok("etcd02")},
+		{0, play("master")},
+		{0, task("install master")},
+		{60 * time.Second, ok("master01")},
+		{0, &ansible.PlaybookEndEvent{}},
+	}
+	for _, e := range events {
+		clock = clock.Add(e.after)
+		r.record(e.event)
+	}
+	clock = clock.Add(time.Second)
+
+	rt := r.timing()
+	if rt.Duration != 97*time.Second {
+		t.Errorf("expected the run to take 97s, but got %s", rt.Duration)
+	}
+	expected := []PlayTiming{
+		{Name: "etcd", Duration: 36 * time.Second, Tasks: []TaskTiming{
+			{Name: "install etcd", Duration: 30 * time.Second, Hosts: []HostTiming{{"etcd01", 10 * time.Second}, {"etcd02", 30 * time.Second}}},
+			{Name: "start etcd", Duration: 5 * time.Second, Hosts: []HostTiming{{"etcd02", 5 * time.Second}}},
+		}},
+		{Name: "master", Duration: 60 * time.Second, Tasks: []TaskTiming{
+			{Name: "install master", Duration: 60 * time.Second, Hosts: []HostTiming{{"master01", 60 * time.Second}}},
+		}},
+	}
+	if !reflect.DeepEqual(rt.Plays, expected) {
+		t.Errorf("expected plays %+v, but got %+v", expected, rt.Plays)
+	}
+	expectedHosts := []HostTiming{{"master01", 60 * time.Second}, {"etcd02", 35 * time.Second}, {"etcd01", 10 * time.Second}}
+	if !reflect.DeepEqual(rt.Hosts, expectedHosts) {
+		t.Errorf("expected hosts %+v, but got %+v", expectedHosts, rt.Hosts)
+	}
+
+	tmp, err := ioutil.TempDir("", "ket-test-timing")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	out := &bytes.Buffer{}
+	if err = r.write(out, tmp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out.String(), "1m0s     master: install master\n  30s      etcd: install etcd") {
+		t.Errorf("expected the slowest tasks to be printed, but got:\n%s", out.String())
+	}
+	b, err := ioutil.ReadFile(filepath.Join(tmp, runTimingFilename))
+	if err != nil {
+		t.Fatalf("error reading timing file: %v", err)
+	}
+	if !strings.Contains(string(b), "duration: 36s") {
+		t.Errorf("expected the durations to be recorded, but got:\n%s", b)
+	}
+
+	// the timing is recorded without being printed
+	if err = os.Remove(filepath.Join(tmp, runTimingFilename)); err != nil {
+		t.Fatalf("error removing timing file: %v", err)
+	}
+	if err = r.write(nil, tmp); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(tmp, runTimingFilename)); err != nil {
+		t.Errorf("expected the timing to be recorded, but got: %v", err)
+	}
+}
@@ -15,6 +15,7 @@ type upgradeKubeInfoClient interface {
 	data.PersistentVolumeClaimGetter
 	data.PersistentVolumeGetter
 	data.StatefulSetGetter
+	data.PDBLister
 }
 
 type etcdNodeCountErr struct{}
@@ -137,6 +138,22 @@ func (e podRunningJobErr) Error() string {
 	return fmt.Sprintf(`Pod that belongs to job "%s/%s" is running on this node.`, e.name, e.namespace)
 }
 
+type podDisruptionBudgetErr struct {
+	namespace string
+	name      string
+	workloads []string
+	evicted   int
+	allowed   int32
+	healthy   int32
+	desired   int32
+}
+
+func (e podDisruptionBudgetErr) Error() string {
+	return fmt.Sprintf(`Draining this node would violate PodDisruptionBudget "%s/%s": %d of its pods (%s) are running on this node, `+
+		"but only %d disruptions are allowed, with %d healthy pods and %d desired healthy pods. "+
+		"The drain would wait until the budget allows the evictions.", e.namespace, e.name, e.evicted, strings.Join(e.workloads, ", "), e.allowed, e.healthy, e.desired)
+}
+
 // DetectNodeUpgradeSafety determines whether it's safe to upgrade a specific node
 // listed in the plan file. If any condition that could result in data or availability
 // loss is detected, the upgrade is deemed unsafe, and the conditions are returned as errors.
@@ -187,6 +204,7 @@ func detectWorkerNodeUpgradeSafety(node Node, kubeClient upgradeKubeInfoClient)
 		errs = append(errs, fmt.Errorf("unable to determine node upgrade safety: %v", err))
 		return errs
 	}
+	errs = append(errs, detectPodDisruptionBudgetViolations(node, *podList, kubeClient)...)
 	nodePods := []data.Pod{}
 	for _, p := range podList.Items {
 		// Don't check pods that are running in "kube-system" namespace
@@ -301,3 +319,62 @@ func detectWorkerNodeUpgradeSafety(node Node, kubeClient upgradeKubeInfoClient)
 
 	return errs
 }
+
+// detectPodDisruptionBudgetViolations returns the PodDisruptionBudgets that would be violated
+// by draining the node. The running pods that are evicted by the drain, which are all the pods
+// on the node except for DaemonSet and mirror pods, cannot outnumber the disruptions allowed
+// by the budgets that select them.
+// The check is deliberately conservative: the drain evicts the pods one at a time, and could
+// evict more pods than the budget currently allows once the evicted pods are replaced by
+// healthy pods on other nodes. Whether they are replaced cannot be known before the drain,
+// and the drain waits for them otherwise, so the disruptions must be allowed upfront.
+func detectPodDisruptionBudgetViolations(node Node, podList data.PodList, kubeClient upgradeKubeInfoClient) []error {
+	pdbList, err := kubeClient.ListPodDisruptionBudgets()
+	if err != nil || pdbList == nil {
+		return []error{fmt.Errorf("unable to determine the PodDisruptionBudgets affected by draining the node: %v", err)}
+	}
+	// the name of the kubernetes node is the lower case hostname
+	nodeName := strings.ToLower(node.Host)
+	errs := []error{}
+	for _, pdb := range pdbList.Items {
+		var evicted int
+		var workloads []string
+		seen := map[string]bool{}
+		for _, p := range podList.Items {
+			if p.Spec.NodeName != nodeName || p.Namespace != pdb.Namespace || p.Status.Phase != data.PodRunning {
+				continue
+			}
+			if _, ok := p.Annotations["kubernetes.io/config.mirror"]; ok {
+				continue
+			}
+			workload := fmt.Sprintf(`pod "%s/%s"`, p.Namespace, p.Name)
+			if len(p.OwnerReferences) > 0 {
+				owner := p.OwnerReferences[0]
+				if strings.ToLower(owner.Kind) == "daemonset" {
+					continue
+				}
+				workload = fmt.Sprintf(`%s "%s/%s"`, owner.Kind, p.Namespace, owner.Name)
+			}
+			if !pdb.Spec.Selector.Matches(p.Labels) {
+				continue
+			}
+			evicted++
+			if !seen[workload] {
+				seen[workload] = true
+				workloads = append(workloads, workload)
+			}
+		}
+		if evicted > int(pdb.Status.PodDisruptionsAllowed) {
+			errs = append(errs, podDisruptionBudgetErr{
+				namespace: pdb.Namespace,
+				name:      pdb.Name,
+				workloads: workloads,
+				evicted:   evicted,
+				allowed:   pdb.Status.PodDisruptionsAllowed,
+				healthy:   pdb.Status.CurrentHealthy,
+				desired:   pdb.Status.DesiredHealthy,
+			})
+		}
+	}
+	return errs
+}
@@ -0,0 +1,131 @@
+package install
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/data"
+)
+
+// The actions taken when the health gates fail on the upgraded worker nodes
+const (
+	HealthGateFailureAbort = "abort"
+	HealthGateFailurePause = "pause"
+)
+
+// UpgradeGate is called after each batch of worker nodes is upgraded, with the nodes of the
+// batch. The upgrade of the nodes is stopped when it returns an error.
+type UpgradeGate func(upgraded []ListableNode) error
+
+// NodeCommandRunner runs a command on a node of the cluster, such as over SSH
+type NodeCommandRunner func(node Node, command string) error
+
+// UpgradeHealthGates are the health gates that the upgraded worker nodes must pass before
+// the next batch of worker nodes is upgraded
+type UpgradeHealthGates struct {
+	// Command is run on each upgraded node, and must succeed. The command is not run when empty.
+	Command string
+	// Timeout is the maximum duration to wait for the gates to pass
+	Timeout time.Duration
+	// Interval is the duration between two checks of the gates
+	Interval time.Duration
+}
+
+type upgradeHealthClient interface {
+	data.NodeGetter
+	data.PodLister
+}
+
+// CheckUpgradeHealth checks the health gates on the upgraded nodes, and returns the gates that
+// failed. The node must be Ready, and all the pods scheduled on the node must be running and
+// Ready, or have completed successfully.
+func CheckUpgradeHealth(nodes []ListableNode, gates UpgradeHealthGates, kubeClient upgradeHealthClient, runCommand NodeCommandRunner) []error {
+	errs := []error{}
+	podList, err := kubeClient.ListPods()
+	if err != nil {
+		errs = append(errs, fmt.Errorf("unable to list the pods of the cluster: %v", err))
+	}
+	for _, n := range nodes {
+		// the name of the kubernetes node is the lower case hostname
+		name := strings.ToLower(n.Node.Host)
+		node, err := kubeClient.GetNode(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to get node %q: %v", name, err))
+		} else if err := nodeReady(*node); err != nil {
+			errs = append(errs, err)
+		}
+		if podList != nil {
+			for _, p := range podList.Items {
+				if p.Spec.NodeName != name {
+					continue
+				}
+				if err := podHealthy(p); err != nil {
+					errs = append(errs, fmt.Errorf("pod \"%s/%s\" on node %q %v", p.Namespace, p.Name, name, err))
+				}
+			}
+		}
+		if gates.Command != "" {
+			if err := runCommand(n.Node, gates.Command); err != nil {
+				errs = append(errs, fmt.Errorf("health gate command failed on node %q: %v", name, err))
+			}
+		}
+	}
+	return errs
+}
+
+func nodeReady(node data.Node) error {
+	for _, c := range node.Status.Conditions {
+		if c.Type != "Ready" {
+			continue
+		}
+		if c.Status == "True" {
+			return nil
+		}
+		return fmt.Errorf("node %q is not Ready: %s %s", node.Name, c.Reason, c.Message)
+	}
+	return fmt.Errorf("node %q is not Ready: the node has not reported its status", node.Name)
+}
+
+// podHealthy returns an error if the pod did not complete successfully, and is not running
+// and Ready. A pod whose containers are crashing is running, but not Ready.
+func podHealthy(p data.Pod) error {
+	if p.Status.Phase == data.PodSucceeded {
+		return nil
+	}
+	if p.Status.Phase != data.PodRunning {
+		return fmt.Errorf("is %s", p.Status.Phase)
+	}
+	for _, c := range p.Status.ContainerStatuses {
+		if !c.Ready && c.State.Waiting != nil {
+			return fmt.Errorf("is not Ready: container %q is waiting: %s %s", c.Name, c.State.Waiting.Reason, c.State.Waiting.Message)
+		}
+	}
+	for _, c := range p.Status.Conditions {
+		if c.Type == "Ready" && c.Status != "True" {
+			return fmt.Errorf("is not Ready: %s %s", c.Reason, c.Message)
+		}
+	}
+	return nil
+}
+
+// WaitForUpgradeHealth checks the health gates on the upgraded nodes until they pass, the
+// timeout of the gates is exceeded, or the context is done. It returns the gates that failed
+// on the last check.
+func WaitForUpgradeHealth(ctx context.Context, nodes []ListableNode, gates UpgradeHealthGates, kubeClient upgradeHealthClient, runCommand NodeCommandRunner) []error {
+	timeout := time.After(gates.Timeout)
+	for {
+		errs := CheckUpgradeHealth(nodes, gates, kubeClient, runCommand)
+		if len(errs) == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return append(errs, fmt.Errorf("stopped waiting for the health gates to pass: %v", ctx.Err()))
+		case <-timeout:
+			return append(errs, fmt.Errorf("the health gates did not pass within %v", gates.Timeout))
+		case <-time.After(gates.Interval):
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package install
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apprenda/kismatic/pkg/data"
+)
+
+type fakeHealthClient struct {
+	nodes map[string]data.Node
+	pods  []data.Pod
+}
+
+func (f fakeHealthClient) GetNode(name string) (*data.Node, error) {
+	n, ok := f.nodes[name]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return &n, nil
+}
+
+func (f fakeHealthClient) ListPods() (*data.PodList, error) {
+	return &data.PodList{Items: f.pods}, nil
+}
+
+func kubeNode(name string, ready string) data.Node {
+	n := data.Node{ObjectMeta: data.ObjectMeta{Name: name}}
+	n.Status.Conditions = []data.NodeCondition{{Type: "Ready", Status: ready, Reason: "KubeletNotReady"}}
+	return n
+}
+
+func kubePod(name string, node string, phase data.PodPhase) data.Pod {
+	p := data.Pod{ObjectMeta: data.ObjectMeta{Namespace: "default", Name: name}}
+	p.Spec.NodeName = node
+	p.Status.Phase = phase
+	return p
+}
+
+func crashingPod(p data.Pod) data.Pod {
+	p.Status.ContainerStatuses = []data.ContainerStatus{{
+		Name:         p.Name,
+		RestartCount: 5,
+		State:        data.ContainerState{Waiting: &data.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+	}}
+	return notReadyPod(p)
+}
+
+func notReadyPod(p data.Pod) data.Pod {
+	p.Status.Conditions = []data.PodCondition{{Type: "Ready", Status: "False", Reason: "ContainersNotReady"}}
+	return p
+}
+
+func TestCheckUpgradeHealth(t *testing.T) {
+	nodes := []ListableNode{{Node: Node{Host: "Worker1"}, Roles: []string{"worker"}}}
+	noCommand := func(node Node, command string) error {
+		t.Errorf("unexpected command %q on node %q", command, node.Host)
+		return nil
+	}
+	tests := []struct {
+		name       string
+		client     fakeHealthClient
+		command    string
+		runCommand NodeCommandRunner
+		errs       []string
+	}{
+		{
+			name: "healthy node",
+			client: fakeHealthClient{
+				nodes: map[string]data.Node{"worker1": kubeNode("worker1", "True")},
+				pods:  []data.Pod{kubePod("web", "worker1", data.PodRunning), kubePod("job", "worker1", data.PodSucceeded), kubePod("other", "worker2", data.PodPending)},
+			},
+			runCommand: noCommand,
+		},
+		{
+			name: "node not ready, and pods not running",
+			client: fakeHealthClient{
+				nodes: map[string]data.Node{"worker1": kubeNode("worker1", "False")},
+				pods:  []data.Pod{kubePod("web", "worker1", data.PodPending), kubePod("db", "worker1", data.PodFailed)},
+			},
+			runCommand: noCommand,
+			errs:       []string{`node "worker1" is not Ready`, `pod "default/web" on node "worker1" is Pending`, `pod "default/db" on node "worker1" is Failed`},
+		},
+		{
+			name: "running pods that are not ready",
+			client: fakeHealthClient{
+				nodes: map[string]data.Node{"worker1": kubeNode("worker1", "True")},
+				pods: []data.Pod{
+					crashingPod(kubePod("web", "worker1", data.PodRunning)),
+					notReadyPod(kubePod("db", "worker1", data.PodRunning)),
+				},
+			},
+			runCommand: noCommand,
+			errs:       []string{`pod "default/web" on node "worker1" is not Ready: container "web" is waiting: CrashLoopBackOff`, `pod "default/db" on node "worker1" is not Ready: ContainersNotReady`},
+		},
+		{
+			name:       "node not found",
+			client:     fakeHealthClient{},
+			runCommand: noCommand,
+			errs:       []string{`unable to get node "worker1"`},
+		},
+		{
+			name:    "failed command",
+			client:  fakeHealthClient{nodes: map[string]data.Node{"worker1": kubeNode("worker1", "True")}},
+			command: "curl -f localhost:8080/healthz",
+			runCommand: func(node Node, command string) error {
+				if node.Host != "Worker1" || command != "curl -f localhost:8080/healthz" {
+					t.Errorf("unexpected command %q on node %q", command, node.Host)
+				}
+				return errors.New("exit status 22")
+			},
+			errs: []string{`health gate command failed on node "worker1": exit status 22`},
+		},
+	}
+	for _, test := range tests {
+		errs := CheckUpgradeHealth(nodes, UpgradeHealthGates{Command: test.command}, test.client, test.runCommand)
+		if len(errs) != len(test.errs) {
+			t.Errorf("%s: expected %d errors, got %v", test.name, len(test.errs), errs)
+			continue
+		}
+		for i, err := range errs {
+			if !strings.Contains(err.Error(), test.errs[i]) {
+				t.Errorf("%s: expected error %q, got %q", test.name, test.errs[i], err)
+			}
+		}
+	}
+}
+
+func TestWaitForUpgradeHealth(t *testing.T) {
+	nodes := []ListableNode{{Node: Node{Host: "worker1"}, Roles: []string{"worker"}}}
+	client := fakeHealthClient{nodes: map[string]data.Node{"worker1": kubeNode("worker1", "True")}}
+	gates := UpgradeHealthGates{Command: "true", Timeout: time.Second, Interval: time.Millisecond}
+
+	// the gates pass on the third check
+	checks := 0
+	runCommand := func(node Node, command string) error {
+		checks++
+		if checks < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	}
+	if errs := WaitForUpgradeHealth(context.Background(), nodes, gates, client, runCommand); len(errs) != 0 {
+		t.Errorf("expected the gates to pass, got %v", errs)
+	}
+	if checks != 3 {
+		t.Errorf("expected 3 checks, got %d", checks)
+	}
+
+	// the gates never pass
+	gates.Timeout = 20 * time.Millisecond
+	failing := func(node Node, command string) error { return errors.New("unhealthy") }
+	errs := WaitForUpgradeHealth(context.Background(), nodes, gates, client, failing)
+	if len(errs) != 2 || !strings.Contains(errs[1].Error(), "did not pass within") {
+		t.Errorf("expected the gates to time out, got %v", errs)
+	}
+
+	// the context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	gates.Timeout = time.Minute
+	errs = WaitForUpgradeHealth(ctx, nodes, gates, client, failing)
+	if len(errs) != 2 || !strings.Contains(errs[1].Error(), "stopped waiting") {
+		t.Errorf("expected to stop waiting, got %v", errs)
+	}
+}
@@ -0,0 +1,226 @@
+package install
+
+// The phases of the upgrade of the nodes, in order
+const (
+	UpgradePhaseEtcd   = "etcd"
+	UpgradePhaseMaster = "master"
+	UpgradePhaseWorker = "worker"
+)
+
+// UpgradePlan is the computed plan of an upgrade of the cluster
+type UpgradePlan struct {
+	// TargetVersion is the version of Kismatic that the nodes are upgraded to
+	TargetVersion string `json:"targetVersion" yaml:"targetVersion"`
+	// KubernetesVersion is the version of Kubernetes that the nodes are upgraded to
+	KubernetesVersion string `json:"kubernetesVersion" yaml:"kubernetesVersion"`
+	// Online is true when the upgrade is online, and the safety checks were run
+	Online bool `json:"online" yaml:"online"`
+	// PartialAllowed is true when the nodes that are not safe to upgrade are skipped
+	PartialAllowed bool `json:"partialAllowed" yaml:"partialAllowed"`
+	// MaxParallelWorkers is the maximum number of worker nodes that are upgraded together
+	MaxParallelWorkers int `json:"maxParallelWorkers" yaml:"maxParallelWorkers"`
+	// Canary is true when a single worker node is upgraded first, and the health gates
+	// are checked after each batch of worker nodes
+	Canary bool `json:"canary" yaml:"canary"`
+	// Blocked is the reason the upgrade stops before upgrading any node, and asks
+	// for confirmation, if any
+	Blocked string `json:"blocked,omitempty" yaml:"blocked,omitempty"`
+	// Nodes of the cluster
+	Nodes []UpgradePlanNode `json:"nodes" yaml:"nodes"`
+	// Phases in which the nodes are upgraded, in order
+	Phases []UpgradePlanPhase `json:"phases" yaml:"phases"`
+	// ClusterServices is the upgrade of the cluster services, after the nodes
+	ClusterServices UpgradePlanClusterServices `json:"clusterServices" yaml:"clusterServices"`
+}
+
+// UpgradePlanNode is a node of the cluster, and whether it is upgraded
+type UpgradePlanNode struct {
+	Host  string   `json:"host" yaml:"host"`
+	IP    string   `json:"ip" yaml:"ip"`
+	Roles []string `json:"roles" yaml:"roles"`
+	// Version of Kismatic that the node is at
+	Version string `json:"version" yaml:"version"`
+	// KubernetesVersion is the version of Kubernetes that the node is at
+	KubernetesVersion string `json:"kubernetesVersion,omitempty" yaml:"kubernetesVersion,omitempty"`
+	// OutOfDate is true when the node is not at the target versions
+	OutOfDate bool `json:"outOfDate" yaml:"outOfDate"`
+	// Upgrade is true when the node is upgraded in one of the phases
+	Upgrade bool `json:"upgrade" yaml:"upgrade"`
+	// SkipReason is the reason an out of date node is not upgraded, if any
+	SkipReason string `json:"skipReason,omitempty" yaml:"skipReason,omitempty"`
+	// SafetyErrors are the safety checks of the online upgrade that failed on the node
+	SafetyErrors []string `json:"safetyErrors,omitempty" yaml:"safetyErrors,omitempty"`
+}
+
+// UpgradePlanPhase is a phase of the upgrade of the nodes
+type UpgradePlanPhase struct {
+	// Name of the phase: "etcd", "master" or "worker"
+	Name string `json:"name" yaml:"name"`
+	// Batches of nodes that are upgraded together, in order
+	Batches [][]string `json:"batches" yaml:"batches"`
+}
+
+// UpgradePlanClusterServices is the upgrade of the cluster services
+type UpgradePlanClusterServices struct {
+	// Playbook that upgrades the cluster services
+	Playbook string `json:"playbook" yaml:"playbook"`
+	// Skipped is true when the cluster services are not upgraded, as in a partial upgrade
+	Skipped bool `json:"skipped" yaml:"skipped"`
+	// SmokeTest is true when the smoke test runs after the cluster services are upgraded
+	SmokeTest bool `json:"smokeTest" yaml:"smokeTest"`
+}
+
+// UpgradePlanOptions are the options of the upgrade that the plan is computed for
+type UpgradePlanOptions struct {
+	Online             bool
+	PartialAllowed     bool
+	IgnoreSafetyChecks bool
+	MaxParallelWorkers int
+	Canary             bool
+}
+
+// upgradeBatch is a batch of nodes that are upgraded together
+type upgradeBatch struct {
+	phase string
+	nodes []ListableNode
+}
+
+// NodesToUpgrade returns the nodes that are not at the target versions, and the nodes that are
+func NodesToUpgrade(plan Plan, nodes []ListableNode) (toUpgrade []ListableNode, toSkip []ListableNode) {
+	for _, n := range nodes {
+		// run if KET version or component versions are different
+		// don't check component versions if the node has only "etcd" role
+		if IsOlderVersion(n.Version) || (!(len(n.Roles) == 1 && n.Roles[0] == "etcd") && plan.Cluster.Version != n.ComponentVersions.Kubernetes) {
+			toUpgrade = append(toUpgrade, n)
+		} else {
+			toSkip = append(toSkip, n)
+		}
+	}
+	return toUpgrade, toSkip
+}
+
+// upgradeBatches returns the batches in which the nodes are upgraded, in order. Etcd nodes are
+// upgraded first, one at a time, then master nodes, one at a time, and then the rest of the nodes,
+// in batches of up to maxParallelWorkers nodes. With canary, the first batch of the rest of the
+// nodes is a single node. Nodes with multiple roles are upgraded once, in the first phase of
+// their roles.
+func upgradeBatches(nodes []ListableNode, maxParallelWorkers int, canary bool) []upgradeBatch {
+	var batches []upgradeBatch
+	// Nodes can have multiple roles. For this reason, we need to keep track of which nodes
+	// have been upgraded to avoid re-upgrading them.
+	upgraded := map[string]bool{}
+	for _, phase := range []string{UpgradePhaseEtcd, UpgradePhaseMaster} {
+		for _, n := range nodes {
+			if !upgraded[n.Node.IP] && hasRole(n, phase) {
+				batches = append(batches, upgradeBatch{phase: phase, nodes: []ListableNode{n}})
+				upgraded[n.Node.IP] = true
+			}
+		}
+	}
+	var workers []ListableNode
+	for _, n := range nodes {
+		if !upgraded[n.Node.IP] {
+			workers = append(workers, n)
+			upgraded[n.Node.IP] = true
+		}
+	}
+	if maxParallelWorkers < 1 {
+		maxParallelWorkers = 1
+	}
+	for len(workers) > 0 {
+		size := maxParallelWorkers
+		if canary {
+			size = 1
+			canary = false
+		}
+		if len(workers) < size {
+			size = len(workers)
+		}
+		batches = append(batches, upgradeBatch{phase: UpgradePhaseWorker, nodes: workers[:size]})
+		workers = workers[size:]
+	}
+	return batches
+}
+
+func hasRole(n ListableNode, role string) bool {
+	for _, r := range n.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ComputeUpgradePlan computes the plan of the upgrade of the cluster from the versions of its
+// nodes. When the upgrade is online, the safety of the upgrade of each out of date node is checked
+// with the kube client, which only reads the state of the cluster.
+func ComputeUpgradePlan(plan Plan, nodes []ListableNode, opts UpgradePlanOptions, kubeClient upgradeKubeInfoClient) UpgradePlan {
+	up := UpgradePlan{
+		TargetVersion:      KismaticVersion.String(),
+		KubernetesVersion:  plan.Cluster.Version,
+		Online:             opts.Online,
+		PartialAllowed:     opts.PartialAllowed,
+		MaxParallelWorkers: opts.MaxParallelWorkers,
+		Canary:             opts.Canary,
+		Phases:             []UpgradePlanPhase{},
+		ClusterServices: UpgradePlanClusterServices{
+			Playbook:  "upgrade-cluster-services.yaml",
+			Skipped:   opts.PartialAllowed,
+			SmokeTest: !opts.PartialAllowed && plan.NetworkConfigured(),
+		},
+	}
+	toUpgrade, _ := NodesToUpgrade(plan, nodes)
+	outOfDate := map[string]bool{}
+	for _, n := range toUpgrade {
+		outOfDate[n.Node.IP] = true
+	}
+
+	var upgraded []ListableNode
+	for _, n := range nodes {
+		pn := UpgradePlanNode{
+			Host:              n.Node.Host,
+			IP:                n.Node.IP,
+			Roles:             n.Roles,
+			Version:           n.Version.String(),
+			KubernetesVersion: n.ComponentVersions.Kubernetes,
+			OutOfDate:         outOfDate[n.Node.IP],
+		}
+		if !pn.OutOfDate {
+			pn.SkipReason = "the node is at the target version"
+			up.Nodes = append(up.Nodes, pn)
+			continue
+		}
+		pn.Upgrade = true
+		if opts.Online {
+			for _, err := range DetectNodeUpgradeSafety(plan, n.Node, kubeClient) {
+				pn.SafetyErrors = append(pn.SafetyErrors, err.Error())
+			}
+		}
+		if len(pn.SafetyErrors) > 0 && !opts.IgnoreSafetyChecks {
+			// etcd and master nodes are never skipped, and block the upgrade instead
+			if !opts.PartialAllowed || hasRole(n, "etcd") || hasRole(n, "master") {
+				up.Blocked = "Unable to perform an online upgrade due to the unsafe conditions detected."
+			} else {
+				pn.Upgrade = false
+				pn.SkipReason = "unsafe conditions were detected on the node"
+			}
+		}
+		if pn.Upgrade {
+			upgraded = append(upgraded, n)
+		}
+		up.Nodes = append(up.Nodes, pn)
+	}
+
+	for _, b := range upgradeBatches(upgraded, opts.MaxParallelWorkers, opts.Canary) {
+		var hosts []string
+		for _, n := range b.nodes {
+			hosts = append(hosts, n.Node.Host)
+		}
+		if len(up.Phases) == 0 || up.Phases[len(up.Phases)-1].Name != b.phase {
+			up.Phases = append(up.Phases, UpgradePlanPhase{Name: b.phase})
+		}
+		last := &up.Phases[len(up.Phases)-1]
+		last.Batches = append(last.Batches, hosts)
+	}
+	return up
+}
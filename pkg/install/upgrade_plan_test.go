@@ -0,0 +1,119 @@
+package install
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/blang/semver"
+)
+
+func TestUpgradeBatches(t *testing.T) {
+	node := func(host string, roles ...string) ListableNode {
+		return ListableNode{Node: Node{Host: host, IP: "10.0.0." + host[len(host)-1:]}, Roles: roles}
+	}
+	tests := []struct {
+		nodes              []ListableNode
+		maxParallelWorkers int
+		canary             bool
+		expected           [][]string
+	}{
+		{
+			nodes:              []ListableNode{node("worker1", "worker"), node("master2", "master"), node("etcd3", "etcd")},
+			maxParallelWorkers: 2,
+			// the last batch of workers is upgraded, even if it is not full
+			expected: [][]string{{"etcd3"}, {"master2"}, {"worker1"}},
+		},
+		{
+			// nodes with multiple roles are upgraded once, in the first phase of their roles
+			nodes:              []ListableNode{node("master1", "master", "etcd"), node("worker2", "worker", "ingress"), node("worker3", "worker"), node("worker4", "worker")},
+			maxParallelWorkers: 2,
+			expected:           [][]string{{"master1"}, {"worker2", "worker3"}, {"worker4"}},
+		},
+		{
+			// with canary, a single worker is upgraded first
+			nodes:              []ListableNode{node("etcd1", "etcd"), node("worker2", "worker"), node("worker3", "worker"), node("worker4", "worker"), node("worker5", "worker")},
+			maxParallelWorkers: 2,
+			canary:             true,
+			expected:           [][]string{{"etcd1"}, {"worker2"}, {"worker3", "worker4"}, {"worker5"}},
+		},
+	}
+	for i, test := range tests {
+		var got [][]string
+		for _, b := range upgradeBatches(test.nodes, test.maxParallelWorkers, test.canary) {
+			var hosts []string
+			for _, n := range b.nodes {
+				hosts = append(hosts, n.Node.Host)
+			}
+			got = append(got, hosts)
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("test %d: expected batches %v, got %v", i, test.expected, got)
+		}
+	}
+}
+
+func TestComputeUpgradePlan(t *testing.T) {
+	defer func(v semver.Version) { KismaticVersion = v }(KismaticVersion)
+	SetVersion("1.2.0")
+	old := semver.MustParse("1.0.0")
+	plan := Plan{
+		Cluster: Cluster{Version: "v1.10.11"},
+		Etcd:    NodeGroup{ExpectedCount: 3, Nodes: []Node{{Host: "etcd1", IP: "10.0.0.1"}}},
+		Worker:  NodeGroup{ExpectedCount: 1, Nodes: []Node{{Host: "worker2", IP: "10.0.0.2"}, {Host: "worker3", IP: "10.0.0.3"}}},
+	}
+	nodes := []ListableNode{
+		{Node: plan.Etcd.Nodes[0], Roles: []string{"etcd"}, Version: old},
+		{Node: plan.Worker.Nodes[0], Roles: []string{"worker"}, Version: old, ComponentVersions: ComponentVersions{Kubernetes: "v1.9.5"}},
+		{Node: plan.Worker.Nodes[1], Roles: []string{"worker"}, Version: KismaticVersion, ComponentVersions: ComponentVersions{Kubernetes: "v1.10.11"}},
+	}
+
+	// the upgrade of a worker node is unsafe, as there is a single worker node in the plan
+	up := ComputeUpgradePlan(plan, nodes, UpgradePlanOptions{Online: true, MaxParallelWorkers: 2}, fakeUpgradeKubeClient{})
+	if up.Blocked == "" {
+		t.Errorf("expected the upgrade to be blocked by the unsafe worker node")
+	}
+	if len(up.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(up.Nodes))
+	}
+	if !up.Nodes[0].OutOfDate || !up.Nodes[0].Upgrade || len(up.Nodes[0].SafetyErrors) != 0 {
+		t.Errorf("expected the etcd node to be upgraded safely, got %+v", up.Nodes[0])
+	}
+	if !up.Nodes[1].OutOfDate || len(up.Nodes[1].SafetyErrors) != 1 || up.Nodes[1].SafetyErrors[0] != (workerNodeCountErr{}).Error() {
+		t.Errorf("expected the safety error of the worker node, got %+v", up.Nodes[1])
+	}
+	if up.Nodes[2].OutOfDate || up.Nodes[2].Upgrade || up.Nodes[2].SkipReason == "" {
+		t.Errorf("expected the up to date worker node to be skipped, got %+v", up.Nodes[2])
+	}
+	expectedPhases := []UpgradePlanPhase{
+		{Name: UpgradePhaseEtcd, Batches: [][]string{{"etcd1"}}},
+		{Name: UpgradePhaseWorker, Batches: [][]string{{"worker2"}}},
+	}
+	if !reflect.DeepEqual(up.Phases, expectedPhases) {
+		t.Errorf("expected phases %v, got %v", expectedPhases, up.Phases)
+	}
+	if up.ClusterServices.Skipped {
+		t.Errorf("expected the cluster services to be upgraded")
+	}
+
+	// with a partial upgrade, the unsafe worker node is skipped
+	up = ComputeUpgradePlan(plan, nodes, UpgradePlanOptions{Online: true, PartialAllowed: true, MaxParallelWorkers: 2}, fakeUpgradeKubeClient{})
+	if up.Blocked != "" {
+		t.Errorf("expected the partial upgrade not to be blocked, got %q", up.Blocked)
+	}
+	if up.Nodes[1].Upgrade || up.Nodes[1].SkipReason == "" {
+		t.Errorf("expected the unsafe worker node to be skipped, got %+v", up.Nodes[1])
+	}
+	expectedPhases = []UpgradePlanPhase{{Name: UpgradePhaseEtcd, Batches: [][]string{{"etcd1"}}}}
+	if !reflect.DeepEqual(up.Phases, expectedPhases) {
+		t.Errorf("expected phases %v, got %v", expectedPhases, up.Phases)
+	}
+	if !up.ClusterServices.Skipped {
+		t.Errorf("expected the cluster services not to be upgraded in a partial upgrade")
+	}
+
+	// the safety checks are not run in an offline upgrade
+	up = ComputeUpgradePlan(plan, nodes, UpgradePlanOptions{MaxParallelWorkers: 2}, nil)
+	if up.Blocked != "" || len(up.Nodes[1].SafetyErrors) != 0 || !up.Nodes[1].Upgrade {
+		t.Errorf("expected the offline upgrade to upgrade the worker node, got %+v", up)
+	}
+}
@@ -17,6 +17,7 @@ type fakeUpgradeKubeClient struct {
 	getPersistentVolume      func(name string) (*data.PersistentVolume, error)
 	getPersistentVolumeClaim func(name string) (*data.PersistentVolumeClaim, error)
 	getStatefulSet           func() (*data.StatefulSet, error)
+	listPDBs                 func() (*data.PodDisruptionBudgetList, error)
 }
 
 func (f fakeUpgradeKubeClient) ListPods() (*data.PodList, error) {
@@ -68,6 +69,13 @@ func (f fakeUpgradeKubeClient) GetStatefulSet(namespace, name string) (*data.Sta
 	return nil, errors.New("StatefulSet not found")
 }
 
+func (f fakeUpgradeKubeClient) ListPodDisruptionBudgets() (*data.PodDisruptionBudgetList, error) {
+	if f.listPDBs != nil {
+		return f.listPDBs()
+	}
+	return &data.PodDisruptionBudgetList{}, nil
+}
+
 func getSafePodWithCreatedByRef(t *testing.T, nodeName string, createdByKind string) data.Pod {
 	pod := data.Pod{
 		ObjectMeta: data.ObjectMeta{
@@ -816,3 +824,166 @@ func TestDetectNodeUpgradeSafetyAllReplicaControllerPodsSameNode(t *testing.T) {
 		t.Errorf("expected replicasOnSingleNodeErr, but got %T", errs[0])
 	}
 }
+
+func TestDetectNodeUpgradeSafetyPodDisruptionBudgetViolated(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 2,
+			Nodes: []Node{
+				{
+					Host: "foo",
+					IP:   "10.0.0.1",
+				},
+				{
+					Host: "bar",
+					IP:   "10.0.0.2",
+				},
+			},
+		},
+	}
+	node := plan.Worker.Nodes[0]
+
+	// Two pods of a replica set with 4 replicas are running on the node,
+	// and the budget of the replica set allows a single disruption
+	pod := func(name, nodeName string, phase data.PodPhase) data.Pod {
+		p := getSafePodWithCreatedByRef(t, nodeName, "ReplicaSet")
+		p.Name = name
+		p.Labels = map[string]string{"app": "web"}
+		p.Status.Phase = phase
+		return p
+	}
+	pods := []data.Pod{pod("web-1", "foo", data.PodRunning), pod("web-2", "foo", data.PodRunning), pod("web-3", "bar", data.PodRunning), pod("web-4", "foo", data.PodFailed)}
+	// A daemon set pod is not evicted by the drain
+	ds := getSafePodWithCreatedByRef(t, node.Host, "DaemonSet")
+	ds.Labels = map[string]string{"app": "web"}
+	ds.Status.Phase = data.PodRunning
+	pods = append(pods, ds)
+	pdb := func(name string, selector *data.LabelSelector, allowed int32) data.PodDisruptionBudget {
+		return data.PodDisruptionBudget{
+			ObjectMeta: data.ObjectMeta{Namespace: "foo", Name: name},
+			Spec:       data.PodDisruptionBudgetSpec{Selector: selector},
+			Status:     data.PodDisruptionBudgetStatus{PodDisruptionsAllowed: allowed, CurrentHealthy: 3, DesiredHealthy: 2},
+		}
+	}
+	k8sClient := fakeUpgradeKubeClient{
+		listPods: func() (*data.PodList, error) {
+			return &data.PodList{Items: pods}, nil
+		},
+		getReplicaSet: func() (*data.ReplicaSet, error) {
+			return &data.ReplicaSet{Status: data.ReplicaSetStatus{Replicas: 4}}, nil
+		},
+		getDaemonSet: func() (*data.DaemonSet, error) {
+			return &data.DaemonSet{Status: data.DaemonSetStatus{DesiredNumberScheduled: 2}}, nil
+		},
+		listPDBs: func() (*data.PodDisruptionBudgetList, error) {
+			return &data.PodDisruptionBudgetList{
+				Items: []data.PodDisruptionBudget{
+					pdb("web", &data.LabelSelector{MatchLabels: map[string]string{"app": "web"}}, 1),
+					pdb("web-expr", &data.LabelSelector{MatchExpressions: []data.LabelSelectorRequirement{{Key: "app", Operator: "In", Values: []string{"web", "api"}}}}, 2),
+					pdb("db", &data.LabelSelector{MatchLabels: map[string]string{"app": "db"}}, 0),
+					pdb("empty", &data.LabelSelector{}, 0),
+				},
+			}, nil
+		},
+	}
+	errs := DetectNodeUpgradeSafety(plan, node, k8sClient)
+	if len(errs) != 1 {
+		t.Fatalf("Expected %d errors, but got %v", 1, errs)
+	}
+	pdbErr, ok := errs[0].(podDisruptionBudgetErr)
+	if !ok {
+		t.Fatalf("expected podDisruptionBudgetErr, but got %T", errs[0])
+	}
+	if pdbErr.name != "web" || pdbErr.evicted != 2 || pdbErr.allowed != 1 {
+		t.Errorf("expected 2 evicted pods of budget web, with 1 allowed disruption, but got %+v", pdbErr)
+	}
+	if !strings.Contains(pdbErr.Error(), `ReplicaSet "foo/foo"`) {
+		t.Errorf("expected the error to name the affected workload, but got %q", pdbErr.Error())
+	}
+}
+
+func TestDetectNodeUpgradeSafetyPodDisruptionBudgetBelowReplicas(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 2,
+			Nodes: []Node{
+				{
+					Host: "Foo",
+					IP:   "10.0.0.1",
+				},
+				{
+					Host: "bar",
+					IP:   "10.0.0.2",
+				},
+			},
+		},
+	}
+	node := plan.Worker.Nodes[0]
+
+	// A replica set with 3 replicas, one of which is running on the node, has a budget
+	// with minAvailable 2, which allows a single disruption
+	pods := []data.Pod{}
+	for i, nodeName := range []string{"foo", "bar", "bar"} {
+		p := getSafePodWithCreatedByRef(t, nodeName, "ReplicaSet")
+		p.Name = fmt.Sprintf("web-%d", i)
+		p.Labels = map[string]string{"app": "web"}
+		p.Status.Phase = data.PodRunning
+		pods = append(pods, p)
+	}
+	budget := data.PodDisruptionBudget{
+		ObjectMeta: data.ObjectMeta{Namespace: "foo", Name: "web"},
+		Spec:       data.PodDisruptionBudgetSpec{Selector: &data.LabelSelector{MatchLabels: map[string]string{"app": "web"}}},
+		Status:     data.PodDisruptionBudgetStatus{PodDisruptionsAllowed: 1, CurrentHealthy: 3, DesiredHealthy: 2},
+	}
+	k8sClient := fakeUpgradeKubeClient{
+		listPods: func() (*data.PodList, error) {
+			return &data.PodList{Items: pods}, nil
+		},
+		getReplicaSet: func() (*data.ReplicaSet, error) {
+			return &data.ReplicaSet{Status: data.ReplicaSetStatus{Replicas: 3}}, nil
+		},
+		listPDBs: func() (*data.PodDisruptionBudgetList, error) {
+			return &data.PodDisruptionBudgetList{Items: []data.PodDisruptionBudget{budget}}, nil
+		},
+	}
+	if errs := DetectNodeUpgradeSafety(plan, node, k8sClient); len(errs) != 0 {
+		t.Errorf("expected no errors when the budget allows the eviction, but got %v", errs)
+	}
+
+	// the budget is violated once a second replica runs on the node, which is matched
+	// by its lower case name
+	pods[1].Spec.NodeName = "foo"
+	errs := DetectNodeUpgradeSafety(plan, node, k8sClient)
+	if len(errs) != 1 {
+		t.Fatalf("Expected %d errors, but got %v", 1, errs)
+	}
+	if pdbErr, ok := errs[0].(podDisruptionBudgetErr); !ok || pdbErr.evicted != 2 {
+		t.Errorf("expected 2 evicted pods of budget web, but got %v", errs[0])
+	}
+}
+
+func TestDetectNodeUpgradeSafetyPodDisruptionBudgetListFailed(t *testing.T) {
+	plan := Plan{
+		Worker: NodeGroup{
+			ExpectedCount: 2,
+			Nodes: []Node{
+				{
+					Host: "foo",
+					IP:   "10.0.0.1",
+				},
+			},
+		},
+	}
+	node := plan.Worker.Nodes[0]
+	k8sClient := fakeUpgradeKubeClient{
+		listPDBs: func() (*data.PodDisruptionBudgetList, error) {
+			return nil, errors.New("forbidden")
+		},
+	}
+	errs := DetectNodeUpgradeSafety(plan, node, k8sClient)
+	if len(errs) != 1 {
+		t.Errorf("Expected %d errors, but got %v", 1, errs)
+	} else if !strings.Contains(errs[0].Error(), "PodDisruptionBudgets") {
+		t.Errorf("expected an error listing the budgets, but got %v", errs[0])
+	}
+}
@@ -51,9 +51,27 @@ func ValidateNodes(nodes []Node) (bool, []error) {
 func ValidatePlanSSHConnections(p *Plan) (bool, []error) {
 	v := newValidator()
 
-	s := sshConnectionSet{p.Cluster.SSH, p.GetUniqueNodes()}
+	// group the nodes by their SSH configuration
+	sets := []*sshConnectionSet{}
+	for _, n := range p.GetUniqueNodes() {
+		sshConfig := p.SSHConfigForNode(n)
+		var set *sshConnectionSet
+		for _, s := range sets {
+			if s.SSHConfig == sshConfig {
+				set = s
+				break
+			}
+		}
+		if set == nil {
+			set = &sshConnectionSet{SSHConfig: sshConfig}
+			sets = append(sets, set)
+		}
+		set.Nodes = append(set.Nodes, n)
+	}
 
-	v.validateWithErrPrefix("Node Connnection", s)
+	for _, s := range sets {
+		v.validateWithErrPrefix("Node Connnection", *s)
+	}
 
 	return v.valid()
 }
@@ -153,6 +171,9 @@ func (p *Plan) validate() (bool, []error) {
 	v.validateWithErrPrefix("Ingress nodes", &p.Ingress)
 	v.validate(p.NFS)
 	v.validateWithErrPrefix("Storage nodes", &p.Storage)
+	if ok, errs := p.validateNodeSSHConfigs(); !ok {
+		v.addError(errs...)
+	}
 
 	return v.valid()
 }
@@ -230,18 +251,49 @@ func (s *SSHConfig) validate() (bool, []error) {
 	if s.User == "" {
 		v.addError(errors.New("SSH user field is required"))
 	}
+	// the keys of the ssh-agent are used when no key is set
 	if s.Key == "" {
-		v.addError(errors.New("SSH key field is required"))
-	}
-	if _, err := os.Stat(s.Key); os.IsNotExist(err) {
-		v.addError(fmt.Errorf("SSH Key file was not found at %q", s.Key))
-	}
-	if !filepath.IsAbs(s.Key) {
-		v.addError(errors.New("SSH Key field must be an absolute path"))
+		if !sshAgentAvailable() {
+			v.addError(fmt.Errorf("SSH key field is required when no ssh-agent is available at %s", ssh.AgentEnvVar))
+		}
+	} else {
+		if _, err := os.Stat(s.Key); os.IsNotExist(err) {
+			v.addError(fmt.Errorf("SSH Key file was not found at %q", s.Key))
+		}
+		if !filepath.IsAbs(s.Key) {
+			v.addError(errors.New("SSH Key field must be an absolute path"))
+		}
 	}
 	if s.Port < 1 || s.Port > 65535 {
 		v.addError(fmt.Errorf("SSH port %d is invalid. Port must be in the range 1-65535", s.Port))
 	}
+	if s.KnownHosts != "" {
+		if _, err := os.Stat(s.KnownHosts); os.IsNotExist(err) {
+			v.addError(fmt.Errorf("SSH known_hosts file was not found at %q", s.KnownHosts))
+		}
+	}
+	if s.Bastion != nil {
+		v.validate(s.Bastion)
+	}
+	return v.valid()
+}
+
+func (b *SSHBastion) validate() (bool, []error) {
+	v := newValidator()
+	if b.Host == "" {
+		v.addError(errors.New("SSH bastion host field is required"))
+	}
+	if b.Key != "" {
+		if _, err := os.Stat(b.Key); os.IsNotExist(err) {
+			v.addError(fmt.Errorf("SSH bastion key file was not found at %q", b.Key))
+		}
+		if !filepath.IsAbs(b.Key) {
+			v.addError(errors.New("SSH bastion key field must be an absolute path"))
+		}
+	}
+	if b.Port < 1 || b.Port > 65535 {
+		v.addError(fmt.Errorf("SSH bastion port %d is invalid. Port must be in the range 1-65535", b.Port))
+	}
 	return v.valid()
 }
 
@@ -365,11 +417,31 @@ func (p *PackageManager) validate() (bool, []error) {
 	return v.valid()
 }
 
+// sshAgentAvailable returns true if the keys of an ssh-agent can be used for SSH access
+var sshAgentAvailable = ssh.AgentAvailable
+
+// validPrivateKey verifies the SSH key can be used. Keys may be encrypted, and the key
+// may be omitted when an ssh-agent is available.
+func validPrivateKey(key string) error {
+	if key == "" {
+		if !sshAgentAvailable() {
+			return fmt.Errorf("no SSH key was given, and no ssh-agent is available at %s", ssh.AgentEnvVar)
+		}
+		return nil
+	}
+	return ssh.ValidPrivateKey(key)
+}
+
 // validate SSH access to the nodes
 func (s sshConnectionSet) validate() (bool, []error) {
 	v := newValidator()
 
-	err := ssh.ValidUnencryptedPrivateKey(s.SSHConfig.Key)
+	err := validPrivateKey(s.SSHConfig.Key)
+	if err == nil && s.SSHConfig.Bastion != nil {
+		if err = validPrivateKey(s.SSHConfig.Bastion.Key); err != nil {
+			err = fmt.Errorf("bastion key: %v", err)
+		}
+	}
 	if err != nil {
 		v.addError(fmt.Errorf("SSH key validation error: %v", err))
 	} else {
@@ -380,7 +452,7 @@ func (s sshConnectionSet) validate() (bool, []error) {
 		for _, node := range s.Nodes {
 			go func(ip string) {
 				defer wg.Done()
-				sshErr := ssh.TestConnection(ip, s.SSHConfig.Port, s.SSHConfig.User, s.SSHConfig.Key)
+				sshErr := ssh.TestConnection(ip, s.SSHConfig.Port, s.SSHConfig.User, s.SSHConfig.Key, s.SSHConfig.SSHBastion())
 				// Need to send something the buffered channel
 				if sshErr != nil {
 					errQueue <- fmt.Errorf("SSH connectivity validation failed for %q: %v", ip, sshErr)
@@ -352,11 +352,22 @@ func TestValidatePlanEmptySSHUser(t *testing.T) {
 }
 
 func TestValidatePlanEmptySSHKey(t *testing.T) {
+	defer func(f func() bool) { sshAgentAvailable = f }(sshAgentAvailable)
+	sshAgentAvailable = func() bool { return false }
 	p := validPlan()
 	p.Cluster.SSH.Key = ""
 	assertInvalidPlan(t, p)
 }
 
+func TestValidateSSHConfigEmptySSHKeyWithAgent(t *testing.T) {
+	defer func(f func() bool) { sshAgentAvailable = f }(sshAgentAvailable)
+	sshAgentAvailable = func() bool { return true }
+	s := SSHConfig{User: "alice", Port: 22}
+	if ok, errs := s.validate(); !ok {
+		t.Errorf("expected an SSH configuration without a key to be valid when an ssh-agent is available, but got %v", errs)
+	}
+}
+
 func TestValidatePlanNonExistentSSHKey(t *testing.T) {
 	p := validPlan()
 	p.Cluster.SSH.Key = "/foo"
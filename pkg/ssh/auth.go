@@ -0,0 +1,264 @@
+package ssh
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	// AgentEnvVar is the environment variable that holds the socket of the ssh-agent
+	AgentEnvVar = "SSH_AUTH_SOCK"
+	// PassphraseEnvVar is the environment variable that holds the passphrase of
+	// encrypted SSH keys. The passphrase is prompted for when the variable is not set.
+	PassphraseEnvVar = "KISMATIC_SSH_KEY_PASSPHRASE"
+)
+
+// PassphrasePrompt reads the passphrase of an encrypted SSH key.
+// It is called at most once per key, unless the passphrase is wrong.
+var PassphrasePrompt = func(key string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		return nil, fmt.Errorf("SSH key %q is encrypted: set %s or run kismatic in a terminal to enter the passphrase", key, PassphraseEnvVar)
+	}
+	fmt.Fprintf(os.Stderr, "Enter passphrase for SSH key %q: ", key)
+	passphrase, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("error reading passphrase: %v", err)
+	}
+	return passphrase, nil
+}
+
+// the decrypted private keys, by file. The lock is held while prompting for a
+// passphrase, so that concurrent connections prompt only once.
+var (
+	privateKeysMu sync.Mutex
+	privateKeys   = map[string]interface{}{}
+)
+
+// privateKey returns the private key in the file, decrypting it if it is encrypted
+func privateKey(file string) (interface{}, error) {
+	privateKeysMu.Lock()
+	defer privateKeysMu.Unlock()
+	if k, ok := privateKeys[file]; ok {
+		return k, nil
+	}
+	buffer, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading SSH key: %v", err)
+	}
+	var k interface{}
+	if encrypted, _ := isEncrypted(buffer); encrypted {
+		passphrase := []byte(os.Getenv(PassphraseEnvVar))
+		if len(passphrase) == 0 {
+			if passphrase, err = PassphrasePrompt(file); err != nil {
+				return nil, err
+			}
+		}
+		if k, err = ssh.ParseRawPrivateKeyWithPassphrase(buffer, passphrase); err != nil {
+			return nil, fmt.Errorf("error decrypting SSH key %q: %v", file, err)
+		}
+	} else if k, err = ssh.ParseRawPrivateKey(buffer); err != nil {
+		return nil, fmt.Errorf("Parse SSH key error: %v", err)
+	}
+	privateKeys[file] = k
+	return k, nil
+}
+
+// EncryptedKey returns true if the SSH key file is protected by a passphrase
+func EncryptedKey(file string) bool {
+	buffer, err := ioutil.ReadFile(file)
+	if err != nil {
+		return false
+	}
+	encrypted, _ := isEncrypted(buffer)
+	return encrypted
+}
+
+// AgentAvailable returns true if an ssh-agent is reachable at SSH_AUTH_SOCK
+func AgentAvailable() bool {
+	conn, err := dialAgent()
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+func dialAgent() (net.Conn, error) {
+	return dialAgentSocket(os.Getenv(AgentEnvVar))
+}
+
+func dialAgentSocket(sock string) (net.Conn, error) {
+	if sock == "" {
+		return nil, fmt.Errorf("%s is not set", AgentEnvVar)
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to ssh-agent: %v", err)
+	}
+	return conn, nil
+}
+
+// authMethods returns the methods for authenticating with the key file, if one is given,
+// and with the keys of the ssh-agent, if one is running. The returned function releases
+// the connection to the agent, and must be called once the handshake is complete.
+func authMethods(key string) ([]ssh.AuthMethod, func(), error) {
+	var signers []ssh.Signer
+	if key != "" {
+		k, err := privateKey(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer, err := ssh.NewSignerFromKey(k)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Parse SSH key error: %v", err)
+		}
+		signers = append(signers, signer)
+	}
+	release := func() {}
+	conn, err := dialAgent()
+	if err != nil {
+		if key == "" {
+			return nil, nil, fmt.Errorf("no SSH key was given, and the ssh-agent is not available: %v", err)
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signers...)}, release, nil
+	}
+	release = func() { conn.Close() }
+	agentSigners := agent.NewClient(conn).Signers
+	return []ssh.AuthMethod{ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		s, err := agentSigners()
+		if err != nil {
+			// the key file can still be used
+			return signers, nil
+		}
+		return append(signers, s...), nil
+	})}, release, nil
+}
+
+// LocalAgent is an ssh-agent served by kismatic, which holds decrypted SSH keys so
+// that the ssh binary can use them without asking for their passphrase. Requests for
+// other keys are forwarded to the ssh-agent at SSH_AUTH_SOCK, if one is running.
+type LocalAgent struct {
+	// Socket is the path of the agent's socket, to be set in SSH_AUTH_SOCK
+	Socket   string
+	dir      string
+	listener net.Listener
+	// upstream is the socket of the ssh-agent that was running when the agent was started
+	upstream string
+}
+
+// StartAgent starts an ssh-agent that holds the keys in the given files
+func StartAgent(keys ...string) (*LocalAgent, error) {
+	keyring := agent.NewKeyring()
+	for _, file := range keys {
+		k, err := privateKey(file)
+		if err != nil {
+			return nil, err
+		}
+		if err = keyring.Add(agent.AddedKey{PrivateKey: k, Comment: file}); err != nil {
+			return nil, fmt.Errorf("error adding SSH key %q to the agent: %v", file, err)
+		}
+	}
+	// the socket is only accessible by the current user
+	dir, err := ioutil.TempDir("", "kismatic-agent")
+	if err != nil {
+		return nil, fmt.Errorf("error creating ssh-agent directory: %v", err)
+	}
+	sock := filepath.Join(dir, "agent.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("error starting ssh-agent: %v", err)
+	}
+	a := &LocalAgent{Socket: sock, dir: dir, listener: l, upstream: os.Getenv(AgentEnvVar)}
+	go a.serve(keyring)
+	return a, nil
+}
+
+func (a *LocalAgent) serve(keyring agent.Agent) {
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			served := keyring
+			if upstreamConn, err := dialAgentSocket(a.upstream); err == nil {
+				defer upstreamConn.Close()
+				served = &forwardingAgent{local: keyring, upstream: agent.NewClient(upstreamConn)}
+			}
+			agent.ServeAgent(served, conn)
+		}()
+	}
+}
+
+// Close stops the agent and removes its socket
+func (a *LocalAgent) Close() error {
+	a.listener.Close()
+	return os.RemoveAll(a.dir)
+}
+
+// forwardingAgent signs with the local keys, and forwards the requests for other
+// keys to the upstream agent. Keys are only added to the local agent.
+type forwardingAgent struct {
+	local    agent.Agent
+	upstream agent.Agent
+}
+
+func (f *forwardingAgent) List() ([]*agent.Key, error) {
+	keys, err := f.local.List()
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := f.upstream.List()
+	if err != nil {
+		return keys, nil
+	}
+	return append(keys, upstream...), nil
+}
+
+func (f *forwardingAgent) Sign(key ssh.PublicKey, data []byte) (*ssh.Signature, error) {
+	keys, err := f.local.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return f.local.Sign(key, data)
+		}
+	}
+	return f.upstream.Sign(key, data)
+}
+
+func (f *forwardingAgent) Add(key agent.AddedKey) error { return f.local.Add(key) }
+
+func (f *forwardingAgent) Remove(key ssh.PublicKey) error { return f.local.Remove(key) }
+
+func (f *forwardingAgent) RemoveAll() error { return f.local.RemoveAll() }
+
+func (f *forwardingAgent) Lock(passphrase []byte) error { return f.local.Lock(passphrase) }
+
+func (f *forwardingAgent) Unlock(passphrase []byte) error { return f.local.Unlock(passphrase) }
+
+func (f *forwardingAgent) Signers() ([]ssh.Signer, error) {
+	signers, err := f.local.Signers()
+	if err != nil {
+		return nil, err
+	}
+	upstream, err := f.upstream.Signers()
+	if err != nil {
+		return signers, nil
+	}
+	return append(signers, upstream...), nil
+}
@@ -0,0 +1,322 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// KnownHostsFilename is the name of the known_hosts file in the generated assets directory
+const KnownHostsFilename = "known_hosts"
+
+// the known_hosts file is shared by all the connections, and is locked while it
+// is read or updated
+var (
+	knownHostsMu   sync.Mutex
+	knownHostsFile = filepath.Join("generated", KnownHostsFilename)
+)
+
+// SetKnownHostsFile sets the known_hosts file that is used for verifying the host keys
+// of the SSH servers. Host keys are trusted on first use and recorded in the file.
+func SetKnownHostsFile(file string) {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	knownHostsFile = file
+}
+
+// KnownHostsFile returns the absolute path of the known_hosts file that is used for verifying
+// the host keys of the SSH servers
+func KnownHostsFile() (string, error) {
+	knownHostsMu.Lock()
+	file := knownHostsFile
+	knownHostsMu.Unlock()
+	return filepath.Abs(file)
+}
+
+// KnownHost is an entry of the known_hosts file
+type KnownHost struct {
+	Hosts       []string
+	KeyType     string
+	Fingerprint string
+}
+
+// HostKeyChangedError is returned when the host key of an SSH server does not match
+// the key recorded in the known_hosts file
+type HostKeyChangedError struct {
+	Host           string
+	KnownHostsFile string
+	Line           int
+}
+
+func (e *HostKeyChangedError) Error() string {
+	return fmt.Sprintf("the host key of %s has changed since it was recorded in %s:%d. This could mean that the node was reinstalled, "+
+		"or that someone is intercepting the connection. If the change is expected, remove the recorded key with \"kismatic ssh known-hosts reset %s\"",
+		e.Host, e.KnownHostsFile, e.Line, e.Host)
+}
+
+// hostKeyCallback verifies host keys against the known_hosts file. The keys of unknown hosts
+// are trusted and added to the file.
+func hostKeyCallback(hostname string, remote net.Addr, key ssh.PublicKey) error {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	file, err := filepath.Abs(knownHostsFile)
+	if err != nil {
+		return err
+	}
+	if err = ensureFile(file); err != nil {
+		return fmt.Errorf("error creating known_hosts file: %v", err)
+	}
+	cb, err := knownhosts.New(file)
+	if err != nil {
+		return fmt.Errorf("error reading known_hosts file %s: %v", file, err)
+	}
+	err = cb(hostname, remote, key)
+	keyErr, ok := err.(*knownhosts.KeyError)
+	if !ok {
+		return err
+	}
+	if len(keyErr.Want) > 0 {
+		return &HostKeyChangedError{Host: knownhosts.Normalize(hostname), KnownHostsFile: file, Line: keyErr.Want[0].Line}
+	}
+	// trust on first use
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening known_hosts file: %v", err)
+	}
+	defer f.Close()
+	if _, err = fmt.Fprintln(f, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key)); err != nil {
+		return fmt.Errorf("error adding host key to known_hosts file: %v", err)
+	}
+	return nil
+}
+
+func isKnownHostsComment(line string) bool {
+	l := strings.TrimSpace(line)
+	return l == "" || strings.HasPrefix(l, "#")
+}
+
+func ensureFile(file string) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// ListKnownHosts returns the entries of the known_hosts file
+func ListKnownHosts() ([]KnownHost, error) {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	b, err := ioutil.ReadFile(knownHostsFile)
+	if os.IsNotExist(err) {
+		return []KnownHost{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading known_hosts file: %v", err)
+	}
+	hosts := []KnownHost{}
+	for _, line := range strings.Split(string(b), "\n") {
+		if isKnownHostsComment(line) {
+			continue
+		}
+		_, h, key, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing known_hosts file: %v", err)
+		}
+		hosts = append(hosts, KnownHost{Hosts: h, KeyType: key.Type(), Fingerprint: ssh.FingerprintSHA256(key)})
+	}
+	return hosts, nil
+}
+
+// RemoveKnownHosts removes the entries of the given hosts from the known_hosts file,
+// and returns the number of removed entries. All entries are removed when no host is given.
+// Hosts can be given as "host", "host:port" or "[host]:port".
+func RemoveKnownHosts(hosts ...string) (int, error) {
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	b, err := ioutil.ReadFile(knownHostsFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error reading known_hosts file: %v", err)
+	}
+	remove := map[string]bool{}
+	for _, h := range hosts {
+		remove[h] = true
+		remove[knownhosts.Normalize(h)] = true
+	}
+	var kept bytes.Buffer
+	removed := 0
+	for _, line := range strings.Split(string(b), "\n") {
+		if isKnownHostsComment(line) {
+			if strings.TrimSpace(line) != "" {
+				fmt.Fprintln(&kept, line)
+			}
+			continue
+		}
+		_, lineHosts, _, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			return 0, fmt.Errorf("error parsing known_hosts file: %v", err)
+		}
+		match := len(hosts) == 0
+		for _, h := range lineHosts {
+			if remove[h] {
+				match = true
+			}
+		}
+		if match {
+			removed++
+			continue
+		}
+		fmt.Fprintln(&kept, line)
+	}
+	if err = ioutil.WriteFile(knownHostsFile, kept.Bytes(), 0600); err != nil {
+		return 0, fmt.Errorf("error writing known_hosts file: %v", err)
+	}
+	return removed, nil
+}
+
+// ImportKnownHosts adds the entries of the given known_hosts file to the known_hosts file.
+// The imported keys take precedence: the keys that are recorded for the same hosts, such as
+// keys that were trusted on first use, are removed. Returns the number of added entries.
+func ImportKnownHosts(file string) (int, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return 0, fmt.Errorf("error reading known_hosts file: %v", err)
+	}
+	imported := map[string]bool{}
+	importedHost := map[string]bool{}
+	var importedLines []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if isKnownHostsComment(line) {
+			continue
+		}
+		_, hosts, _, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			return 0, fmt.Errorf("error parsing known_hosts file %s: %v", file, err)
+		}
+		line = strings.TrimSpace(line)
+		for _, h := range hosts {
+			importedHost[h] = true
+		}
+		if !imported[line] {
+			imported[line] = true
+			importedLines = append(importedLines, line)
+		}
+	}
+
+	knownHostsMu.Lock()
+	defer knownHostsMu.Unlock()
+	b, err = ioutil.ReadFile(knownHostsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return 0, fmt.Errorf("error reading known_hosts file: %v", err)
+	}
+	var kept bytes.Buffer
+	changed := false
+	present := map[string]bool{}
+	for _, line := range strings.Split(string(b), "\n") {
+		if isKnownHostsComment(line) {
+			if strings.TrimSpace(line) != "" {
+				fmt.Fprintln(&kept, line)
+			}
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if imported[line] {
+			present[line] = true
+			fmt.Fprintln(&kept, line)
+			continue
+		}
+		_, hosts, _, _, _, err := ssh.ParseKnownHosts([]byte(line))
+		if err != nil {
+			return 0, fmt.Errorf("error parsing known_hosts file: %v", err)
+		}
+		replaced := false
+		for _, h := range hosts {
+			replaced = replaced || importedHost[h]
+		}
+		if replaced {
+			changed = true
+			continue
+		}
+		fmt.Fprintln(&kept, line)
+	}
+	n := 0
+	for _, line := range importedLines {
+		if present[line] {
+			continue
+		}
+		fmt.Fprintln(&kept, line)
+		n++
+	}
+	if n == 0 && !changed {
+		return 0, nil
+	}
+	if err = ensureFile(knownHostsFile); err != nil {
+		return 0, fmt.Errorf("error creating known_hosts file: %v", err)
+	}
+	if err = ioutil.WriteFile(knownHostsFile, kept.Bytes(), 0600); err != nil {
+		return 0, fmt.Errorf("error writing known_hosts file: %v", err)
+	}
+	return n, nil
+}
+
+// ScanHostKey connects to the SSH server and verifies its host key against the known_hosts
+// file, adding the key if the host is unknown. The connection does not authenticate
+// with the host, but it is authenticated with the bastion, if one is given.
+func ScanHostKey(host string, port int, bastion *Bastion) error {
+	addr := net.JoinHostPort(host, fmt.Sprintf("%d", port))
+	var verified bool
+	var verifyErr error
+	config := &ssh.ClientConfig{
+		User: "kismatic",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			verifyErr = hostKeyCallback(hostname, remote, key)
+			verified = verifyErr == nil
+			return verifyErr
+		},
+		Timeout: nativeConnectTimeout,
+	}
+	var conn net.Conn
+	var err error
+	if bastion != nil {
+		b := (&NativeClient{Host: host, Port: port, Bastion: bastion}).target().bastion
+		via, bastionErr := defaultPool.get(context.Background(), *b)
+		if bastionErr != nil {
+			return fmt.Errorf("error connecting to bastion %s: %v", b.addr, bastionErr)
+		}
+		conn, err = via.Dial("tcp", addr)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, nativeConnectTimeout)
+	}
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %v", addr, err)
+	}
+	defer conn.Close()
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err == nil {
+		// the server did not require authentication
+		ssh.NewClient(c, chans, reqs).Close()
+		return nil
+	}
+	if verifyErr != nil {
+		return verifyErr
+	}
+	if !verified {
+		return fmt.Errorf("error verifying the host key of %s: %v", addr, err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package ssh
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestScanHostKeyTrustOnFirstUse(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-known-hosts")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	SetKnownHostsFile(filepath.Join(tmp, "generated", KnownHostsFilename))
+	s := newTestServer(t)
+	defer s.listener.Close()
+	host, port := s.hostPort()
+
+	if err = ScanHostKey(host, port, nil); err != nil {
+		t.Fatalf("unexpected error scanning an unknown host: %v", err)
+	}
+	hosts, err := ListKnownHosts()
+	if err != nil {
+		t.Fatalf("unexpected error listing known hosts: %v", err)
+	}
+	if len(hosts) != 1 || hosts[0].Hosts[0] != knownhosts.Normalize(s.listener.Addr().String()) {
+		t.Fatalf("expected the host key to be recorded, but got %+v", hosts)
+	}
+	if err = ScanHostKey(host, port, nil); err != nil {
+		t.Errorf("unexpected error scanning a known host: %v", err)
+	}
+
+	// the server's host key changes
+	hostKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatalf("error creating host key signer: %v", err)
+	}
+	s.config.AddHostKey(signer)
+	err = ScanHostKey(host, port, nil)
+	if _, ok := err.(*HostKeyChangedError); !ok {
+		t.Fatalf("expected a host key changed error, but got %v", err)
+	}
+
+	n, err := RemoveKnownHosts(s.listener.Addr().String())
+	if err != nil || n != 1 {
+		t.Fatalf("expected the host to be removed, but got %d %v", n, err)
+	}
+	if err = ScanHostKey(host, port, nil); err != nil {
+		t.Errorf("unexpected error scanning a host after removing its key: %v", err)
+	}
+}
+
+func TestImportAndResetKnownHosts(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-known-hosts")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	SetKnownHostsFile(filepath.Join(tmp, KnownHostsFilename))
+
+	var lines string
+	for _, h := range []string{"10.0.0.1", "[10.0.0.2]:2222"} {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		if err != nil {
+			t.Fatalf("error generating key: %v", err)
+		}
+		pub, err := ssh.NewPublicKey(&key.PublicKey)
+		if err != nil {
+			t.Fatalf("error creating public key: %v", err)
+		}
+		lines += knownhosts.Line([]string{h}, pub) + "\n"
+	}
+	seed := filepath.Join(tmp, "seed")
+	if err = ioutil.WriteFile(seed, []byte("# seeded keys\n"+lines), 0600); err != nil {
+		t.Fatalf("error writing known_hosts file: %v", err)
+	}
+
+	if n, err := ImportKnownHosts(seed); err != nil || n != 2 {
+		t.Fatalf("expected 2 imported hosts, but got %d %v", n, err)
+	}
+	if n, err := ImportKnownHosts(seed); err != nil || n != 0 {
+		t.Errorf("expected known hosts not to be imported again, but got %d %v", n, err)
+	}
+	hosts, err := ListKnownHosts()
+	if err != nil || len(hosts) != 2 {
+		t.Fatalf("unexpected known hosts %+v %v", hosts, err)
+	}
+	seededFingerprint := hosts[0].Fingerprint
+
+	// a seeded key replaces the key that was trusted on first use
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	pub, err := ssh.NewPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("error creating public key: %v", err)
+	}
+	if _, err = RemoveKnownHosts("10.0.0.1"); err != nil {
+		t.Fatalf("unexpected error removing host: %v", err)
+	}
+	if err = hostKeyCallback("10.0.0.1:22", &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}, pub); err != nil {
+		t.Fatalf("unexpected error trusting host key on first use: %v", err)
+	}
+	if n, err := ImportKnownHosts(seed); err != nil || n != 1 {
+		t.Errorf("expected the seeded key to be imported again, but got %d %v", n, err)
+	}
+	hosts, err = ListKnownHosts()
+	if err != nil || len(hosts) != 2 {
+		t.Fatalf("unexpected known hosts %+v %v", hosts, err)
+	}
+	for _, h := range hosts {
+		if h.Hosts[0] == "10.0.0.1" && h.Fingerprint != seededFingerprint {
+			t.Errorf("expected the seeded key of 10.0.0.1 to replace the key trusted on first use")
+		}
+	}
+
+	if n, err := RemoveKnownHosts("10.0.0.2:2222"); err != nil || n != 1 {
+		t.Errorf("expected the host to be removed, but got %d %v", n, err)
+	}
+	hosts, err = ListKnownHosts()
+	if err != nil || len(hosts) != 1 || hosts[0].Hosts[0] != "10.0.0.1" {
+		t.Errorf("unexpected known hosts %+v %v", hosts, err)
+	}
+	if n, err := RemoveKnownHosts(); err != nil || n != 1 {
+		t.Errorf("expected all hosts to be removed, but got %d %v", n, err)
+	}
+}
@@ -0,0 +1,369 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+const (
+	// timeout for establishing the SSH connection
+	nativeConnectTimeout = 10 * time.Second
+	// number of attempts to establish the SSH connection
+	nativeConnectionAttempts = 3
+)
+
+// NativeClient is an SSH client built on golang.org/x/crypto/ssh.
+// Connections are pooled per host, so running multiple commands against the
+// same host only pays for a single SSH handshake.
+type NativeClient struct {
+	Host    string
+	Port    int
+	User    string
+	Key     string
+	Bastion *Bastion
+	// ctx bounds the connection and the commands run by the client
+	ctx context.Context
+}
+
+func newNativeClient(ctx context.Context, host string, port int, user string, key string, bastion *Bastion) *NativeClient {
+	return &NativeClient{
+		Host:    host,
+		Port:    port,
+		User:    user,
+		Key:     key,
+		Bastion: bastion,
+		ctx:     ctx,
+	}
+}
+
+// Output runs the command and returns the combined stdout and stderr
+func (client *NativeClient) Output(pty bool, args ...string) (string, error) {
+	session, err := client.newSession()
+	if err != nil {
+		return "", err
+	}
+	defer session.Close()
+	if pty {
+		if err = session.RequestPty("xterm", 40, 80, ssh.TerminalModes{ssh.ECHO: 0}); err != nil {
+			return "", fmt.Errorf("error requesting pseudo-terminal: %v", err)
+		}
+	}
+	var output []byte
+	err = client.run(session, func() error {
+		var runErr error
+		output, runErr = session.CombinedOutput(strings.Join(args, " "))
+		return runErr
+	})
+	return string(output), err
+}
+
+// Shell runs the command, binding Stdin, Stdout and Stderr. An interactive shell
+// is started when no command is given.
+func (client *NativeClient) Shell(pty bool, args ...string) error {
+	session, err := client.newSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+	session.Stdin = os.Stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+
+	fd := int(os.Stdin.Fd())
+	isTerminal := terminal.IsTerminal(fd)
+	// like the ssh binary, allocate a pseudo-terminal for interactive shells
+	if pty || (len(args) == 0 && isTerminal) {
+		width, height := 80, 40
+		if isTerminal {
+			if w, h, err := terminal.GetSize(fd); err == nil {
+				width, height = w, h
+			}
+			state, err := terminal.MakeRaw(fd)
+			if err != nil {
+				return fmt.Errorf("error setting terminal to raw mode: %v", err)
+			}
+			defer terminal.Restore(fd, state)
+		}
+		term := os.Getenv("TERM")
+		if term == "" {
+			term = "xterm"
+		}
+		if err = session.RequestPty(term, height, width, ssh.TerminalModes{}); err != nil {
+			return fmt.Errorf("error requesting pseudo-terminal: %v", err)
+		}
+	}
+
+	return client.run(session, func() error {
+		if len(args) == 0 {
+			if err := session.Shell(); err != nil {
+				return err
+			}
+			return session.Wait()
+		}
+		return session.Run(strings.Join(args, " "))
+	})
+}
+
+// Close releases the client. The pooled connection to the host is kept open for the
+// other clients of the host, until CloseConnections is called.
+func (client *NativeClient) Close() error {
+	return nil
+}
+
+// run calls fn, closing the session if the client's context is done before fn returns
+func (client *NativeClient) run(session *ssh.Session, fn func() error) error {
+	if client.ctx.Done() == nil {
+		return fn()
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- fn() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-client.ctx.Done():
+		session.Close()
+		return client.ctx.Err()
+	}
+}
+
+// newSession opens a session on the pooled connection to the host. The connection is
+// re-established if the pooled one is no longer usable.
+func (client *NativeClient) newSession() (*ssh.Session, error) {
+	conn, err := defaultPool.get(client.ctx, client.target())
+	if err != nil {
+		return nil, err
+	}
+	session, err := conn.NewSession()
+	if err == nil {
+		return session, nil
+	}
+	defaultPool.remove(client.target(), conn)
+	if conn, err = defaultPool.get(client.ctx, client.target()); err != nil {
+		return nil, err
+	}
+	session, err = conn.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("error opening SSH session: %v", err)
+	}
+	return session, nil
+}
+
+func (client *NativeClient) target() connTarget {
+	t := connTarget{
+		addr: net.JoinHostPort(client.Host, fmt.Sprintf("%d", client.Port)),
+		user: client.User,
+		key:  client.Key,
+	}
+	if client.Bastion != nil {
+		t.bastion = &connTarget{
+			addr: net.JoinHostPort(client.Bastion.Host, fmt.Sprintf("%d", client.Bastion.Port)),
+			user: client.Bastion.User,
+			key:  client.Bastion.Key,
+		}
+	}
+	return t
+}
+
+// connTarget identifies a pooled connection
+type connTarget struct {
+	addr    string
+	user    string
+	key     string
+	bastion *connTarget
+}
+
+func (t connTarget) String() string {
+	s := fmt.Sprintf("%s@%s|%s", t.user, t.addr, t.key)
+	if t.bastion != nil {
+		s = s + "|" + t.bastion.String()
+	}
+	return s
+}
+
+// connPool keeps a single SSH connection per target
+type connPool struct {
+	mu    sync.Mutex
+	conns map[string]*pooledConn
+	// dial establishes a new connection to the target, optionally through another connection
+	dial func(ctx context.Context, via *ssh.Client, t connTarget) (*ssh.Client, error)
+}
+
+// pooledConn is locked while the connection is being established, so that
+// connections to different targets can be established concurrently
+type pooledConn struct {
+	mu     sync.Mutex
+	client *ssh.Client
+}
+
+var defaultPool = newConnPool(dialTarget)
+
+func newConnPool(dial func(ctx context.Context, via *ssh.Client, t connTarget) (*ssh.Client, error)) *connPool {
+	return &connPool{conns: map[string]*pooledConn{}, dial: dial}
+}
+
+// get returns the pooled connection to the target, establishing it if needed
+func (p *connPool) get(ctx context.Context, t connTarget) (*ssh.Client, error) {
+	p.mu.Lock()
+	pc, ok := p.conns[t.String()]
+	if !ok {
+		pc = &pooledConn{}
+		p.conns[t.String()] = pc
+	}
+	p.mu.Unlock()
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.client != nil {
+		return pc.client, nil
+	}
+	var via *ssh.Client
+	if t.bastion != nil {
+		b, err := p.get(ctx, *t.bastion)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to bastion %s: %v", t.bastion.addr, err)
+		}
+		via = b
+	}
+	c, err := p.dial(ctx, via, t)
+	if err != nil && via != nil {
+		// the bastion connection might have been dropped
+		p.remove(*t.bastion, via)
+		if via, err = p.get(ctx, *t.bastion); err != nil {
+			return nil, fmt.Errorf("error connecting to bastion %s: %v", t.bastion.addr, err)
+		}
+		c, err = p.dial(ctx, via, t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	pc.client = c
+	return c, nil
+}
+
+// remove closes the connection and removes it from the pool, if it is still pooled
+func (p *connPool) remove(t connTarget, c *ssh.Client) {
+	p.mu.Lock()
+	pc, ok := p.conns[t.String()]
+	p.mu.Unlock()
+	if ok {
+		pc.mu.Lock()
+		if pc.client == c {
+			pc.client = nil
+		}
+		pc.mu.Unlock()
+	}
+	c.Close()
+}
+
+// closeAll closes all the pooled connections
+func (p *connPool) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for k, pc := range p.conns {
+		pc.mu.Lock()
+		if pc.client != nil {
+			pc.client.Close()
+		}
+		pc.mu.Unlock()
+		delete(p.conns, k)
+	}
+}
+
+// CloseConnections closes all the pooled SSH connections
+func CloseConnections() {
+	defaultPool.closeAll()
+}
+
+// dialTarget establishes an SSH connection to the target, retrying a few times
+// if the connection fails
+func dialTarget(ctx context.Context, via *ssh.Client, t connTarget) (*ssh.Client, error) {
+	config, release, err := clientConfig(t.user, t.key)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	for attempt := 1; ; attempt++ {
+		var c *ssh.Client
+		c, err = dialOnce(ctx, via, t.addr, config)
+		if err == nil {
+			return c, nil
+		}
+		if attempt == nativeConnectionAttempts || ctx.Err() != nil {
+			return nil, fmt.Errorf("error connecting to %s: %v", t.addr, err)
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("error connecting to %s: %v", t.addr, ctx.Err())
+		}
+	}
+}
+
+func dialOnce(ctx context.Context, via *ssh.Client, addr string, config *ssh.ClientConfig) (*ssh.Client, error) {
+	ctx, cancel := context.WithTimeout(ctx, nativeConnectTimeout)
+	defer cancel()
+	type result struct {
+		client *ssh.Client
+		err    error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		var conn net.Conn
+		var err error
+		if via != nil {
+			conn, err = via.Dial("tcp", addr)
+		} else {
+			conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		}
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		if deadline, ok := ctx.Deadline(); ok {
+			conn.SetDeadline(deadline)
+		}
+		c, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+		if err != nil {
+			conn.Close()
+			resCh <- result{err: err}
+			return
+		}
+		// the deadline only applies to the handshake
+		conn.SetDeadline(time.Time{})
+		resCh <- result{client: ssh.NewClient(c, chans, reqs)}
+	}()
+	select {
+	case res := <-resCh:
+		return res.client, res.err
+	case <-ctx.Done():
+		go func() {
+			if res := <-resCh; res.client != nil {
+				res.client.Close()
+			}
+		}()
+		return nil, ctx.Err()
+	}
+}
+
+// clientConfig returns the configuration for authenticating as the user with the key and
+// the ssh-agent. The returned function must be called once the handshake is complete.
+func clientConfig(user, key string) (*ssh.ClientConfig, func(), error) {
+	auth, release, err := authMethods(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &ssh.ClientConfig{
+		User:            user,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         nativeConnectTimeout,
+	}, release, nil
+}
@@ -0,0 +1,243 @@
+package ssh
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// testServer is an SSH server that echoes the commands it runs, and
+// forwards TCP connections like a bastion host
+type testServer struct {
+	listener net.Listener
+	config   *ssh.ServerConfig
+	mu       sync.Mutex
+	conns    int
+	// delay before replying to a command
+	delay time.Duration
+}
+
+func newTestServer(t *testing.T) *testServer {
+	hostKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating host key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(hostKey)
+	if err != nil {
+		t.Fatalf("error creating host key signer: %v", err)
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) { return nil, nil },
+	}
+	config.AddHostKey(signer)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("error listening: %v", err)
+	}
+	s := &testServer{listener: l, config: config}
+	go s.serve()
+	return s
+}
+
+func (s *testServer) hostPort() (string, int) {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func (s *testServer) connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+func (s *testServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.mu.Unlock()
+		go s.handle(conn)
+	}
+}
+
+func (s *testServer) handle(conn net.Conn) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, s.config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		switch newCh.ChannelType() {
+		case "session":
+			ch, reqs, err := newCh.Accept()
+			if err != nil {
+				continue
+			}
+			go s.handleSession(ch, reqs)
+		case "direct-tcpip":
+			var target struct {
+				Host     string
+				Port     uint32
+				OrigHost string
+				OrigPort uint32
+			}
+			ssh.Unmarshal(newCh.ExtraData(), &target)
+			tc, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+			if err != nil {
+				newCh.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			ch, reqs, err := newCh.Accept()
+			if err != nil {
+				tc.Close()
+				continue
+			}
+			go ssh.DiscardRequests(reqs)
+			go func() {
+				io.Copy(ch, tc)
+				ch.Close()
+			}()
+			go func() {
+				io.Copy(tc, ch)
+				tc.Close()
+			}()
+		default:
+			newCh.Reject(ssh.UnknownChannelType, "unsupported channel type")
+		}
+	}
+}
+
+func (s *testServer) handleSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for req := range reqs {
+		switch req.Type {
+		case "pty-req":
+			req.Reply(true, nil)
+		case "exec":
+			var cmd struct{ Command string }
+			ssh.Unmarshal(req.Payload, &cmd)
+			req.Reply(true, nil)
+			time.Sleep(s.delay)
+			fmt.Fprintf(ch, "ran: %s", cmd.Command)
+			ch.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{0}))
+			return
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+func writeTestKey(t *testing.T, dir string) string {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("error generating key: %v", err)
+	}
+	path := filepath.Join(dir, "id_rsa")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err = ioutil.WriteFile(path, pemBytes, 0600); err != nil {
+		t.Fatalf("error writing key: %v", err)
+	}
+	return path
+}
+
+func TestNativeClientReusesConnection(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-ssh")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	defer CloseConnections()
+	SetKnownHostsFile(filepath.Join(tmp, KnownHostsFilename))
+	key := writeTestKey(t, tmp)
+	s := newTestServer(t)
+	defer s.listener.Close()
+	host, port := s.hostPort()
+
+	for i := 0; i < 3; i++ {
+		client, err := NewClient(host, port, "alice", key, nil)
+		if err != nil {
+			t.Fatalf("unexpected error creating client: %v", err)
+		}
+		out, err := client.Output(i%2 == 0, "cat", "/etc/kismatic-version")
+		if err != nil {
+			t.Fatalf("unexpected error running command: %v", err)
+		}
+		if out != "ran: cat /etc/kismatic-version" {
+			t.Errorf("unexpected output %q", out)
+		}
+	}
+	if c := s.connections(); c != 1 {
+		t.Errorf("expected a single connection to the server, but got %d", c)
+	}
+}
+
+func TestNativeClientBastion(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-ssh")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	defer CloseConnections()
+	SetKnownHostsFile(filepath.Join(tmp, KnownHostsFilename))
+	key := writeTestKey(t, tmp)
+	bastion := newTestServer(t)
+	defer bastion.listener.Close()
+	node := newTestServer(t)
+	defer node.listener.Close()
+	bastionHost, bastionPort := bastion.hostPort()
+	host, port := node.hostPort()
+
+	b := &Bastion{Host: bastionHost, Port: bastionPort, User: "jump", Key: key}
+	if err = TestConnection(host, port, "alice", key, b); err != nil {
+		t.Fatalf("unexpected error connecting through the bastion: %v", err)
+	}
+	if bastion.connections() != 1 || node.connections() != 1 {
+		t.Errorf("expected the node to be accessed through the bastion, but got %d bastion and %d node connections", bastion.connections(), node.connections())
+	}
+}
+
+func TestNativeClientContextDeadline(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "ket-test-ssh")
+	if err != nil {
+		t.Fatalf("error creating temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmp)
+	defer CloseConnections()
+	SetKnownHostsFile(filepath.Join(tmp, KnownHostsFilename))
+	key := writeTestKey(t, tmp)
+	s := newTestServer(t)
+	defer s.listener.Close()
+	s.delay = 5 * time.Second
+	host, port := s.hostPort()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	client, err := newClientContext(ctx, host, port, "alice", key, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	start := time.Now()
+	if _, err = client.Output(false, "sleep"); err != context.DeadlineExceeded {
+		t.Errorf("expected the command to fail with a deadline error, but got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("expected the command to be interrupted when the deadline is exceeded")
+	}
+}
@@ -1,6 +1,7 @@
 package ssh
 
 import (
+	"context"
 	"crypto/x509"
 	"encoding/pem"
 	"fmt"
@@ -8,6 +9,8 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"strings"
+	"sync"
 
 	"golang.org/x/crypto/ssh"
 )
@@ -15,8 +18,6 @@ import (
 var baseSSHArgs = []string{
 	"-F", "/dev/null",
 	"-o", "PasswordAuthentication=no",
-	"-o", "StrictHostKeyChecking=no",
-	"-o", "UserKnownHostsFile=/dev/null",
 	"-o", "LogLevel=quiet", // suppress "Warning: Permanently added '[localhost]:2022' (ECDSA) to the list of known hosts."
 	"-o", "ConnectionAttempts=3", // retry 3 times if SSH connection fails
 	"-o", "ConnectTimeout=10", // timeout after 10 seconds
@@ -24,52 +25,158 @@ var baseSSHArgs = []string{
 	"-o", "ControlPath=none",
 }
 
+// ClientEnvVar is the environment variable that selects the SSH client implementation.
+// The native client is used by default, and the external ssh binary is used when
+// the variable is set to "external".
+const ClientEnvVar = "KISMATIC_SSH_CLIENT"
+
 type Client interface {
 	Output(pty bool, args ...string) (string, error)
 	Shell(pty bool, args ...string) error
+	// Close releases the resources held by the client
+	Close() error
+}
+
+// Bastion is a host through which the SSH connections to the target host are proxied
+type Bastion struct {
+	Host string
+	Port int
+	User string
+	Key  string
 }
 
 type ExternalClient struct {
 	BaseArgs   []string
 	BinaryPath string
 	cmd        *exec.Cmd
+	ctx        context.Context
+	// encryptedKeys are served by a local ssh-agent, which is started on the first
+	// command and stopped when the client is closed
+	encryptedKeys []string
+	agentMu       sync.Mutex
+	agent         *LocalAgent
 }
 
 // TestConnection connects to ip:port as user with key and immediately exits.
-func TestConnection(ip string, port int, user, key string) error {
-	client, err := NewClient(ip, port, user, key)
+// The connection is proxied through the bastion, if one is given.
+func TestConnection(ip string, port int, user, key string, bastion *Bastion) error {
+	client, err := NewClient(ip, port, user, key, bastion)
 	if err != nil {
 		return err
 	}
 
-	return client.Shell(false, "exit")
+	defer client.Close()
+	_, err = client.Output(false, "exit")
+	return err
+}
+
+// NewClient returns an SSH client for running commands on the host.
+// When a bastion is given, the connection to the host is proxied through the bastion.
+func NewClient(host string, port int, user string, key string, bastion *Bastion) (Client, error) {
+	return newClientContext(context.Background(), host, port, user, key, bastion)
 }
 
-// NewClient verifies ssh is available in the PATH and returns an SSH client
-func NewClient(host string, port int, user string, key string) (Client, error) {
-	if err := ValidUnencryptedPrivateKey(key); err != nil {
+// newClientContext returns an SSH client for running commands on the host. Connecting
+// to the host and running commands fail once the context is done.
+// The client uses a pooled connection to the host, unless the external ssh binary
+// is selected with the KISMATIC_SSH_CLIENT environment variable.
+func newClientContext(ctx context.Context, host string, port int, user string, key string, bastion *Bastion) (Client, error) {
+	if err := validClientKeys(key, bastion); err != nil {
 		return nil, err
 	}
+	if os.Getenv(ClientEnvVar) == "external" {
+		return newExternalClientContext(ctx, host, port, user, key, bastion)
+	}
+	return newNativeClient(ctx, host, port, user, key, bastion), nil
+}
 
+// NewExternalClient verifies ssh is available in the PATH and returns an SSH client
+// that runs the ssh binary
+func NewExternalClient(host string, port int, user string, key string, bastion *Bastion) (Client, error) {
+	if err := validClientKeys(key, bastion); err != nil {
+		return nil, err
+	}
+	return newExternalClientContext(context.Background(), host, port, user, key, bastion)
+}
+
+func newExternalClientContext(ctx context.Context, host string, port int, user string, key string, bastion *Bastion) (*ExternalClient, error) {
 	sshBinaryPath, err := exec.LookPath("ssh")
 	if err != nil {
 		return nil, fmt.Errorf("command not found: ssh")
 	}
 
-	return newExternalClient(sshBinaryPath, user, host, port, key)
+	// the ssh binary only connects to hosts with a known host key
+	if err = ScanHostKey(host, port, bastion); err != nil {
+		return nil, err
+	}
+	if bastion != nil {
+		if err = ScanHostKey(bastion.Host, bastion.Port, nil); err != nil {
+			return nil, err
+		}
+	}
+	knownHosts, err := KnownHostsFile()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newExternalClient(sshBinaryPath, user, host, port, key, bastion, knownHosts)
+	if err != nil {
+		return nil, err
+	}
+	client.ctx = ctx
+	return client, nil
 }
 
-func newExternalClient(sshBinaryPath string, user string, host string, port int, key string) (*ExternalClient, error) {
+// validClientKeys verifies the keys can be used for connecting. A key can be omitted
+// when an ssh-agent is running.
+func validClientKeys(key string, bastion *Bastion) error {
+	if err := validClientKey(key); err != nil {
+		return err
+	}
+	if bastion != nil {
+		if err := validClientKey(bastion.Key); err != nil {
+			return fmt.Errorf("bastion SSH key: %v", err)
+		}
+	}
+	return nil
+}
+
+func validClientKey(key string) error {
+	if key == "" {
+		if !AgentAvailable() {
+			return fmt.Errorf("an SSH key is required when no ssh-agent is available at %s", AgentEnvVar)
+		}
+		return nil
+	}
+	return ValidPrivateKey(key)
+}
+
+func newExternalClient(sshBinaryPath string, user string, host string, port int, key string, bastion *Bastion, knownHostsFile string) (*ExternalClient, error) {
 	// Get defailt args with user and host
-	args := append(baseSSHArgs, fmt.Sprintf("%s@%s", user, host))
+	args := append(baseSSHArgs, hostKeyArgs(knownHostsFile)...)
+	args = append(args, fmt.Sprintf("%s@%s", user, host))
 	// set port
 	args = append(args, "-p", fmt.Sprintf("%d", port))
-	// set key
-	args = append(args, "-i", key)
+	// set key, the ssh-agent is used when no key is given
+	if key != "" {
+		args = append(args, "-i", key)
+	}
+	// proxy the connection through the bastion
+	if bastion != nil {
+		args = append(args, "-o", "ProxyCommand="+proxyCommand(sshBinaryPath, bastion, knownHostsFile))
+	}
 
 	client := &ExternalClient{
 		BinaryPath: sshBinaryPath,
 		BaseArgs:   args,
+		ctx:        context.Background(),
+	}
+	// encrypted keys are served by a local ssh-agent, so that the
+	// passphrase is only asked for once
+	for _, k := range []string{key, bastionKey(bastion)} {
+		if k != "" && EncryptedKey(k) {
+			client.encryptedKeys = append(client.encryptedKeys, k)
+		}
 	}
 
 	return client, nil
@@ -78,7 +185,10 @@ func newExternalClient(sshBinaryPath string, user string, host string, port int,
 // Output runs the ssh command and returns the output
 func (client *ExternalClient) Output(pty bool, args ...string) (string, error) {
 	args = append(client.BaseArgs, args...)
-	cmd := getSSHCmd(client.BinaryPath, pty, args...)
+	cmd := getSSHCmd(client.ctx, client.BinaryPath, pty, args...)
+	if err := client.useAgent(cmd); err != nil {
+		return "", err
+	}
 	// for pseudo-tty and sudo to work correctly Stdin must be set to os.Stdin
 	if pty {
 		cmd.Stdin = os.Stdin
@@ -90,22 +200,109 @@ func (client *ExternalClient) Output(pty bool, args ...string) (string, error) {
 // Shell runs the ssh command, binding Stdin, Stdout and Stderr
 func (client *ExternalClient) Shell(pty bool, args ...string) error {
 	args = append(client.BaseArgs, args...)
-	cmd := getSSHCmd(client.BinaryPath, pty, args...)
+	cmd := getSSHCmd(client.ctx, client.BinaryPath, pty, args...)
+	if err := client.useAgent(cmd); err != nil {
+		return err
+	}
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
 }
 
-func getSSHCmd(binaryPath string, pty bool, args ...string) *exec.Cmd {
+// useAgent serves the encrypted keys to the ssh command through the local ssh-agent
+// of the client, starting it if needed
+func (client *ExternalClient) useAgent(cmd *exec.Cmd) error {
+	if len(client.encryptedKeys) == 0 {
+		return nil
+	}
+	client.agentMu.Lock()
+	defer client.agentMu.Unlock()
+	if client.agent == nil {
+		a, err := StartAgent(client.encryptedKeys...)
+		if err != nil {
+			return err
+		}
+		client.agent = a
+	}
+	cmd.Env = append(os.Environ(), AgentEnvVar+"="+client.agent.Socket)
+	return nil
+}
+
+// Close stops the local ssh-agent of the client, if it was started
+func (client *ExternalClient) Close() error {
+	client.agentMu.Lock()
+	defer client.agentMu.Unlock()
+	if client.agent == nil {
+		return nil
+	}
+	err := client.agent.Close()
+	client.agent = nil
+	return err
+}
+
+func bastionKey(b *Bastion) string {
+	if b == nil {
+		return ""
+	}
+	return b.Key
+}
+
+// hostKeyArgs returns the arguments that verify the host key against the known_hosts file
+func hostKeyArgs(knownHostsFile string) []string {
+	return []string{
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile=" + knownHostsFile,
+	}
+}
+
+// proxyCommand returns the command that forwards the SSH connection to the
+// target host through the bastion
+func proxyCommand(sshBinaryPath string, b *Bastion, knownHostsFile string) string {
+	args := append([]string{sshBinaryPath}, baseSSHArgs...)
+	args = append(args, hostKeyArgs(knownHostsFile)...)
+	if b.Key != "" {
+		args = append(args, "-i", b.Key)
+	}
+	args = append(args, "-p", fmt.Sprintf("%d", b.Port), "-W", "%h:%p", fmt.Sprintf("%s@%s", b.User, b.Host))
+	for i, a := range args {
+		args[i] = shellQuote(a)
+	}
+	return strings.Join(args, " ")
+}
+
+// shellQuote quotes the argument for the shell that runs the proxy command
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !strings.ContainsRune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789@%+=:,./_-", r)
+	}) == -1 {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'"'"'`, -1) + "'"
+}
+
+func getSSHCmd(ctx context.Context, binaryPath string, pty bool, args ...string) *exec.Cmd {
 	if pty {
 		args = append([]string{"-t"}, args...)
 	}
-	return exec.Command(binaryPath, args...)
+	return exec.CommandContext(ctx, binaryPath, args...)
 }
 
-// ValidUnencryptedPrivateKey parses SSH private key
+// ValidUnencryptedPrivateKey parses SSH private key, and rejects keys that are
+// protected by a passphrase
 func ValidUnencryptedPrivateKey(file string) error {
+	if err := ValidPrivateKey(file); err != nil {
+		return err
+	}
+	if EncryptedKey(file) {
+		return fmt.Errorf("Encrypted SSH key is not permitted")
+	}
+	return nil
+}
+
+// ValidPrivateKey verifies the SSH private key file is readable and has strict permissions.
+// Unencrypted keys are parsed, while encrypted keys are only decrypted when they are used.
+func ValidPrivateKey(file string) error {
 	// Check private key before use it
 	fi, err := os.Stat(file)
 	if err != nil {
@@ -123,13 +320,10 @@ func ValidUnencryptedPrivateKey(file string) error {
 		return fmt.Errorf("Parse SSH key error")
 	}
 
-	if isEncrypted {
-		return fmt.Errorf("Encrypted SSH key is not permitted")
-	}
-
-	_, err = ssh.ParsePrivateKey(buffer)
-	if err != nil {
-		return fmt.Errorf("Parse SSH key error: %v", err)
+	if !isEncrypted {
+		if _, err = ssh.ParsePrivateKey(buffer); err != nil {
+			return fmt.Errorf("Parse SSH key error: %v", err)
+		}
 	}
 
 	if runtime.GOOS != "windows" {
@@ -156,5 +350,11 @@ func isEncrypted(buffer []byte) (bool, error) {
 		return false, fmt.Errorf("Parse SSH key error")
 	}
 
-	return x509.IsEncryptedPEMBlock(block), nil
+	if x509.IsEncryptedPEMBlock(block) {
+		return true, nil
+	}
+	// keys in the OpenSSH format are encrypted in the key data, instead of the PEM headers
+	_, err := ssh.ParseRawPrivateKey(buffer)
+	_, missingPassphrase := err.(*ssh.PassphraseMissingError)
+	return missingPassphrase, nil
 }